@@ -0,0 +1,140 @@
+// Command rollout-trial runs a single trial and prints its result as JSON.
+//
+// It exists so that a single trial's execution doesn't require going
+// through NewJobOrchestrator/Run: a k8s fan-out, a queue worker, or a local
+// script can each shell out to this binary with a trial spec and get back a
+// models.TrialResult, independent of how the rest of the job is scheduled.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/spachava753/rollout/internal/executor"
+	"github.com/spachava753/rollout/internal/models"
+	"github.com/spachava753/rollout/internal/task"
+)
+
+// trialSpec is the input format rollout-trial reads: a job's configuration
+// (for the settings an individual trial execution needs, e.g. Verifier,
+// Environment, Diff, Redaction) plus the per-trial specifics that
+// NewJobOrchestrator would otherwise fan out across trials itself.
+//
+// TaskPath points at a task directory rather than embedding a models.Task
+// directly, since Task.FS isn't JSON-serializable; rollout-trial loads it
+// with task.NewLoader the same way the local orchestrator does.
+type trialSpec struct {
+	Job       models.JobConfig `json:"job"`
+	TaskPath  string           `json:"task_path"`
+	Agent     models.Agent     `json:"agent"`
+	Dataset   string           `json:"dataset"`
+	Attempt   int              `json:"attempt"`
+	OutputDir string           `json:"output_dir"`
+	// MirrorAddr, if set, is passed to executor.BuildProvider so trials run
+	// against a registry mirror started separately and shared across a
+	// job's trials; leave empty to pull images directly.
+	MirrorAddr string `json:"mirror_addr,omitempty"`
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: rollout-trial [--debug] <trial-spec.json>")
+}
+
+func main() {
+	fs := flag.NewFlagSet("rollout-trial", flag.ExitOnError)
+	debug := fs.Bool("debug", false, "drop into an interactive shell in the trial's environment if its install or execute phase fails")
+	fs.Parse(os.Args[1:])
+	if fs.NArg() != 1 {
+		usage()
+		os.Exit(1)
+	}
+
+	specData, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		slog.Error("reading trial spec", "error", err)
+		os.Exit(1)
+	}
+	var spec trialSpec
+	if err := json.Unmarshal(specData, &spec); err != nil {
+		slog.Error("parsing trial spec", "error", err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	result, err := runTrial(ctx, spec, *debug)
+	if err != nil {
+		slog.Error("trial failed", "error", err)
+		os.Exit(1)
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		slog.Error("marshalling result", "error", err)
+		os.Exit(1)
+	}
+
+	if spec.OutputDir != "" {
+		if err := os.MkdirAll(spec.OutputDir, 0755); err != nil {
+			slog.Error("creating output directory", "error", err)
+			os.Exit(1)
+		}
+		if err := os.WriteFile(spec.OutputDir+"/result.json", resultJSON, 0644); err != nil {
+			slog.Error("writing result.json", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	fmt.Println(string(resultJSON))
+
+	if result.Error != nil {
+		os.Exit(1)
+	}
+}
+
+func runTrial(ctx context.Context, spec trialSpec, debug bool) (*models.TrialResult, error) {
+	loadedTask, err := task.NewLoader().LoadTask(ctx, spec.TaskPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading task: %w", err)
+	}
+
+	provider, err := executor.BuildProvider(spec.Job.Environment, spec.MirrorAddr)
+	if err != nil {
+		return nil, fmt.Errorf("building provider: %w", err)
+	}
+
+	trialExecutor := executor.DefaultTrialExecutorFunc(spec.Job)
+	if setter, ok := trialExecutor.(interface{ SetDebug(bool) }); ok {
+		setter.SetDebug(debug)
+	}
+
+	trial := models.Trial{
+		ID:        fmt.Sprintf("%s-%s-%d", loadedTask.Name, spec.Agent.Name, spec.Attempt),
+		Task:      *loadedTask,
+		Agent:     spec.Agent,
+		Dataset:   spec.Dataset,
+		Attempt:   spec.Attempt,
+		OutputDir: spec.OutputDir,
+	}
+
+	result, err := trialExecutor.Execute(ctx, trial, provider)
+	if err != nil {
+		return nil, fmt.Errorf("executing trial: %w", err)
+	}
+
+	// rollout-trial runs a single attempt with no retry wrapper (unlike
+	// NewJobOrchestrator's executeWithRetry), so the only statuses possible
+	// here are completed and failed.
+	if result.Error != nil {
+		result.Status = models.StatusFailed
+	} else {
+		result.Status = models.StatusCompleted
+	}
+	result.Labels = spec.Job.Labels
+
+	return result, nil
+}
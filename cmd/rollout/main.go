@@ -2,22 +2,103 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
+	"time"
 
+	"github.com/spachava753/rollout/internal/bench"
+	"github.com/spachava753/rollout/internal/bisect"
+	"github.com/spachava753/rollout/internal/bundle"
+	"github.com/spachava753/rollout/internal/config"
+	"github.com/spachava753/rollout/internal/environment/docker"
 	"github.com/spachava753/rollout/internal/executor"
+	"github.com/spachava753/rollout/internal/mockllm"
+	"github.com/spachava753/rollout/internal/models"
+	"github.com/spachava753/rollout/internal/replay"
+	"github.com/spachava753/rollout/internal/report"
+	"github.com/spachava753/rollout/internal/retention"
 )
 
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: rollout [--locked] [--offline] [--debug] <job.yaml>")
+	fmt.Fprintln(os.Stderr, "       rollout gc --images [--prefix <prefix>]")
+	fmt.Fprintln(os.Stderr, "       rollout clean --apply-policy <job.yaml>")
+	fmt.Fprintln(os.Stderr, "       rollout replay <trial-dir>")
+	fmt.Fprintln(os.Stderr, "       rollout task bundle [--output <path>] <task-dir>")
+	fmt.Fprintln(os.Stderr, "       rollout mock-llm [--addr <addr>] [--fixtures <dir>] [--stub-file <path>]")
+	fmt.Fprintln(os.Stderr, "       rollout bench [--trials <n>] [--workers <n>]")
+	fmt.Fprintln(os.Stderr, "       rollout trials [--watch] <job-dir>")
+	fmt.Fprintln(os.Stderr, "       rollout report [--rows task|agent|dataset] [--cols task|agent|dataset] [--html <path>] <job-dir>")
+	fmt.Fprintln(os.Stderr, "       rollout bisect --git-url <url> --good <commit> --bad <commit> [--task-path <path>] [--agent <name>] <job.yaml>")
+}
+
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Fprintln(os.Stderr, "usage: rollout <job.yaml>")
+		usage()
 		os.Exit(1)
 	}
 
-	configPath := os.Args[1]
+	if os.Args[1] == "gc" {
+		runGC(os.Args[2:])
+		return
+	}
+
+	if os.Args[1] == "clean" {
+		runClean(os.Args[2:])
+		return
+	}
+
+	if os.Args[1] == "replay" {
+		runReplay(os.Args[2:])
+		return
+	}
+
+	if os.Args[1] == "task" {
+		runTask(os.Args[2:])
+		return
+	}
+
+	if os.Args[1] == "mock-llm" {
+		runMockLLM(os.Args[2:])
+		return
+	}
+
+	if os.Args[1] == "bench" {
+		runBench(os.Args[2:])
+		return
+	}
+
+	if os.Args[1] == "trials" {
+		runTrials(os.Args[2:])
+		return
+	}
+
+	if os.Args[1] == "report" {
+		runReport(os.Args[2:])
+		return
+	}
+
+	if os.Args[1] == "bisect" {
+		runBisect(os.Args[2:])
+		return
+	}
+
+	fs := flag.NewFlagSet("rollout", flag.ExitOnError)
+	locked := fs.Bool("locked", false, "require rollout.lock and pin registry datasets to exactly the commits it records")
+	offline := fs.Bool("offline", false, "forbid network access (registry clones, URLs, image pulls), failing fast instead")
+	debug := fs.Bool("debug", false, "drop into an interactive shell in a trial's environment if its install or execute phase fails; use with a single trial")
+	fs.Parse(os.Args[1:])
+	if fs.NArg() != 1 {
+		usage()
+		os.Exit(1)
+	}
+	configPath := fs.Arg(0)
 
 	// Setup context with manual signal handling
 	ctx, cancel := context.WithCancel(context.Background())
@@ -37,7 +118,7 @@ func main() {
 		cancel()
 	}()
 
-	result, err := executor.RunFromConfig(ctx, configPath)
+	result, err := executor.RunFromConfig(ctx, configPath, *locked, *offline, *debug)
 	if err != nil {
 		slog.Error("job failed", "error", err)
 		os.Exit(1)
@@ -52,7 +133,406 @@ func main() {
 	fmt.Printf("Mean reward: %.4f\n", result.MeanReward)
 	fmt.Printf("Duration: %.2fs\n", result.TotalDurationSec)
 
+	if len(result.Validation) > 0 {
+		fmt.Printf("\nTask validation (mode: validate):\n")
+		for _, v := range result.Validation {
+			if v.Error != "" {
+				fmt.Printf("  %s / %s: FAILED: %s\n", v.DatasetName, v.TaskName, v.Error)
+				continue
+			}
+			status := "FAILED"
+			if v.Passed {
+				status = "PASSED"
+			}
+			fmt.Printf("  %s / %s: %s (reward=%v, %.2fs)\n", v.DatasetName, v.TaskName, status, v.Reward, v.DurationSec)
+		}
+	}
+
+	if len(result.QuarantinedTasks) > 0 {
+		fmt.Printf("\nQuarantined tasks (oracle sanity check failed, excluded from agent trials):\n")
+		for _, q := range result.QuarantinedTasks {
+			fmt.Printf("  %s / %s: %s\n", q.DatasetName, q.TaskName, q.Reason)
+		}
+	}
+
+	if len(result.Flakiness) > 0 {
+		fmt.Printf("\nFlaky tasks (attempts disagreed on pass/fail):\n")
+		for _, f := range result.Flakiness {
+			fmt.Printf("  %s / %s / %s: %d/%d passed\n", f.AgentName, f.DatasetName, f.TaskName, f.Passes, f.Attempts)
+		}
+	}
+
+	if len(result.ImageReports) > 0 {
+		fmt.Printf("\nImage reports:\n")
+		for _, r := range result.ImageReports {
+			if r.Error != "" {
+				fmt.Printf("  %s (%s): scan failed: %s\n", r.ImageRef, r.TaskName, r.Error)
+				continue
+			}
+			fmt.Printf("  %s (%s): %.1fMB, %d critical, %d high, %d medium, %d low\n",
+				r.ImageRef, r.TaskName, float64(r.SizeBytes)/(1024*1024), r.CriticalCVEs, r.HighCVEs, r.MediumCVEs, r.LowCVEs)
+		}
+	}
+
 	if result.FailedTrials > 0 || result.Cancelled {
 		os.Exit(1)
 	}
 }
+
+// runGC implements `rollout gc --images`, which reclaims disk space used by
+// images built with environment.image_cache.keep.
+func runGC(args []string) {
+	fs := flag.NewFlagSet("gc", flag.ExitOnError)
+	images := fs.Bool("images", false, "remove cached task images")
+	prefix := fs.String("prefix", "rollout-cache", "image tag prefix to remove")
+	fs.Parse(args)
+
+	if !*images {
+		usage()
+		os.Exit(1)
+	}
+
+	n, err := docker.PruneImages(context.Background(), *prefix)
+	if err != nil {
+		slog.Error("gc failed", "error", err)
+		os.Exit(1)
+	}
+	fmt.Printf("removed %d cached image(s)\n", n)
+}
+
+// runClean implements `rollout clean --apply-policy <job.yaml>`, which
+// prunes or archives old job result directories under the job's jobs_dir
+// according to its retention config.
+func runClean(args []string) {
+	fs := flag.NewFlagSet("clean", flag.ExitOnError)
+	applyPolicy := fs.Bool("apply-policy", false, "apply the job's retention policy to its jobs_dir")
+	fs.Parse(args)
+
+	if !*applyPolicy || fs.NArg() != 1 {
+		usage()
+		os.Exit(1)
+	}
+
+	cfg, err := config.LoadJobConfig(fs.Arg(0))
+	if err != nil {
+		slog.Error("clean failed", "error", err)
+		os.Exit(1)
+	}
+
+	result, err := retention.Apply(cfg.JobsDir, cfg.Retention)
+	if err != nil {
+		slog.Error("clean failed", "error", err)
+		os.Exit(1)
+	}
+	fmt.Printf("archived %d, removed %d job director(y/ies)\n", result.Archived, result.Removed)
+}
+
+// runReplay implements `rollout replay <trial-dir>`, which rebuilds the
+// environment a recorded trial ran against and re-runs its verifier.
+func runReplay(args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		usage()
+		os.Exit(1)
+	}
+
+	provider, err := docker.NewProvider(docker.ProviderConfig{})
+	if err != nil {
+		slog.Error("creating docker provider", "error", err)
+		os.Exit(1)
+	}
+
+	result, err := replay.Replay(context.Background(), fs.Arg(0), provider)
+	if err != nil {
+		slog.Error("replay failed", "error", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("verifier exit code: %d\n", result.ExitCode)
+	if result.Reward != nil {
+		fmt.Printf("reward: %.4f\n", *result.Reward)
+	} else {
+		fmt.Println("reward: (none reported)")
+	}
+	fmt.Printf("patch applied: %v\n", result.PatchApplied)
+}
+
+// runTask dispatches rollout's "task" subcommands.
+func runTask(args []string) {
+	if len(args) < 1 || args[0] != "bundle" {
+		usage()
+		os.Exit(1)
+	}
+	runTaskBundle(args[1:])
+}
+
+// runTaskBundle implements `rollout task bundle [--output <path>] <task-dir>`,
+// which exports a task's environment and tests as a reproducible tarball
+// independent of its git repo.
+func runTaskBundle(args []string) {
+	fs := flag.NewFlagSet("task bundle", flag.ExitOnError)
+	output := fs.String("output", "", "output tarball path (default: <task-name>-bundle.tar.gz in the current directory)")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		usage()
+		os.Exit(1)
+	}
+
+	result, err := bundle.Bundle(context.Background(), fs.Arg(0), *output)
+	if err != nil {
+		slog.Error("bundle failed", "error", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("wrote %s (%d files)\n", result.Path, result.Files)
+	for ref, digest := range result.BaseImages {
+		if digest == "" {
+			fmt.Printf("  base image %s: digest resolution failed\n", ref)
+			continue
+		}
+		fmt.Printf("  base image %s -> %s\n", ref, digest)
+	}
+}
+
+// runMockLLM implements `rollout mock-llm`, which starts a minimal
+// OpenAI-chat-completions-compatible server serving recorded or stubbed
+// responses instead of calling a real LLM API. Point an agent at it with an
+// env var in job.yaml's agent.env (e.g. OPENAI_BASE_URL), for fully
+// offline, deterministic integration tests of agents and of rollout
+// itself. Runs until interrupted.
+func runMockLLM(args []string) {
+	fs := flag.NewFlagSet("mock-llm", flag.ExitOnError)
+	addr := fs.String("addr", "127.0.0.1:0", "address to listen on")
+	fixtures := fs.String("fixtures", "", "directory of recorded responses, named <sha256-of-request-body-hex>.json")
+	stubFile := fs.String("stub-file", "", "file whose contents are served for requests with no matching fixture")
+	fs.Parse(args)
+
+	var stub string
+	if *stubFile != "" {
+		data, err := os.ReadFile(*stubFile)
+		if err != nil {
+			slog.Error("mock-llm failed", "error", err)
+			os.Exit(1)
+		}
+		stub = string(data)
+	}
+
+	srv := mockllm.NewServer(mockllm.Config{Addr: *addr, FixturesDir: *fixtures, Stub: stub})
+	baseURL, err := srv.Start()
+	if err != nil {
+		slog.Error("mock-llm failed", "error", err)
+		os.Exit(1)
+	}
+	defer srv.Stop()
+	fmt.Printf("mock LLM server listening at %s\n", baseURL)
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	<-sigChan
+}
+
+// runBench implements `rollout bench`, which runs a synthetic "N tiny
+// trials" workload against the in-memory fake environment provider and
+// reports scheduling throughput and memory per trial, so performance
+// regressions in the job pipeline itself (not any particular agent or
+// provider) can be caught without docker or network access.
+func runBench(args []string) {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	trials := fs.Int("trials", 100, "number of synthetic trials to run")
+	workers := fs.Int("workers", 8, "number of trials to run concurrently")
+	fs.Parse(args)
+
+	result, err := bench.Run(context.Background(), bench.Config{Trials: *trials, Workers: *workers})
+	if err != nil {
+		slog.Error("bench failed", "error", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("trials: %d (completed %d, failed %d)\n", result.Trials, result.CompletedTrials, result.FailedTrials)
+	fmt.Printf("duration: %s\n", result.Duration)
+	fmt.Printf("throughput: %.1f trials/sec\n", result.TrialsPerSec)
+	fmt.Printf("memory: %d bytes/trial\n", result.BytesPerTrial)
+}
+
+// runTrials implements `rollout trials [--watch] <job-dir>`, which reads the
+// running job's jobDir/status.json - written by executor.StatusTracker as
+// trials progress - and lists every in-flight trial's phase, time spent in
+// that phase, environment, and last few lines of output. Unlike `rollout
+// bench`, this reads a live job's state from another process rather than
+// running anything itself, the same way `rollout replay` reads a finished
+// trial's output directory.
+func runTrials(args []string) {
+	fs := flag.NewFlagSet("trials", flag.ExitOnError)
+	watch := fs.Bool("watch", false, "keep reprinting the snapshot every second until interrupted")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		usage()
+		os.Exit(1)
+	}
+	statusPath := filepath.Join(fs.Arg(0), executor.StatusFileName)
+
+	for {
+		if err := printTrialStatus(statusPath); err != nil {
+			slog.Error("reading trial status", "path", statusPath, "error", err)
+			os.Exit(1)
+		}
+		if !*watch {
+			return
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// printTrialStatus reads and prints one snapshot from statusPath.
+func printTrialStatus(statusPath string) error {
+	data, err := os.ReadFile(statusPath)
+	if err != nil {
+		return err
+	}
+	var trials []executor.TrialStatus
+	if err := json.Unmarshal(data, &trials); err != nil {
+		return fmt.Errorf("parsing %s: %w", statusPath, err)
+	}
+
+	if len(trials) == 0 {
+		fmt.Println("no trials currently in flight")
+		return nil
+	}
+	for _, t := range trials {
+		fmt.Printf("%s/%s/%s attempt=%d phase=%s elapsed=%s env=%s/%s\n",
+			t.DatasetName, t.TaskName, t.AgentName, t.Attempt, t.Phase,
+			time.Since(t.PhaseStartedAt).Round(time.Second), t.EnvironmentProvider, t.EnvironmentID)
+		for _, line := range t.RecentOutput {
+			fmt.Printf("    %s\n", line)
+		}
+	}
+	return nil
+}
+
+// runReport implements `rollout report`, which pivots a finished job's
+// result.json by two of {task, agent, dataset} (rows=tasks, cols=agents by
+// default) and prints the mean reward per cell, so a multi-agent
+// multi-dataset job is interpretable at a glance instead of scanning
+// JobResult.Results trial by trial. --html additionally writes a heatmap
+// rendering of the same pivot.
+func runReport(args []string) {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	rows := fs.String("rows", "task", "pivot row axis: task, agent, or dataset")
+	cols := fs.String("cols", "agent", "pivot column axis: task, agent, or dataset")
+	htmlPath := fs.String("html", "", "also write an HTML heatmap rendering of the pivot to this path")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		usage()
+		os.Exit(1)
+	}
+
+	resultPath := filepath.Join(fs.Arg(0), "result.json")
+	data, err := os.ReadFile(resultPath)
+	if err != nil {
+		slog.Error("reading job result", "path", resultPath, "error", err)
+		os.Exit(1)
+	}
+	var jobResult models.JobResult
+	if err := json.Unmarshal(data, &jobResult); err != nil {
+		slog.Error("parsing job result", "path", resultPath, "error", err)
+		os.Exit(1)
+	}
+
+	pivot, err := report.Build(jobResult.Results, report.Axis(*rows), report.Axis(*cols))
+	if err != nil {
+		slog.Error("building report", "error", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%-20s", "")
+	for _, col := range pivot.Cols {
+		fmt.Printf("%-20s", col)
+	}
+	fmt.Println()
+	for _, row := range pivot.Rows {
+		fmt.Printf("%-20s", row)
+		for _, col := range pivot.Cols {
+			cell, ok := pivot.Cells[row][col]
+			if !ok {
+				fmt.Printf("%-20s", "-")
+				continue
+			}
+			fmt.Printf("%-20s", fmt.Sprintf("%.2f (%d)", cell.MeanReward, cell.Trials))
+		}
+		fmt.Println()
+	}
+
+	if *htmlPath != "" {
+		if err := os.WriteFile(*htmlPath, []byte(pivot.RenderHTML()), 0644); err != nil {
+			slog.Error("writing HTML report", "path", *htmlPath, "error", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// runBisect implements `rollout bisect`, which narrows a reward regression
+// in a registry task down to the commit that introduced it by binary
+// searching the task repo's commit history, rerunning the oracle (or a
+// named agent) at each candidate the same way `rollout bench` drives a
+// synthetic job through the real pipeline. <job.yaml> supplies the
+// environment block each candidate commit's trial runs against; its own
+// datasets and agents are otherwise ignored.
+func runBisect(args []string) {
+	fs := flag.NewFlagSet("bisect", flag.ExitOnError)
+	gitURL := fs.String("git-url", "", "task repository to bisect")
+	taskPath := fs.String("task-path", "", "path within the repository to the task directory")
+	good := fs.String("good", "", "commit known not to exhibit the regression")
+	bad := fs.String("bad", "", "commit known to exhibit the regression")
+	agentName := fs.String("agent", "", "agent to rerun at each candidate commit; defaults to the oracle")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 || *gitURL == "" || *good == "" || *bad == "" {
+		usage()
+		os.Exit(1)
+	}
+
+	jobCfg, err := config.LoadJobConfig(fs.Arg(0))
+	if err != nil {
+		slog.Error("loading job config", "error", err)
+		os.Exit(1)
+	}
+
+	cfg := bisect.Config{
+		GitURL:      *gitURL,
+		TaskPath:    *taskPath,
+		GoodCommit:  *good,
+		BadCommit:   *bad,
+		Environment: jobCfg.Environment,
+	}
+	if *agentName != "" {
+		cfg.Agent = models.Agent{Name: *agentName}
+	}
+
+	result, err := bisect.Run(context.Background(), cfg)
+	if err != nil {
+		slog.Error("bisect failed", "error", err)
+		os.Exit(1)
+	}
+
+	for _, c := range result.Checked {
+		status := "good"
+		if !c.Passed {
+			status = "bad"
+		}
+		reward := "n/a"
+		if c.Reward != nil {
+			reward = fmt.Sprintf("%.2f", *c.Reward)
+		}
+		if c.Error != "" {
+			fmt.Printf("%s: error: %s\n", c.Commit, c.Error)
+			continue
+		}
+		fmt.Printf("%s: %s (reward=%s)\n", c.Commit, status, reward)
+	}
+	fmt.Printf("first bad commit: %s\n", result.FirstBadCommit)
+}
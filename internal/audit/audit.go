@@ -0,0 +1,96 @@
+// Package audit records every provider-level operation an environment
+// provider performs during a job (image builds, container creates, execs,
+// copies, destroys) into a per-job audit.jsonl, for compliance review and
+// for tracing where a job's time and cost went after the fact.
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileName is the audit log's filename within a job directory.
+const FileName = "audit.jsonl"
+
+// Operation names recorded in Entry.Op.
+const (
+	OpImageBuild      = "image_build"
+	OpContainerCreate = "container_create"
+	OpExec            = "exec"
+	OpCopyTo          = "copy_to"
+	OpCopyFrom        = "copy_from"
+	OpDestroy         = "destroy"
+)
+
+// Entry is one line of audit.jsonl.
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Provider string    `json:"provider"`
+	Op       string    `json:"op"`
+	// Target identifies what the operation acted on: an image ref for
+	// OpImageBuild, a container/environment ID for the rest.
+	Target string `json:"target,omitempty"`
+	// CommandHash is the hex SHA-256 digest of an OpExec's command text,
+	// recorded instead of the command itself so the audit log can't leak
+	// secrets an agent's command line might carry. See HashCommand.
+	CommandHash string `json:"command_hash,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Logger appends Entry records to a job's audit.jsonl. A nil *Logger is
+// valid and a no-op, so callers can hold one unconditionally without
+// checking whether auditing is wired in.
+type Logger struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// Open creates (or appends to) jobDir/audit.jsonl.
+func Open(jobDir string) (*Logger, error) {
+	f, err := os.OpenFile(filepath.Join(jobDir, FileName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("opening audit log: %w", err)
+	}
+	return &Logger{file: f}, nil
+}
+
+// Record appends an entry, filling in Time. Safe for concurrent use across
+// a job's trial goroutines, each of which may drive its own provider calls
+// at the same time.
+func (l *Logger) Record(e Entry) {
+	if l == nil {
+		return
+	}
+	e.Time = time.Now()
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.file.Write(data)
+}
+
+// HashCommand returns the hex SHA-256 digest of cmd, for recording that an
+// exec happened without persisting the command text (and anything secret
+// it might carry) in the audit log.
+func HashCommand(cmd string) string {
+	sum := sha256.Sum256([]byte(cmd))
+	return hex.EncodeToString(sum[:])
+}
+
+// Close closes the underlying file. Safe to call on a nil *Logger.
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+	return l.file.Close()
+}
@@ -0,0 +1,58 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordAppendsJSONLEntries(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer l.Close()
+
+	l.Record(Entry{Provider: "docker", Op: OpContainerCreate, Target: "abc123"})
+	l.Record(Entry{Provider: "docker", Op: OpExec, Target: "abc123", CommandHash: HashCommand("echo hi")})
+
+	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	if err != nil {
+		t.Fatalf("reading audit log: %v", err)
+	}
+
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("unmarshaling entry: %v", err)
+		}
+		entries = append(entries, e)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Op != OpContainerCreate || entries[0].Target != "abc123" {
+		t.Errorf("entry 0 = %+v", entries[0])
+	}
+	if entries[1].Op != OpExec || entries[1].CommandHash == "" {
+		t.Errorf("entry 1 = %+v", entries[1])
+	}
+	if entries[1].CommandHash == "echo hi" {
+		t.Errorf("command text leaked into audit log instead of being hashed")
+	}
+}
+
+func TestRecordOnNilLoggerIsNoOp(t *testing.T) {
+	var l *Logger
+	l.Record(Entry{Provider: "docker", Op: OpDestroy})
+	if err := l.Close(); err != nil {
+		t.Errorf("Close on nil Logger: %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+// Package bench implements rollout's synthetic "N tiny trials" workload: it
+// runs a small generated dataset through the real job pipeline against the
+// in-memory fake environment provider (see internal/environment/fake), so
+// performance-motivated changes to scheduling, copying, or result
+// aggregation can be measured without needing docker or a network, and
+// without the noise of a real provider's own latency.
+package bench
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/spachava753/rollout/internal/executor"
+	"github.com/spachava753/rollout/internal/models"
+)
+
+// Config controls a synthetic benchmark run.
+type Config struct {
+	// Trials is the total number of trials to run. Must be positive.
+	Trials int
+	// Workers is the number of trials run concurrently. Zero or negative
+	// defaults to 1.
+	Workers int
+}
+
+// Result reports timing and memory stats for a Run.
+type Result struct {
+	Trials          int
+	CompletedTrials int
+	FailedTrials    int
+	Duration        time.Duration
+	TrialsPerSec    float64
+	// BytesPerTrial estimates heap allocation per trial, from the delta in
+	// runtime.MemStats.TotalAlloc across the run divided by Trials. A rough
+	// signal for catching large regressions (e.g. an accidental O(n^2)
+	// buffer copy), not a precise per-trial accounting.
+	BytesPerTrial uint64
+}
+
+// Run generates a single tiny task and executes cfg.Trials attempts of it
+// (one agent, cfg.Trials attempts) through a real JobOrchestrator, using the
+// fake provider so no docker daemon or network access is required.
+func Run(ctx context.Context, cfg Config) (*Result, error) {
+	if cfg.Trials <= 0 {
+		return nil, fmt.Errorf("trials must be positive, got %d", cfg.Trials)
+	}
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	datasetDir, err := newSyntheticDataset()
+	if err != nil {
+		return nil, fmt.Errorf("creating synthetic dataset: %w", err)
+	}
+	defer os.RemoveAll(datasetDir)
+
+	jobsDir, err := os.MkdirTemp("", "rollout-bench-jobs-")
+	if err != nil {
+		return nil, fmt.Errorf("creating jobs dir: %w", err)
+	}
+	defer os.RemoveAll(jobsDir)
+
+	jobCfg := models.JobConfig{
+		JobsDir:           jobsDir,
+		NAttempts:         cfg.Trials,
+		NConcurrentTrials: workers,
+		TimeoutMultiplier: 1.0,
+		InstructionPath:   "/tmp/instruction.md",
+		Environment: models.JobEnvironmentConfig{
+			Type: models.ProviderTypes{"fake"},
+		},
+		Agents:   []models.Agent{{Name: "bench"}},
+		Datasets: []models.DatasetRef{{Name: "bench", Path: &datasetDir}},
+	}
+
+	orchestrator, err := executor.NewJobOrchestrator(jobCfg, executor.DefaultTrialExecutorFunc)
+	if err != nil {
+		return nil, fmt.Errorf("creating orchestrator: %w", err)
+	}
+	defer func() {
+		if err := orchestrator.Close(ctx); err != nil {
+			slog.Warn("closing orchestrator failed", "error", err)
+		}
+	}()
+
+	var memBefore, memAfter runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&memBefore)
+
+	start := time.Now()
+	result, err := orchestrator.Run(ctx)
+	elapsed := time.Since(start)
+
+	runtime.ReadMemStats(&memAfter)
+
+	if err != nil {
+		return nil, fmt.Errorf("running synthetic job: %w", err)
+	}
+
+	r := &Result{
+		Trials:          result.TotalTrials,
+		CompletedTrials: result.CompletedTrials,
+		FailedTrials:    result.FailedTrials,
+		Duration:        elapsed,
+	}
+	if elapsed > 0 {
+		r.TrialsPerSec = float64(r.Trials) / elapsed.Seconds()
+	}
+	if memAfter.TotalAlloc > memBefore.TotalAlloc && r.Trials > 0 {
+		r.BytesPerTrial = (memAfter.TotalAlloc - memBefore.TotalAlloc) / uint64(r.Trials)
+	}
+	return r, nil
+}
+
+// newSyntheticDataset writes a single minimal task ("tiny") to a temp
+// directory, satisfying task.Loader.ValidateTask, and returns the dataset
+// directory (the task's parent) containing it.
+func newSyntheticDataset() (string, error) {
+	datasetDir, err := os.MkdirTemp("", "rollout-bench-dataset-")
+	if err != nil {
+		return "", err
+	}
+
+	taskDir := filepath.Join(datasetDir, "tiny")
+	if err := os.MkdirAll(filepath.Join(taskDir, "environment"), 0755); err != nil {
+		os.RemoveAll(datasetDir)
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Join(taskDir, "tests"), 0755); err != nil {
+		os.RemoveAll(datasetDir)
+		return "", err
+	}
+
+	files := map[string]string{
+		"task.toml":              "version = \"1.0\"\n",
+		"instruction.md":         "Create a file called hello.txt with \"Hello, world!\" as the content.\n",
+		"environment/Dockerfile": "FROM scratch\n",
+		"tests/test.sh":          "#!/bin/bash\necho 1 > /logs/verifier/reward.txt\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(taskDir, name), []byte(content), 0644); err != nil {
+			os.RemoveAll(datasetDir)
+			return "", err
+		}
+	}
+
+	return datasetDir, nil
+}
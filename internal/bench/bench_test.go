@@ -0,0 +1,28 @@
+package bench
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	result, err := Run(context.Background(), Config{Trials: 3, Workers: 2})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Trials != 3 {
+		t.Errorf("expected 3 trials, got %d", result.Trials)
+	}
+	if result.CompletedTrials != 3 {
+		t.Errorf("expected 3 completed trials, got %d", result.CompletedTrials)
+	}
+	if result.FailedTrials != 0 {
+		t.Errorf("expected 0 failed trials, got %d", result.FailedTrials)
+	}
+}
+
+func TestRunRejectsNonPositiveTrials(t *testing.T) {
+	if _, err := Run(context.Background(), Config{Trials: 0}); err == nil {
+		t.Error("expected error for zero trials")
+	}
+}
@@ -0,0 +1,301 @@
+// Package bisect automates finding which commit of a task's git repository
+// introduced a reward regression. It walks the commit range between a known
+// good and a known bad commit, checking out and rerunning the oracle (or a
+// named agent) at each candidate via a binary search, the same way `git
+// bisect run` narrows down a code regression.
+package bisect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spachava753/rollout/internal/executor"
+	"github.com/spachava753/rollout/internal/models"
+)
+
+// Config controls a bisect run.
+type Config struct {
+	// GitURL is the task repository to bisect. Required.
+	GitURL string
+	// TaskPath is the path within the repository to the task directory.
+	// Empty means the repository root is the task itself.
+	TaskPath string
+	// GoodCommit is a commit known not to exhibit the regression.
+	// Required.
+	GoodCommit string
+	// BadCommit is a commit known to exhibit the regression. Required.
+	BadCommit string
+	// Agent, if set, reruns this agent's trial at each candidate commit
+	// instead of the oracle. Zero value runs the oracle (via ModeValidate),
+	// which is the common case: the task's own reference solution started
+	// failing its own verifier.
+	Agent models.Agent
+	// Environment selects the provider each candidate commit's trial runs
+	// against. Required.
+	Environment models.JobEnvironmentConfig
+	// RewardThreshold, if non-nil, overrides the task's own pass_threshold
+	// when deciding whether a candidate commit's reward counts as "good".
+	RewardThreshold *float64
+	// JobsDir is the scratch directory each candidate commit's job writes
+	// its job-run files under. Empty uses a temp directory that bisect
+	// creates and removes itself.
+	JobsDir string
+}
+
+// Check records the outcome of rerunning a single candidate commit.
+type Check struct {
+	Commit string   `json:"commit"`
+	Passed bool     `json:"passed"`
+	Reward *float64 `json:"reward"`
+	Error  string   `json:"error,omitempty"`
+}
+
+// Result is the outcome of a bisect run.
+type Result struct {
+	// FirstBadCommit is the earliest commit in (GoodCommit, BadCommit] at
+	// which the regression reproduced.
+	FirstBadCommit string `json:"first_bad_commit"`
+	// Checked lists every candidate commit bisect actually ran, in the
+	// order it ran them.
+	Checked []Check `json:"checked"`
+}
+
+// Run clones cfg.GitURL, lists the commits between cfg.GoodCommit
+// (exclusive) and cfg.BadCommit (inclusive), and binary searches over them
+// by rerunning the task's oracle (or cfg.Agent) at each candidate, narrowing
+// down the first commit where it stops passing.
+func Run(ctx context.Context, cfg Config) (*Result, error) {
+	if cfg.GitURL == "" {
+		return nil, fmt.Errorf("git url is required")
+	}
+	if cfg.GoodCommit == "" || cfg.BadCommit == "" {
+		return nil, fmt.Errorf("good and bad commits are required")
+	}
+
+	cloneDir, err := os.MkdirTemp("", "rollout-bisect-repo-")
+	if err != nil {
+		return nil, fmt.Errorf("creating clone dir: %w", err)
+	}
+	defer os.RemoveAll(cloneDir)
+
+	if err := runGit(ctx, "", "clone", cfg.GitURL, cloneDir); err != nil {
+		return nil, fmt.Errorf("cloning %s: %w", cfg.GitURL, err)
+	}
+
+	commits, err := commitRange(ctx, cloneDir, cfg.GoodCommit, cfg.BadCommit)
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("no commits between %s and %s", cfg.GoodCommit, cfg.BadCommit)
+	}
+
+	result := &Result{}
+
+	// Binary search for the first commit in commits that fails, assuming
+	// the regression is monotonic: every commit at or after the first bad
+	// one stays bad. commits[len(commits)-1] is BadCommit itself, so hi
+	// always converges to a real failure.
+	lo, hi := 0, len(commits)-1
+	for lo < hi {
+		mid := (lo + hi) / 2
+		check, err := checkCommit(ctx, cfg, cloneDir, commits[mid])
+		if err != nil {
+			return nil, err
+		}
+		result.Checked = append(result.Checked, *check)
+		if check.Passed {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+
+	// lo == hi: confirm it unless it's already the commit we just checked.
+	if len(result.Checked) == 0 || result.Checked[len(result.Checked)-1].Commit != commits[lo] {
+		check, err := checkCommit(ctx, cfg, cloneDir, commits[lo])
+		if err != nil {
+			return nil, err
+		}
+		result.Checked = append(result.Checked, *check)
+	}
+
+	result.FirstBadCommit = commits[lo]
+	return result, nil
+}
+
+// checkCommit checks out commit in cloneDir and reruns the task's oracle
+// (or cfg.Agent) against it, reporting whether it passed.
+func checkCommit(ctx context.Context, cfg Config, cloneDir, commit string) (*Check, error) {
+	slog.Info("bisect: checking commit", "commit", commit)
+
+	if err := runGit(ctx, cloneDir, "checkout", commit); err != nil {
+		return nil, fmt.Errorf("checking out %s: %w", commit, err)
+	}
+
+	taskDir := cloneDir
+	if cfg.TaskPath != "" {
+		taskDir = filepath.Join(cloneDir, cfg.TaskPath)
+	}
+
+	datasetDir, err := stageDataset(taskDir)
+	if err != nil {
+		return nil, fmt.Errorf("staging task at %s: %w", commit, err)
+	}
+	defer os.RemoveAll(datasetDir)
+
+	jobsDir := cfg.JobsDir
+	if jobsDir == "" {
+		tmp, err := os.MkdirTemp("", "rollout-bisect-jobs-")
+		if err != nil {
+			return nil, fmt.Errorf("creating jobs dir: %w", err)
+		}
+		defer os.RemoveAll(tmp)
+		jobsDir = tmp
+	}
+
+	jobCfg := models.JobConfig{
+		JobsDir:           jobsDir,
+		NAttempts:         1,
+		NConcurrentTrials: 1,
+		TimeoutMultiplier: 1.0,
+		InstructionPath:   "/tmp/instruction.md",
+		Environment:       cfg.Environment,
+		Datasets:          []models.DatasetRef{{Name: "bisect", Path: &datasetDir}},
+	}
+	if cfg.RewardThreshold != nil {
+		jobCfg.Verifier.OverridePassThreshold = cfg.RewardThreshold
+	}
+	if cfg.Agent.Name == "" {
+		jobCfg.Mode = models.ModeValidate
+	} else {
+		jobCfg.Agents = []models.Agent{cfg.Agent}
+	}
+
+	orchestrator, err := executor.NewJobOrchestrator(jobCfg, executor.DefaultTrialExecutorFunc)
+	if err != nil {
+		return nil, fmt.Errorf("creating orchestrator for %s: %w", commit, err)
+	}
+	defer func() {
+		if err := orchestrator.Close(ctx); err != nil {
+			slog.Warn("closing orchestrator failed", "commit", commit, "error", err)
+		}
+	}()
+
+	jobResult, err := orchestrator.Run(ctx)
+	if err != nil {
+		return &Check{Commit: commit, Error: err.Error()}, nil
+	}
+
+	if cfg.Agent.Name == "" {
+		if len(jobResult.Validation) == 0 {
+			return &Check{Commit: commit, Error: "validation produced no result"}, nil
+		}
+		v := jobResult.Validation[0]
+		return &Check{Commit: commit, Passed: v.Passed, Reward: v.Reward, Error: v.Error}, nil
+	}
+
+	if len(jobResult.Results) == 0 {
+		return &Check{Commit: commit, Error: "trial produced no result"}, nil
+	}
+	r := jobResult.Results[0]
+	// JobConfig above runs exactly one trial, so PassRate is either 0 or 1
+	// for it, already computed against the task's (or RewardThreshold's)
+	// pass threshold the same way a full multi-trial job would be.
+	return &Check{Commit: commit, Passed: jobResult.PassRate >= 1, Reward: r.Reward}, nil
+}
+
+// stageDataset copies taskDir into a fresh temp directory so it can be
+// passed as a models.DatasetRef.Path, whose loader treats every immediate
+// subdirectory of the path as a task. taskDir itself can't be used
+// directly: it may be the clone root, which also holds .git and siblings
+// the dataset loader would try (and fail) to load as tasks.
+func stageDataset(taskDir string) (string, error) {
+	datasetDir, err := os.MkdirTemp("", "rollout-bisect-dataset-")
+	if err != nil {
+		return "", err
+	}
+	if err := copyTree(taskDir, filepath.Join(datasetDir, "task")); err != nil {
+		os.RemoveAll(datasetDir)
+		return "", err
+	}
+	return datasetDir, nil
+}
+
+// copyTree recursively copies src to dst, skipping .git.
+func copyTree(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if rel == ".git" || strings.HasPrefix(rel, ".git"+string(filepath.Separator)) {
+			return nil
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		return copyFile(path, target, info.Mode())
+	})
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	return err
+}
+
+// commitRange returns the commits strictly after good up to and including
+// bad, oldest first, so index 0 is the earliest candidate.
+func commitRange(ctx context.Context, cloneDir, good, bad string) ([]string, error) {
+	out, err := gitOutput(ctx, cloneDir, "rev-list", "--reverse", good+".."+bad)
+	if err != nil {
+		return nil, fmt.Errorf("listing commits between %s and %s: %w", good, bad, err)
+	}
+	var commits []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line != "" {
+			commits = append(commits, line)
+		}
+	}
+	return commits, nil
+}
+
+func runGit(ctx context.Context, dir string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func gitOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,122 @@
+package bisect
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunRequiresGitURL(t *testing.T) {
+	if _, err := Run(context.Background(), Config{GoodCommit: "a", BadCommit: "b"}); err == nil {
+		t.Error("expected error for missing git url")
+	}
+}
+
+func TestRunRequiresCommits(t *testing.T) {
+	if _, err := Run(context.Background(), Config{GitURL: "https://example.com/repo.git"}); err == nil {
+		t.Error("expected error for missing good/bad commits")
+	}
+}
+
+// TestCommitRange exercises commitRange against a local repo, so it needs
+// no network access, unlike a full Run against a real GitURL.
+func TestCommitRange(t *testing.T) {
+	repoDir := newTestRepo(t, 4)
+
+	commits := gitLog(t, repoDir)
+	if len(commits) != 4 {
+		t.Fatalf("expected 4 commits in test repo, got %d", len(commits))
+	}
+	good, bad := commits[3], commits[0] // commits[] is newest-first
+
+	got, err := commitRange(context.Background(), repoDir, good, bad)
+	if err != nil {
+		t.Fatalf("commitRange failed: %v", err)
+	}
+	want := []string{commits[2], commits[1], commits[0]}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d commits, got %d: %v", len(want), len(got), got)
+	}
+	for i, c := range got {
+		if c != want[i] {
+			t.Errorf("commit %d: got %s, want %s", i, c, want[i])
+		}
+	}
+}
+
+func TestCommitRangeEmptyWhenSame(t *testing.T) {
+	repoDir := newTestRepo(t, 2)
+	commits := gitLog(t, repoDir)
+
+	got, err := commitRange(context.Background(), repoDir, commits[0], commits[0])
+	if err != nil {
+		t.Fatalf("commitRange failed: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("expected no commits between a commit and itself, got %v", got)
+	}
+}
+
+func TestCopyTreeSkipsGitDir(t *testing.T) {
+	repoDir := newTestRepo(t, 1)
+	dst := t.TempDir()
+	dstPath := filepath.Join(dst, "copy")
+
+	if err := copyTree(repoDir, dstPath); err != nil {
+		t.Fatalf("copyTree failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dstPath, ".git")); !os.IsNotExist(err) {
+		t.Errorf("expected .git to be skipped, stat err = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dstPath, "file.txt")); err != nil {
+		t.Errorf("expected file.txt to be copied: %v", err)
+	}
+}
+
+// newTestRepo creates a local git repo with n commits, each adding a line
+// to file.txt, and returns its directory.
+func newTestRepo(t *testing.T, n int) string {
+	t.Helper()
+	dir := t.TempDir()
+	runGitT(t, dir, "init", "-q")
+	runGitT(t, dir, "config", "user.email", "test@example.com")
+	runGitT(t, dir, "config", "user.name", "test")
+	for i := 0; i < n; i++ {
+		path := filepath.Join(dir, "file.txt")
+		data, _ := os.ReadFile(path)
+		data = append(data, byte('a'+i), '\n')
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("writing file.txt: %v", err)
+		}
+		runGitT(t, dir, "add", ".")
+		runGitT(t, dir, "commit", "-q", "-m", "commit")
+	}
+	return dir
+}
+
+func gitLog(t *testing.T, dir string) []string {
+	t.Helper()
+	out, err := gitOutput(context.Background(), dir, "log", "--format=%H")
+	if err != nil {
+		t.Fatalf("git log: %v", err)
+	}
+	var commits []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line != "" {
+			commits = append(commits, line)
+		}
+	}
+	return commits
+}
+
+func runGitT(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v: %s", args, err, out)
+	}
+}
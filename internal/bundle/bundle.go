@@ -0,0 +1,225 @@
+// Package bundle exports a loaded task's environment and tests as a
+// self-contained, reproducible tarball: task.toml, instruction.md, the
+// environment/ build context (including the Dockerfile), and tests/, plus a
+// manifest pinning the Dockerfile's base image(s) by digest and recording a
+// sha256 checksum for every bundled file. The solution/ directory is
+// deliberately left out, since a bundle is meant to be shared with agents
+// and reviewers who shouldn't see the reference solution.
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/spachava753/rollout/internal/task"
+)
+
+// Result summarizes a produced bundle.
+type Result struct {
+	Path string
+	// Files is the number of files written into the tarball, excluding
+	// MANIFEST.json itself.
+	Files int
+	// BaseImages maps each distinct image named in a Dockerfile FROM line to
+	// its resolved digest reference, or "" if resolution failed (e.g. the
+	// image isn't present locally and couldn't be pulled).
+	BaseImages map[string]string
+}
+
+// manifest is written as MANIFEST.json inside the tarball.
+type manifest struct {
+	TaskName  string    `json:"task_name"`
+	CreatedAt time.Time `json:"created_at"`
+	// BaseImages maps each Dockerfile FROM reference to its resolved digest
+	// reference, empty if resolution failed.
+	BaseImages map[string]string `json:"base_images"`
+	// Checksums maps each bundled file's path (relative to the bundle root)
+	// to its sha256 hex digest.
+	Checksums map[string]string `json:"checksums"`
+}
+
+var fromRe = regexp.MustCompile(`(?im)^\s*FROM\s+(?:--platform=\S+\s+)?(\S+)`)
+
+// Bundle loads the task at taskPath and writes a gzipped tar to outputPath
+// (or "<task-name>-bundle.tar.gz" in the current directory when empty).
+func Bundle(ctx context.Context, taskPath, outputPath string) (Result, error) {
+	t, err := task.NewLoader().LoadTask(ctx, taskPath)
+	if err != nil {
+		return Result{}, fmt.Errorf("loading task: %w", err)
+	}
+
+	if outputPath == "" {
+		outputPath = t.Name + "-bundle.tar.gz"
+	}
+
+	envFS, err := t.Environment()
+	if err != nil {
+		return Result{}, fmt.Errorf("opening environment dir: %w", err)
+	}
+	dockerfile, err := fs.ReadFile(envFS, "Dockerfile")
+	if err != nil {
+		return Result{}, fmt.Errorf("reading Dockerfile: %w", err)
+	}
+	baseImages := resolveBaseImages(ctx, dockerfile)
+
+	out, err := os.Create(outputPath)
+	if err != nil {
+		return Result{}, fmt.Errorf("creating bundle: %w", err)
+	}
+	defer out.Close()
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+
+	checksums := make(map[string]string)
+	nFiles := 0
+	for _, entry := range []struct {
+		root string // path within the bundle
+		fsys fs.FS
+	}{
+		{"environment", envFS},
+	} {
+		if err := addFS(tw, entry.fsys, entry.root, checksums); err != nil {
+			return Result{}, fmt.Errorf("adding %s: %w", entry.root, err)
+		}
+	}
+	testsFS, err := t.Tests()
+	if err != nil {
+		return Result{}, fmt.Errorf("opening tests dir: %w", err)
+	}
+	if err := addFS(tw, testsFS, "tests", checksums); err != nil {
+		return Result{}, fmt.Errorf("adding tests: %w", err)
+	}
+	for _, name := range []string{"task.toml", "instruction.md"} {
+		if err := addFile(tw, t.FS, name, checksums); err != nil {
+			return Result{}, fmt.Errorf("adding %s: %w", name, err)
+		}
+	}
+	nFiles = len(checksums)
+
+	mf := manifest{
+		TaskName:   t.Name,
+		CreatedAt:  time.Now(),
+		BaseImages: baseImages,
+		Checksums:  checksums,
+	}
+	mfBytes, err := json.MarshalIndent(mf, "", "  ")
+	if err != nil {
+		return Result{}, fmt.Errorf("marshaling manifest: %w", err)
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: "MANIFEST.json", Mode: 0o644, Size: int64(len(mfBytes))}); err != nil {
+		return Result{}, fmt.Errorf("writing manifest header: %w", err)
+	}
+	if _, err := tw.Write(mfBytes); err != nil {
+		return Result{}, fmt.Errorf("writing manifest: %w", err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return Result{}, fmt.Errorf("closing tar: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return Result{}, fmt.Errorf("closing gzip: %w", err)
+	}
+
+	return Result{Path: outputPath, Files: nFiles, BaseImages: baseImages}, nil
+}
+
+// addFS walks fsys and writes every regular file into tw under root,
+// recording each file's sha256 checksum.
+func addFS(tw *tar.Writer, fsys fs.FS, root string, checksums map[string]string) error {
+	return fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		name := path.Join(root, p)
+		return writeFile(tw, fsys, p, name, checksums)
+	})
+}
+
+// addFile writes a single file from fsys into tw under name.
+func addFile(tw *tar.Writer, fsys fs.FS, name string, checksums map[string]string) error {
+	return writeFile(tw, fsys, name, name, checksums)
+}
+
+func writeFile(tw *tar.Writer, fsys fs.FS, srcPath, archiveName string, checksums map[string]string) error {
+	data, err := fs.ReadFile(fsys, srcPath)
+	if err != nil {
+		return err
+	}
+	checksums[archiveName] = hexSHA256(data)
+
+	if err := tw.WriteHeader(&tar.Header{Name: archiveName, Mode: 0o644, Size: int64(len(data))}); err != nil {
+		return err
+	}
+	_, err = tw.Write(data)
+	return err
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// resolveBaseImages finds every distinct image named in dockerfile's FROM
+// lines and resolves each to a digest reference via `docker inspect`,
+// pulling it first if it isn't already present locally. Resolution failures
+// (no docker, no network, unknown image) are non-fatal: the image is left
+// mapped to "" so the bundle can still be produced.
+func resolveBaseImages(ctx context.Context, dockerfile []byte) map[string]string {
+	seen := make(map[string]bool)
+	var refs []string
+	for _, m := range fromRe.FindAllStringSubmatch(string(dockerfile), -1) {
+		ref := m[1]
+		if ref == "scratch" || seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+
+	images := make(map[string]string, len(refs))
+	for _, ref := range refs {
+		images[ref] = resolveDigest(ctx, ref)
+	}
+	return images
+}
+
+func resolveDigest(ctx context.Context, ref string) string {
+	if out, err := exec.CommandContext(ctx, "docker", "image", "inspect", "--format", "{{index .RepoDigests 0}}", ref).Output(); err == nil {
+		if digest := trimmed(out); digest != "" {
+			return digest
+		}
+	}
+	if err := exec.CommandContext(ctx, "docker", "pull", ref).Run(); err != nil {
+		return ""
+	}
+	out, err := exec.CommandContext(ctx, "docker", "image", "inspect", "--format", "{{index .RepoDigests 0}}", ref).Output()
+	if err != nil {
+		return ""
+	}
+	return trimmed(out)
+}
+
+func trimmed(b []byte) string {
+	s := string(b)
+	for len(s) > 0 && (s[len(s)-1] == '\n' || s[len(s)-1] == '\r') {
+		s = s[:len(s)-1]
+	}
+	return s
+}
@@ -148,7 +148,7 @@ datasets:
 		t.Errorf("expected timeout_multiplier 1.5, got %f", cfg.TimeoutMultiplier)
 	}
 
-	if cfg.Environment.Type != "docker" {
+	if cfg.Environment.Type.Primary() != "docker" {
 		t.Errorf("expected environment type docker, got %s", cfg.Environment.Type)
 	}
 
@@ -197,7 +197,7 @@ func TestDefaultJobConfig(t *testing.T) {
 		t.Errorf("expected default instruction_path /tmp/instruction.md, got %s", cfg.InstructionPath)
 	}
 
-	if cfg.Environment.Type != "docker" {
+	if cfg.Environment.Type.Primary() != "docker" {
 		t.Errorf("expected default environment type docker, got %s", cfg.Environment.Type)
 	}
 
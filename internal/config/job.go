@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"regexp"
 
 	"gopkg.in/yaml.v3"
 	"github.com/spachava753/rollout/internal/models"
@@ -23,7 +24,7 @@ func DefaultJobConfig() models.JobConfig {
 			Multiplier:     2.0,
 		},
 		Environment: models.JobEnvironmentConfig{
-			Type:        "docker",
+			Type:        models.ProviderTypes{"docker"},
 			PreserveEnv: models.PreserveNever,
 		},
 	}
@@ -54,6 +55,13 @@ func LoadJobConfig(path string) (models.JobConfig, error) {
 		}
 	}
 
+	// Validate redaction patterns
+	for i, pattern := range cfg.Redaction.Patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return cfg, fmt.Errorf("redaction.patterns[%d]: invalid regex %q: %w", i, pattern, err)
+		}
+	}
+
 	// Apply defaults for missing values
 	if cfg.JobsDir == "" {
 		cfg.JobsDir = "jobs"
@@ -70,8 +78,8 @@ func LoadJobConfig(path string) (models.JobConfig, error) {
 	if cfg.InstructionPath == "" {
 		cfg.InstructionPath = "/tmp/instruction.md"
 	}
-	if cfg.Environment.Type == "" {
-		cfg.Environment.Type = "docker"
+	if len(cfg.Environment.Type) == 0 {
+		cfg.Environment.Type = models.ProviderTypes{"docker"}
 	}
 	if cfg.Environment.PreserveEnv == "" {
 		cfg.Environment.PreserveEnv = models.PreserveNever
@@ -14,7 +14,8 @@ func DefaultTaskConfig() models.TaskConfig {
 	return models.TaskConfig{
 		Version: "1.0",
 		Verifier: models.VerifierConfig{
-			TimeoutSec: 600.0,
+			TimeoutSec:    600.0,
+			PassThreshold: 1.0,
 		},
 		Agent: models.AgentTaskConfig{
 			InstallTimeoutSec: 300.0,
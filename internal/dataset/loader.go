@@ -47,7 +47,7 @@ func (l *Loader) LoadFromPath(ctx context.Context, datasetPath string) (*models.
 
 		taskPath := filepath.Join(absPath, entry.Name())
 		slog.Debug("loading task", "name", entry.Name(), "path", taskPath)
-		
+
 		t, err := l.taskLoader.LoadTask(ctx, taskPath)
 		if err != nil {
 			return nil, fmt.Errorf("loading task %s: %w", entry.Name(), err)
@@ -66,7 +66,7 @@ func (l *Loader) LoadFromPath(ctx context.Context, datasetPath string) (*models.
 
 	name := filepath.Base(absPath)
 	slog.Debug("dataset loaded", "name", name, "tasks", len(tasks))
-	
+
 	return &models.Dataset{
 		Name:  name,
 		Tasks: tasks,
@@ -74,7 +74,9 @@ func (l *Loader) LoadFromPath(ctx context.Context, datasetPath string) (*models.
 }
 
 // LoadFromRegistry loads a dataset from a registry (local path or URL).
-func (l *Loader) LoadFromRegistry(ctx context.Context, ref models.RegistryRef, name, version string) (*models.Dataset, error) {
+// pins, if non-nil, maps task name to a git commit that overrides the
+// registry's own git_commit_id for that task (see registry.Resolver.Resolve).
+func (l *Loader) LoadFromRegistry(ctx context.Context, ref models.RegistryRef, name, version string, pins map[string]string) (*models.Dataset, error) {
 	// Initialize resolver lazily
 	if l.resolver == nil {
 		slog.Debug("initializing registry resolver")
@@ -115,7 +117,7 @@ func (l *Loader) LoadFromRegistry(ctx context.Context, ref models.RegistryRef, n
 
 	// Resolve tasks (clone repos, load tasks)
 	slog.Debug("resolving tasks from registry", "dataset", name, "task_count", len(regDataset.Tasks))
-	tasks, err := l.resolver.Resolve(ctx, regDataset)
+	tasks, err := l.resolver.Resolve(ctx, regDataset, pins)
 	if err != nil {
 		return nil, fmt.Errorf("resolving tasks: %w", err)
 	}
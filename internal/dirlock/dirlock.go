@@ -0,0 +1,91 @@
+// Package dirlock provides a cross-process lock file so multiple rollout
+// processes (e.g. sharded job runs) can safely create and check for
+// directories inside a shared jobs_dir on NFS or another shared volume
+// without racing each other: one stat-then-mkdir sequence could otherwise
+// observe "doesn't exist yet" for two processes at once and clobber
+// whichever one lost.
+package dirlock
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// retryInterval is how long Acquire waits between attempts to create the
+// lock file while it is held by another process.
+const retryInterval = 100 * time.Millisecond
+
+// Lock is a held lock file. Release must be called to drop it.
+type Lock struct {
+	path string
+}
+
+// Acquire creates a lock file at dir+".lock", blocking until it can do so
+// exclusively or timeout elapses. dir's parent directories are created if
+// missing, since the lock typically guards dir's own creation.
+func Acquire(dir string, timeout time.Duration) (*Lock, error) {
+	lockPath := dir + ".lock"
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0755); err != nil {
+		return nil, fmt.Errorf("creating parent of lock file %s: %w", lockPath, err)
+	}
+
+	hostname, _ := os.Hostname()
+	deadline := time.Now().Add(timeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n%s\n", os.Getpid(), hostname)
+			f.Close()
+			return &Lock{path: lockPath}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("creating lock file %s: %w", lockPath, err)
+		}
+		if reclaimIfStale(lockPath, hostname) {
+			continue // holder's pid is dead and the lock file is gone now, retry immediately
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out after %s waiting for lock %s (held by another rollout process)", timeout, lockPath)
+		}
+		time.Sleep(retryInterval)
+	}
+}
+
+// reclaimIfStale removes lockPath if it records a pid on this same host
+// that is no longer running, and reports whether it did. Without this, a
+// rollout process killed while holding the lock (OOM, SIGKILL, host
+// crash - exactly the failures jobs_dir sharing across processes needs to
+// survive) would leave the lock file behind forever, wedging every later
+// run against the same jobs_dir. It never reclaims a lock recorded from a
+// different host, since a bare pid number means nothing there.
+func reclaimIfStale(lockPath, hostname string) bool {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return false
+	}
+	lines := strings.SplitN(strings.TrimSpace(string(data)), "\n", 2)
+	if len(lines) != 2 || lines[1] != hostname {
+		return false
+	}
+	pid, err := strconv.Atoi(lines[0])
+	if err != nil || pid <= 0 {
+		return false
+	}
+	if err := syscall.Kill(pid, 0); err == nil || err == syscall.EPERM {
+		return false // still alive (EPERM: alive but owned by another user)
+	}
+	return os.Remove(lockPath) == nil
+}
+
+// Release drops the lock by removing its lock file.
+func (l *Lock) Release() error {
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing lock file %s: %w", l.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package dirlock
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAcquireExcludesConcurrentHolder(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "job")
+
+	lock, err := Acquire(dir, time.Second)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	if _, err := Acquire(dir, 200*time.Millisecond); err == nil {
+		t.Fatal("expected second Acquire to time out while the first lock is held")
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+
+	lock2, err := Acquire(dir, time.Second)
+	if err != nil {
+		t.Fatalf("Acquire after release: %v", err)
+	}
+	if err := lock2.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+}
+
+func TestAcquireReclaimsStaleLockFromDeadProcess(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "job")
+	lockPath := dir + ".lock"
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("running short-lived process: %v", err)
+	}
+	deadPid := cmd.Process.Pid
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatalf("Hostname: %v", err)
+	}
+	if err := os.WriteFile(lockPath, []byte(fmt.Sprintf("%d\n%s\n", deadPid, hostname)), 0644); err != nil {
+		t.Fatalf("writing stale lock file: %v", err)
+	}
+
+	lock, err := Acquire(dir, time.Second)
+	if err != nil {
+		t.Fatalf("Acquire did not reclaim a lock left behind by a dead process: %v", err)
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+}
+
+func TestAcquireDoesNotReclaimLockFromDifferentHost(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "job")
+	lockPath := dir + ".lock"
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	// A pid that's almost certainly not running, but recorded against a
+	// different host, so Acquire must not reclaim it.
+	if err := os.WriteFile(lockPath, []byte("999999\nsome-other-host\n"), 0644); err != nil {
+		t.Fatalf("writing lock file: %v", err)
+	}
+
+	if _, err := Acquire(dir, 200*time.Millisecond); err == nil {
+		t.Fatal("expected Acquire to time out rather than reclaim a lock recorded from a different host")
+	}
+}
+
+func TestReleaseOnMissingLockIsNotAnError(t *testing.T) {
+	lock := &Lock{path: filepath.Join(t.TempDir(), "nonexistent.lock")}
+	if err := lock.Release(); err != nil {
+		t.Errorf("Release of missing lock file: %v", err)
+	}
+}
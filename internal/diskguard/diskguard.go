@@ -0,0 +1,57 @@
+// Package diskguard estimates whether a job's jobs_dir has enough free disk
+// space to complete, so a run fails fast with a clear error instead of
+// partway through with a cryptic "no space left on device" write error from
+// whichever trial happened to hit the limit first.
+package diskguard
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/spachava753/rollout/internal/models"
+)
+
+// Report summarizes a free-space check against an estimated requirement.
+type Report struct {
+	FreeMB      int64
+	RequiredMB  int64
+	ShortfallMB int64 // zero unless FreeMB < RequiredMB
+}
+
+// OK reports whether the check found enough free space.
+func (r Report) OK() bool {
+	return r.ShortfallMB <= 0
+}
+
+// Check statfs's dir's filesystem and estimates the space a job needs as
+// nTrials * policy.PerTrialEstimateMB, plus policy.ReserveMB held back for
+// everything else already on that filesystem. It does not itself fail the
+// job; callers decide what to do with a short Report based on
+// policy.OnShortfall.
+func Check(dir string, nTrials int, policy models.DiskGuardConfig) (Report, error) {
+	free, err := freeMB(dir)
+	if err != nil {
+		return Report{}, fmt.Errorf("statting %s: %w", dir, err)
+	}
+
+	perTrial := policy.PerTrialEstimateMB
+	if perTrial <= 0 {
+		perTrial = 500
+	}
+	required := policy.ReserveMB + int64(nTrials)*perTrial
+
+	report := Report{FreeMB: free, RequiredMB: required}
+	if free < required {
+		report.ShortfallMB = required - free
+	}
+	return report, nil
+}
+
+// freeMB returns the free space on dir's filesystem in megabytes.
+func freeMB(dir string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize) / (1024 * 1024), nil
+}
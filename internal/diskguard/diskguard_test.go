@@ -0,0 +1,48 @@
+package diskguard
+
+import (
+	"testing"
+
+	"github.com/spachava753/rollout/internal/models"
+)
+
+func TestCheckFlagsShortfall(t *testing.T) {
+	dir := t.TempDir()
+
+	report, err := Check(dir, 10, models.DiskGuardConfig{PerTrialEstimateMB: 1, ReserveMB: 0})
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if report.RequiredMB != 10 {
+		t.Errorf("required = %d, want 10", report.RequiredMB)
+	}
+	if report.FreeMB <= 0 {
+		t.Errorf("free = %d, want > 0 on a real filesystem", report.FreeMB)
+	}
+	if !report.OK() {
+		t.Errorf("expected OK with a tiny estimate, got shortfall %d", report.ShortfallMB)
+	}
+
+	hungry, err := Check(dir, 10, models.DiskGuardConfig{PerTrialEstimateMB: 1 << 40, ReserveMB: 0})
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if hungry.OK() {
+		t.Errorf("expected an absurd per-trial estimate to exceed free space")
+	}
+	if hungry.ShortfallMB <= 0 {
+		t.Errorf("ShortfallMB = %d, want > 0", hungry.ShortfallMB)
+	}
+}
+
+func TestCheckDefaultsPerTrialEstimate(t *testing.T) {
+	dir := t.TempDir()
+
+	withDefault, err := Check(dir, 4, models.DiskGuardConfig{})
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if withDefault.RequiredMB != 4*500 {
+		t.Errorf("required = %d, want %d (default 500MB/trial)", withDefault.RequiredMB, 4*500)
+	}
+}
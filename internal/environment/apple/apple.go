@@ -0,0 +1,385 @@
+// Package apple implements the "apple" environment provider by shelling out
+// to Apple's native container CLI (the Containerization framework's `container`
+// command shipped with macOS 15+), the same CLI-shelling approach docker.go
+// and k8s.go take with their own platform tools.
+//
+// Unlike docker, each container here runs in its own lightweight per-container
+// Linux VM rather than a shared-kernel namespace, and the VM's root filesystem
+// is mounted back into the host under a different UID namespace. That split
+// is why this provider, on top of the usual build/pull/create/exec/copy
+// surface, has to reconcile two cross-VM-boundary concerns docker never needs
+// to: running a command as a specific group inside the VM (Exec's --gid), and
+// fixing up file ownership on copies pulled back out of the VM onto the host.
+package apple
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spachava753/rollout/internal/environment"
+)
+
+// ProviderConfig holds Apple container-specific configuration.
+type ProviderConfig struct {
+	// Kernel selects the kernel image `container` boots each per-container
+	// VM with, passed as --kernel. Empty uses the CLI's configured default.
+	Kernel string
+	// RootExec forces every Exec to run as root inside the VM, overriding
+	// ExecOptions.User and Group, for images that need root regardless of
+	// what a phase's own user/group settings say (e.g. an install step that
+	// must write to paths no unprivileged user in the image can reach).
+	RootExec bool
+}
+
+// ParseProviderConfig extracts Apple-specific config from the generic config map.
+func ParseProviderConfig(config map[string]any) ProviderConfig {
+	var pc ProviderConfig
+	if config == nil {
+		return pc
+	}
+	if v, ok := config["kernel"].(string); ok {
+		pc.Kernel = v
+	}
+	if v, ok := config["root_exec"].(bool); ok {
+		pc.RootExec = v
+	}
+	return pc
+}
+
+// Provider implements the Apple container environment provider.
+type Provider struct {
+	config ProviderConfig
+
+	mu        sync.Mutex
+	builtTags map[string]bool
+}
+
+// NewProvider creates a new Apple container provider.
+func NewProvider(config ProviderConfig) *Provider {
+	return &Provider{config: config, builtTags: make(map[string]bool)}
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "apple"
+}
+
+// BuildImage builds an image from the given context directory.
+func (p *Provider) BuildImage(ctx context.Context, opts environment.BuildImageOptions) (string, error) {
+	args := []string{"build", "-t", opts.Tag}
+	for _, k := range sortedKeys(opts.BuildArgs) {
+		args = append(args, "--build-arg", fmt.Sprintf("%s=%s", k, opts.BuildArgs[k]))
+	}
+	if opts.Target != "" {
+		args = append(args, "--target", opts.Target)
+	}
+	args = append(args, opts.ContextDir)
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	slog.Debug("executing container build", "tag", opts.Tag, "context", opts.ContextDir)
+
+	cmd := exec.CommandContext(ctx, "container", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("building apple container image: %w", err)
+	}
+
+	p.mu.Lock()
+	p.builtTags[opts.Tag] = true
+	p.mu.Unlock()
+
+	return opts.Tag, nil
+}
+
+// builtLocally reports whether imageRef was produced by this Provider's own
+// BuildImage, as opposed to a tag PullImage fetched from a registry.
+func (p *Provider) builtLocally(imageRef string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.builtTags[imageRef]
+}
+
+// sortedKeys returns m's keys in sorted order, so --build-arg flags are
+// passed in a deterministic order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// PullImage pulls a pre-built image from a registry.
+func (p *Provider) PullImage(ctx context.Context, imageRef string) error {
+	slog.Debug("pulling apple container image", "image", imageRef)
+	cmd := exec.CommandContext(ctx, "container", "images", "pull", imageRef)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("pulling apple container image: %w", err)
+	}
+	return nil
+}
+
+// CreateEnvironment creates and starts a container in its own VM.
+func (p *Provider) CreateEnvironment(ctx context.Context, opts environment.CreateEnvironmentOptions) (environment.Environment, error) {
+	containerID := opts.Name
+	if containerID == "" {
+		containerID = fmt.Sprintf("rollout-%d", time.Now().UnixNano())
+	}
+
+	args := []string{"run", "-d", "--name", containerID}
+	if p.config.Kernel != "" {
+		args = append(args, "--kernel", p.config.Kernel)
+	}
+	if opts.CPUs > 0 {
+		args = append(args, "--cpus", strconv.Itoa(opts.CPUs))
+	}
+	if opts.MemoryMB > 0 {
+		args = append(args, "--memory", fmt.Sprintf("%dm", opts.MemoryMB))
+	}
+	if opts.StorageMB > 0 {
+		// The container CLI can only resize the VM disk it itself built from
+		// a Dockerfile; an image pulled from a registry arrives with a fixed
+		// disk image baked in that --disk-size can't grow after the fact.
+		if !p.builtLocally(opts.ImageRef) {
+			return nil, fmt.Errorf("apple provider cannot honor storage_mb for %s: it was not built locally via BuildImage, so its VM disk size is fixed by the registry image and can't be resized", opts.ImageRef)
+		}
+		args = append(args, "--disk-size", fmt.Sprintf("%dM", opts.StorageMB))
+	}
+	for k, v := range opts.Env {
+		args = append(args, "-e", fmt.Sprintf("%s=%s", k, v))
+	}
+	args = append(args, opts.ImageRef, "sleep", "infinity")
+
+	slog.Debug("creating apple container", "name", containerID, "image", opts.ImageRef)
+
+	cmd := exec.CommandContext(ctx, "container", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("creating apple container: %w: %s", err, stderr.String())
+	}
+
+	return &Environment{provider: p, containerID: containerID}, nil
+}
+
+// Environment represents a running Apple container (and its backing VM).
+type Environment struct {
+	provider    *Provider
+	containerID string
+	cost        float64
+}
+
+// ID returns the container ID.
+func (e *Environment) ID() string {
+	return e.containerID
+}
+
+// ExposePort returns a URL for reaching port inside the container. Each
+// Containerization VM gets its own routable IP on the host's private
+// network, so unlike docker this needs no port publishing at container
+// creation time - just resolving that IP via `container inspect`.
+func (e *Environment) ExposePort(ctx context.Context, port int) (string, error) {
+	cmd := exec.CommandContext(ctx, "container", "inspect", e.containerID)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("inspecting apple container: %w: %s", err, stderr.String())
+	}
+
+	var inspected []struct {
+		Networks []struct {
+			Address string `json:"address"`
+		} `json:"networks"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &inspected); err != nil {
+		return "", fmt.Errorf("parsing `container inspect` output: %w", err)
+	}
+	if len(inspected) == 0 || len(inspected[0].Networks) == 0 {
+		return "", fmt.Errorf("container %s has no network address assigned", e.containerID)
+	}
+
+	addr, _, _ := strings.Cut(inspected[0].Networks[0].Address, "/")
+	return fmt.Sprintf("http://%s:%d", addr, port), nil
+}
+
+// CopyTo copies a local file or directory into the container.
+func (e *Environment) CopyTo(ctx context.Context, src, dst string) error {
+	dstDir := filepath.Dir(dst)
+	if dstDir != "/" && dstDir != "." {
+		mkdirCmd := exec.CommandContext(ctx, "container", "exec", e.containerID, "mkdir", "-p", dstDir)
+		if err := mkdirCmd.Run(); err != nil {
+			return fmt.Errorf("creating directory %s: %w", dstDir, err)
+		}
+	}
+
+	slog.Debug("copying to apple container", "container_id", e.containerID, "src", src, "dst", dst)
+
+	cmd := exec.CommandContext(ctx, "container", "cp", src, fmt.Sprintf("%s:%s", e.containerID, dst))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("copying to apple container: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// CopyFrom copies a file or directory from the container to a local path.
+//
+// Because the container's filesystem lives inside its own VM, `container cp`
+// hands files back out owned by the VM's root UID rather than the host user
+// invoking rollout, so every copy needs a chown pass to restore sane host
+// ownership - docker doesn't need this since its containers share the host
+// kernel's UID namespace directly.
+func (e *Environment) CopyFrom(ctx context.Context, src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("creating local directory: %w", err)
+	}
+
+	slog.Debug("copying from apple container", "container_id", e.containerID, "src", src, "dst", dst)
+
+	cmd := exec.CommandContext(ctx, "container", "cp", fmt.Sprintf("%s:%s", e.containerID, src), dst)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("copying from apple container: %w: %s", err, stderr.String())
+	}
+
+	if err := chownToHostUser(dst); err != nil {
+		return fmt.Errorf("restoring host ownership on %s: %w", dst, err)
+	}
+	return nil
+}
+
+// chownToHostUser recursively chowns path to the current host user/group,
+// undoing the VM-root ownership `container cp` leaves behind.
+func chownToHostUser(path string) error {
+	u, err := user.Current()
+	if err != nil {
+		return fmt.Errorf("looking up current user: %w", err)
+	}
+	cmd := exec.Command("chown", "-R", fmt.Sprintf("%s:%s", u.Uid, u.Gid), path)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// Exec executes a command in the container.
+func (e *Environment) Exec(ctx context.Context, cmd string, stdout, stderr io.Writer, opts environment.ExecOptions) (int, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	args := []string{"exec"}
+	for k, v := range opts.Env {
+		args = append(args, "-e", fmt.Sprintf("%s=%s", k, v))
+	}
+	if opts.WorkDir != "" {
+		args = append(args, "-w", opts.WorkDir)
+	}
+
+	// RootExec overrides any requested user/group: some images need root
+	// regardless of what a phase's own settings say.
+	if e.provider.config.RootExec {
+		args = append(args, "--user", "root")
+	} else {
+		if opts.User != "" {
+			args = append(args, "--user", opts.User)
+		}
+		if group, ok := runtimeGroup(opts.User); ok {
+			args = append(args, "--gid", group)
+		}
+	}
+
+	args = append(args, e.containerID, "bash", "-c", cmd)
+
+	cmdPreview := cmd
+	if len(cmdPreview) > 100 {
+		cmdPreview = cmdPreview[:100] + "..."
+	}
+	slog.Debug("executing command in apple container", "container_id", e.containerID, "command", cmdPreview)
+
+	execCmd := exec.CommandContext(ctx, "container", args...)
+	execCmd.Stdout = stdout
+	execCmd.Stderr = stderr
+
+	err := execCmd.Run()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode(), nil
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return -1, fmt.Errorf("command timed out")
+		}
+		return -1, fmt.Errorf("executing command: %w", err)
+	}
+	return 0, nil
+}
+
+// runtimeGroup derives the --gid to pass alongside a "user:group" formatted
+// ExecOptions.User (the same "user[:group]" convention docker accepts on its
+// own -u flag), since Apple's container CLI takes user and group as separate
+// flags rather than docker's combined colon syntax.
+func runtimeGroup(user string) (string, bool) {
+	_, group, ok := strings.Cut(user, ":")
+	if !ok || group == "" {
+		return "", false
+	}
+	return group, true
+}
+
+// Stop stops the container but does not remove it.
+func (e *Environment) Stop(ctx context.Context) error {
+	slog.Debug("stopping apple container", "container_id", e.containerID)
+	cmd := exec.CommandContext(ctx, "container", "stop", e.containerID)
+	if err := cmd.Run(); err != nil {
+		if !strings.Contains(err.Error(), "not found") {
+			return fmt.Errorf("stopping container: %w", err)
+		}
+	}
+	return nil
+}
+
+// Destroy removes the container and cleans up resources.
+func (e *Environment) Destroy(ctx context.Context) error {
+	slog.Debug("destroying apple container", "container_id", e.containerID)
+	cmd := exec.CommandContext(ctx, "container", "rm", "-f", e.containerID)
+	if err := cmd.Run(); err != nil {
+		if !strings.Contains(err.Error(), "not found") {
+			return fmt.Errorf("removing container: %w", err)
+		}
+	}
+	return nil
+}
+
+// Cost returns the cost incurred by this environment (always 0 for local Apple containers).
+func (e *Environment) Cost() float64 {
+	return e.cost
+}
@@ -0,0 +1,215 @@
+// Package cloudflare implements the "cloudflare" environment provider,
+// which runs one Cloudflare Containers instance per trial instead of a
+// local Docker host, letting trials run close to Workers-based agents. It
+// shells out to the wrangler CLI, the same approach the fargate and
+// gcp_cloudrun providers take with their own platforms' CLIs.
+//
+// Cloudflare Containers instances are reached through a Worker route or
+// Durable Object binding, not a remote-exec API like docker exec, kubectl
+// exec, or ECS Execute-Command. That is a genuine platform limitation, not
+// an oversight here: like the gcp_cloudrun provider, this one cannot exec
+// into or copy files into a running instance, so it is only useful for
+// tasks whose image bakes in everything it needs to run at creation time.
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"time"
+
+	"github.com/spachava753/rollout/internal/environment"
+)
+
+// ProviderConfig holds Cloudflare-specific configuration.
+type ProviderConfig struct {
+	// AccountID is the Cloudflare account containers are created under.
+	AccountID string
+	// APIToken authenticates with the Cloudflare API. Passed to wrangler via
+	// the CLOUDFLARE_API_TOKEN environment variable rather than a flag, so
+	// it never shows up in process listings.
+	APIToken string
+	// Location is the Cloudflare location hint instances are placed in
+	// (e.g. "wnam"). If empty, Cloudflare's own placement is used.
+	Location string
+}
+
+// ParseProviderConfig extracts Cloudflare-specific config from the generic config map.
+func ParseProviderConfig(config map[string]any) ProviderConfig {
+	var pc ProviderConfig
+	if config == nil {
+		return pc
+	}
+	if v, ok := config["account_id"].(string); ok {
+		pc.AccountID = v
+	}
+	if v, ok := config["api_token"].(string); ok {
+		pc.APIToken = v
+	}
+	if v, ok := config["location"].(string); ok {
+		pc.Location = v
+	}
+	return pc
+}
+
+// Provider implements the Cloudflare Containers environment provider.
+type Provider struct {
+	config ProviderConfig
+}
+
+// NewProvider creates a new Cloudflare provider.
+func NewProvider(config ProviderConfig) *Provider {
+	return &Provider{config: config}
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "cloudflare"
+}
+
+func (p *Provider) wrangler(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "wrangler", args...)
+	cmd.Env = append(cmd.Environ(),
+		"CLOUDFLARE_API_TOKEN="+p.config.APIToken,
+		"CLOUDFLARE_ACCOUNT_ID="+p.config.AccountID)
+	return cmd
+}
+
+// BuildImage is not supported by the Cloudflare provider: there is no local
+// Docker daemon on Cloudflare's container platform to build against. Build
+// the image locally, push it with `wrangler containers push` (PullImage
+// does this automatically), and reference it with task.toml's docker_image.
+func (p *Provider) BuildImage(ctx context.Context, opts environment.BuildImageOptions) (string, error) {
+	return "", fmt.Errorf("cloudflare provider does not support building images; push %s with wrangler containers push and use docker_image instead", opts.Tag)
+}
+
+// PullImage pushes the local image to Cloudflare's managed container
+// registry, the reverse direction of most providers' PullImage, because
+// Cloudflare Containers can only run images that already live in its own
+// registry.
+func (p *Provider) PullImage(ctx context.Context, imageRef string) error {
+	slog.Debug("pushing image to Cloudflare's container registry", "image", imageRef)
+
+	cmd := p.wrangler(ctx, "containers", "push", imageRef)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("pushing image to Cloudflare registry: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// CreateEnvironment starts a new Cloudflare Containers instance from the
+// trial image.
+func (p *Provider) CreateEnvironment(ctx context.Context, opts environment.CreateEnvironmentOptions) (environment.Environment, error) {
+	name := opts.Name
+	if name == "" {
+		name = fmt.Sprintf("rollout-%d", time.Now().UnixNano())
+	}
+
+	args := []string{"containers", "run", opts.ImageRef, "--name", name}
+	if opts.CPUs > 0 {
+		args = append(args, "--vcpu", fmt.Sprintf("%d", opts.CPUs))
+	}
+	if opts.MemoryMB > 0 {
+		args = append(args, "--memory-mb", fmt.Sprintf("%d", opts.MemoryMB))
+	}
+	if p.config.Location != "" {
+		args = append(args, "--location", p.config.Location)
+	}
+	for k, v := range opts.Env {
+		args = append(args, "--var", fmt.Sprintf("%s=%s", k, v))
+	}
+
+	slog.Debug("creating cloudflare container instance", "name", name, "image", opts.ImageRef)
+
+	cmd := p.wrangler(ctx, append(args, "--json")...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("creating container instance: %w: %s", err, stderr.String())
+	}
+
+	var resp struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil || resp.ID == "" {
+		// Fall back to the name we assigned: some wrangler versions print a
+		// human-readable summary instead of JSON on success.
+		resp.ID = name
+	}
+
+	slog.Debug("cloudflare container instance running", "instance", resp.ID)
+
+	return &Environment{provider: p, instanceID: resp.ID}, nil
+}
+
+// Environment represents a running Cloudflare Containers instance.
+type Environment struct {
+	provider   *Provider
+	instanceID string
+}
+
+// ID returns the instance ID.
+func (e *Environment) ID() string {
+	return e.instanceID
+}
+
+// CopyTo is not supported: Cloudflare Containers instances expose no
+// remote-file-copy API analogous to docker cp or kubectl cp. Bake the file
+// into the image instead.
+func (e *Environment) CopyTo(ctx context.Context, src, dst string) error {
+	return fmt.Errorf("cloudflare provider does not support copying files into a running instance; bake %s into the image instead", src)
+}
+
+// CopyFrom is not supported for the same reason as CopyTo.
+func (e *Environment) CopyFrom(ctx context.Context, src, dst string) error {
+	return fmt.Errorf("cloudflare provider does not support copying files out of a running instance; write %s to R2 from within the container instead", src)
+}
+
+// Exec is not supported: Cloudflare Containers instances have no
+// interactive exec channel analogous to docker exec, kubectl exec, or ECS
+// Execute-Command. The instance's entrypoint command must be baked into the
+// image at creation time.
+func (e *Environment) Exec(ctx context.Context, cmd string, stdout, stderr io.Writer, opts environment.ExecOptions) (int, error) {
+	return -1, fmt.Errorf("cloudflare provider does not support exec; the instance's command must be baked in at creation time")
+}
+
+// Stop stops the container instance but does not remove it.
+func (e *Environment) Stop(ctx context.Context) error {
+	slog.Debug("stopping cloudflare container instance", "instance", e.instanceID)
+
+	cmd := e.provider.wrangler(ctx, "containers", "stop", e.instanceID)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("stopping container instance: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// Destroy stops and deletes the container instance.
+func (e *Environment) Destroy(ctx context.Context) error {
+	slog.Debug("deleting cloudflare container instance", "instance", e.instanceID)
+
+	cmd := e.provider.wrangler(ctx, "containers", "delete", e.instanceID, "--force")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("deleting container instance: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// Cost returns the cost incurred by this environment. Cloudflare Containers
+// billing depends on exact vCPU/memory allocation and per-second usage
+// reported after the fact by Cloudflare's own billing, which this provider
+// does not query, so cost tracking is left to the operator's own billing.
+func (e *Environment) Cost() float64 {
+	return 0
+}
@@ -0,0 +1,203 @@
+// Package cloudrun implements the "gcp_cloudrun" environment provider,
+// which runs one Cloud Run Jobs execution per trial instead of a local
+// Docker host. Like the k8s and fargate providers, it shells out to the
+// gcloud CLI rather than linking a Google Cloud client library.
+//
+// Cloud Run Jobs are run-to-completion: a job's container runs once and
+// exits, with no remote-exec channel into it while it runs. That is a
+// genuine platform limitation, not an oversight here, so CreateEnvironment
+// creates the job from the trial image with the image's own command and
+// waits for its single execution to finish; the execution's outcome is not
+// recorded on CloudRunEnvironment. Exec, CopyTo, and CopyFrom are not
+// supported since there is nothing running to exec or copy into after the
+// job starts.
+package cloudrun
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"time"
+
+	"github.com/spachava753/rollout/internal/environment"
+)
+
+// ProviderConfig holds Cloud Run-specific configuration.
+type ProviderConfig struct {
+	// Project is the GCP project ID jobs are created in.
+	Project string
+	// Region is the Cloud Run region (e.g. "us-central1").
+	Region string
+	// ServiceAccount is the service account email the job runs as. If
+	// empty, the project's default compute service account is used.
+	ServiceAccount string
+}
+
+// ParseProviderConfig extracts Cloud Run-specific config from the generic config map.
+func ParseProviderConfig(config map[string]any) ProviderConfig {
+	var pc ProviderConfig
+	if config == nil {
+		return pc
+	}
+	if v, ok := config["project"].(string); ok {
+		pc.Project = v
+	}
+	if v, ok := config["region"].(string); ok {
+		pc.Region = v
+	}
+	if v, ok := config["service_account"].(string); ok {
+		pc.ServiceAccount = v
+	}
+	return pc
+}
+
+// Provider implements the GCP Cloud Run Jobs environment provider.
+type Provider struct {
+	config ProviderConfig
+}
+
+// NewProvider creates a new Cloud Run provider.
+func NewProvider(config ProviderConfig) *Provider {
+	return &Provider{config: config}
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "gcp_cloudrun"
+}
+
+func (p *Provider) gcloudArgs() []string {
+	var args []string
+	if p.config.Project != "" {
+		args = append(args, "--project", p.config.Project)
+	}
+	if p.config.Region != "" {
+		args = append(args, "--region", p.config.Region)
+	}
+	return args
+}
+
+func (p *Provider) gcloud(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, "gcloud", append(args, p.gcloudArgs()...)...)
+}
+
+// BuildImage is not supported by the Cloud Run provider: jobs execute
+// against images pulled from Artifact Registry, not a local Docker daemon.
+// Push the image and reference it with task.toml's docker_image instead.
+func (p *Provider) BuildImage(ctx context.Context, opts environment.BuildImageOptions) (string, error) {
+	return "", fmt.Errorf("gcp_cloudrun provider does not support building images; push %s to Artifact Registry and use docker_image instead", opts.Tag)
+}
+
+// PullImage is a no-op: Cloud Run pulls the job's image itself when an
+// execution starts.
+func (p *Provider) PullImage(ctx context.Context, imageRef string) error {
+	slog.Debug("skipping explicit image pull, cloud run will pull on job execution", "image", imageRef)
+	return nil
+}
+
+// CreateEnvironment creates a Cloud Run Job configured to run the trial
+// image and executes it, waiting for the run-to-completion execution to
+// finish.
+func (p *Provider) CreateEnvironment(ctx context.Context, opts environment.CreateEnvironmentOptions) (environment.Environment, error) {
+	jobName := opts.Name
+	if jobName == "" {
+		jobName = fmt.Sprintf("rollout-%d", time.Now().UnixNano())
+	}
+
+	createArgs := []string{"run", "jobs", "create", jobName, "--image", opts.ImageRef}
+	if p.config.ServiceAccount != "" {
+		createArgs = append(createArgs, "--service-account", p.config.ServiceAccount)
+	}
+	if opts.CPUs > 0 {
+		createArgs = append(createArgs, "--cpu", fmt.Sprintf("%d", opts.CPUs))
+	}
+	if opts.MemoryMB > 0 {
+		createArgs = append(createArgs, "--memory", fmt.Sprintf("%dMi", opts.MemoryMB))
+	}
+	for k, v := range opts.Env {
+		createArgs = append(createArgs, "--set-env-vars", fmt.Sprintf("%s=%s", k, v))
+	}
+
+	slog.Debug("creating cloud run job", "job", jobName, "image", opts.ImageRef)
+
+	createCmd := p.gcloud(ctx, createArgs...)
+	var stderr bytes.Buffer
+	createCmd.Stderr = &stderr
+	if err := createCmd.Run(); err != nil {
+		return nil, fmt.Errorf("creating cloud run job: %w: %s", err, stderr.String())
+	}
+
+	slog.Debug("executing cloud run job", "job", jobName)
+
+	executeCmd := p.gcloud(ctx, "run", "jobs", "execute", jobName, "--wait")
+	stderr.Reset()
+	executeCmd.Stderr = &stderr
+	if err := executeCmd.Run(); err != nil {
+		return nil, fmt.Errorf("executing cloud run job: %w: %s", err, stderr.String())
+	}
+
+	return &CloudRunEnvironment{provider: p, jobName: jobName}, nil
+}
+
+// CloudRunEnvironment represents a Cloud Run Job that has already run to
+// completion.
+type CloudRunEnvironment struct {
+	provider *Provider
+	jobName  string
+	cost     float64
+}
+
+// ID returns the job name.
+func (e *CloudRunEnvironment) ID() string {
+	return e.jobName
+}
+
+// CopyTo is not supported: a Cloud Run Job's container has already exited
+// by the time CreateEnvironment returns, so there is nothing to copy into.
+func (e *CloudRunEnvironment) CopyTo(ctx context.Context, src, dst string) error {
+	return fmt.Errorf("gcp_cloudrun provider does not support copying files into a job after execution; bake %s into the image instead", src)
+}
+
+// CopyFrom is not supported for the same reason as CopyTo; use Cloud Run
+// Jobs' Cloud Logging output or a GCS write from within the container
+// instead of copying files out after the fact.
+func (e *CloudRunEnvironment) CopyFrom(ctx context.Context, src, dst string) error {
+	return fmt.Errorf("gcp_cloudrun provider does not support copying files out of a job after execution; write %s to GCS from within the container instead", src)
+}
+
+// Exec is not supported: Cloud Run Jobs have no remote-exec channel into a
+// running or completed execution.
+func (e *CloudRunEnvironment) Exec(ctx context.Context, cmd string, stdout, stderr io.Writer, opts environment.ExecOptions) (int, error) {
+	return -1, fmt.Errorf("gcp_cloudrun provider does not support exec; the job's command must be baked in at creation time")
+}
+
+// Stop is a no-op: the job's execution has already completed by the time
+// CreateEnvironment returns.
+func (e *CloudRunEnvironment) Stop(ctx context.Context) error {
+	slog.Debug("stop is a no-op for the gcp_cloudrun provider", "job", e.jobName)
+	return nil
+}
+
+// Destroy deletes the Cloud Run Job.
+func (e *CloudRunEnvironment) Destroy(ctx context.Context) error {
+	slog.Debug("deleting cloud run job", "job", e.jobName)
+
+	cmd := e.provider.gcloud(ctx, "run", "jobs", "delete", e.jobName, "--quiet")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("deleting cloud run job: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// Cost returns the cost incurred by this environment. Cloud Run Jobs billing
+// depends on exact CPU/memory allocation and execution time reported after
+// the fact by GCP billing exports, which this provider does not query, so
+// cost tracking is left to the operator's own billing.
+func (e *CloudRunEnvironment) Cost() float64 {
+	return e.cost
+}
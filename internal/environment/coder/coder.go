@@ -0,0 +1,290 @@
+// Package coder implements the "coder" environment provider, which
+// provisions one Coder (coder.com) workspace per trial from a pre-existing
+// template, for teams that already standardize their dev environments on
+// Coder and want to reuse those templates for rollout trials instead of
+// maintaining a separate Dockerfile. Like the daytona and fargate
+// providers, it shells out to the coder CLI rather than linking Coder's Go
+// SDK (coderd's API isn't designed to be used that way from third-party
+// tools), and reaches the workspace over SSH through `coder ssh --stdio`,
+// the transport Coder's own docs use for scp and editor integrations.
+package coder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/spachava753/rollout/internal/environment"
+)
+
+// ProviderConfig holds Coder-specific configuration.
+type ProviderConfig struct {
+	// URL is the Coder deployment's base URL, e.g. "https://coder.example.com".
+	// Passed to the CLI via CODER_URL rather than a flag, so it's picked up
+	// consistently by both `coder create` and `coder ssh`.
+	URL string
+	// Token authenticates with the Coder deployment. Passed via CODER_SESSION_TOKEN
+	// rather than a flag, so it never shows up in process listings.
+	Token string
+	// Org scopes workspace creation to a Coder organization. Empty uses the
+	// CLI's configured default organization.
+	Org string
+}
+
+// ParseProviderConfig extracts Coder-specific config from the generic config map.
+func ParseProviderConfig(config map[string]any) ProviderConfig {
+	var pc ProviderConfig
+	if config == nil {
+		return pc
+	}
+	if v, ok := config["url"].(string); ok {
+		pc.URL = v
+	}
+	if v, ok := config["token"].(string); ok {
+		pc.Token = v
+	}
+	if v, ok := config["org"].(string); ok {
+		pc.Org = v
+	}
+	return pc
+}
+
+// Provider implements the Coder environment provider.
+type Provider struct {
+	config ProviderConfig
+}
+
+// NewProvider creates a new Coder provider.
+func NewProvider(config ProviderConfig) *Provider {
+	return &Provider{config: config}
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "coder"
+}
+
+// coder runs a coder CLI command with the provider's deployment credentials
+// set via environment variables.
+func (p *Provider) coder(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "coder", args...)
+	cmd.Env = append(cmd.Environ(), "CODER_URL="+p.config.URL, "CODER_SESSION_TOKEN="+p.config.Token)
+	return cmd
+}
+
+// BuildImage is not supported by the coder provider: a Coder workspace is
+// provisioned from a Terraform template, not a Dockerfile, so there is
+// nothing here to build. Point task.toml's docker_image at the name of a
+// template already registered with the Coder deployment instead.
+func (p *Provider) BuildImage(ctx context.Context, opts environment.BuildImageOptions) (string, error) {
+	return "", fmt.Errorf("coder provider does not support building images; register %s as a Coder template and reference its name with docker_image instead", opts.Tag)
+}
+
+// PullImage is a no-op: a Coder template already has everything it needs to
+// provision a workspace, with no separate image-pull step for rollout to
+// trigger ahead of time.
+func (p *Provider) PullImage(ctx context.Context, imageRef string) error {
+	slog.Debug("skipping explicit image pull, coder provisions from its own template", "template", imageRef)
+	return nil
+}
+
+// CreateEnvironment provisions a Coder workspace from the template named by
+// opts.ImageRef.
+func (p *Provider) CreateEnvironment(ctx context.Context, opts environment.CreateEnvironmentOptions) (environment.Environment, error) {
+	name := opts.Name
+	if name == "" {
+		name = fmt.Sprintf("rollout-%d", time.Now().UnixNano())
+	}
+
+	args := []string{"create", name, "--template", opts.ImageRef, "-y"}
+	if p.config.Org != "" {
+		args = append(args, "--org", p.config.Org)
+	}
+
+	slog.Debug("creating coder workspace", "name", name, "template", opts.ImageRef)
+
+	cmd := p.coder(ctx, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("creating coder workspace: %w: %s", err, stderr.String())
+	}
+
+	if err := waitForRunning(ctx, p, name); err != nil {
+		return nil, fmt.Errorf("waiting for workspace to start: %w", err)
+	}
+
+	slog.Debug("coder workspace running", "name", name)
+
+	return &Environment{provider: p, workspace: name}, nil
+}
+
+// workspaceReadyTimeout bounds how long CreateEnvironment waits for a newly
+// created workspace's agent to come online and accept `coder ssh`.
+const workspaceReadyTimeout = 5 * time.Minute
+
+// waitForRunning polls `coder show` until the workspace's agent reports
+// "connected", or returns an error if the workspace fails to build or the
+// timeout elapses.
+func waitForRunning(ctx context.Context, p *Provider, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, workspaceReadyTimeout)
+	defer cancel()
+
+	for {
+		cmd := p.coder(ctx, "show", name)
+		var stdout bytes.Buffer
+		cmd.Stdout = &stdout
+		if err := cmd.Run(); err == nil {
+			out := stdout.String()
+			if strings.Contains(out, "Connected") {
+				return nil
+			}
+			if strings.Contains(out, "failed") {
+				return fmt.Errorf("workspace %s build failed: %s", name, out)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out after %s waiting for workspace agent to connect", workspaceReadyTimeout)
+		case <-time.After(3 * time.Second):
+		}
+	}
+}
+
+// Environment represents a running Coder workspace.
+type Environment struct {
+	provider  *Provider
+	workspace string
+}
+
+// ID returns the workspace name.
+func (e *Environment) ID() string {
+	return e.workspace
+}
+
+// proxyCommand returns the ssh ProxyCommand that tunnels through the
+// Coder CLI's own websocket connection to the workspace, the same
+// transport `coder config-ssh` wires up for editor/IDE integrations.
+func (e *Environment) proxyCommand() string {
+	return fmt.Sprintf("coder ssh --stdio %s", e.workspace)
+}
+
+func (e *Environment) sshArgs() []string {
+	return []string{
+		"-o", "ProxyCommand=" + e.proxyCommand(),
+		"-o", "StrictHostKeyChecking=no",
+		"-o", "UserKnownHostsFile=/dev/null",
+	}
+}
+
+// CopyTo copies a local file or directory into the workspace over scp,
+// tunneled through `coder ssh --stdio`.
+func (e *Environment) CopyTo(ctx context.Context, src, dst string) error {
+	slog.Debug("copying into coder workspace", "workspace", e.workspace, "src", src, "dst", dst)
+
+	args := append(append([]string{}, e.sshArgs()...), "-r", src, "workspace:"+dst)
+	cmd := exec.CommandContext(ctx, "scp", args...)
+	cmd.Env = append(cmd.Environ(), "CODER_URL="+e.provider.config.URL, "CODER_SESSION_TOKEN="+e.provider.config.Token)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("copying into workspace: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// CopyFrom copies a file or directory from the workspace to a local path
+// over scp, tunneled through `coder ssh --stdio`.
+func (e *Environment) CopyFrom(ctx context.Context, src, dst string) error {
+	slog.Debug("copying from coder workspace", "workspace", e.workspace, "src", src, "dst", dst)
+
+	args := append(append([]string{}, e.sshArgs()...), "-r", "workspace:"+src, dst)
+	cmd := exec.CommandContext(ctx, "scp", args...)
+	cmd.Env = append(cmd.Environ(), "CODER_URL="+e.provider.config.URL, "CODER_SESSION_TOKEN="+e.provider.config.Token)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("copying from workspace: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// buildShellCommand assembles the "export ...; cd ...; cmd" one-liner bash
+// runs, shell-quoting opts.Env and opts.WorkDir so a value containing
+// shell metacharacters can't inject extra commands.
+func buildShellCommand(cmd string, opts environment.ExecOptions) string {
+	var sb strings.Builder
+	for k, v := range opts.Env {
+		fmt.Fprintf(&sb, "export %s=%s; ", k, environment.ShellQuote(v))
+	}
+	if opts.WorkDir != "" {
+		fmt.Fprintf(&sb, "cd %s; ", environment.ShellQuote(opts.WorkDir))
+	}
+	sb.WriteString(cmd)
+	return sb.String()
+}
+
+// Exec executes a command in the workspace over `coder ssh`.
+func (e *Environment) Exec(ctx context.Context, cmd string, stdout, stderr io.Writer, opts environment.ExecOptions) (int, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	execCmd := e.provider.coder(ctx, "ssh", e.workspace, "--", "bash", "-c", buildShellCommand(cmd, opts))
+	execCmd.Stdout = stdout
+	execCmd.Stderr = stderr
+
+	err := execCmd.Run()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode(), nil
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return -1, fmt.Errorf("command timed out")
+		}
+		return -1, fmt.Errorf("executing command: %w", err)
+	}
+	return 0, nil
+}
+
+// Stop stops the workspace without deleting it, the same way `coder stop`
+// lets a user resume it later.
+func (e *Environment) Stop(ctx context.Context) error {
+	slog.Debug("stopping coder workspace", "workspace", e.workspace)
+
+	cmd := e.provider.coder(ctx, "stop", e.workspace, "-y")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("stopping workspace: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// Destroy deletes the workspace and releases its resources.
+func (e *Environment) Destroy(ctx context.Context) error {
+	slog.Debug("destroying coder workspace", "workspace", e.workspace)
+
+	cmd := e.provider.coder(ctx, "delete", e.workspace, "-y")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("destroying workspace: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// Cost always returns 0: Coder's own billing (if any) is tied to the
+// underlying infrastructure its template provisions, which this provider
+// has no visibility into.
+func (e *Environment) Cost() float64 {
+	return 0
+}
@@ -0,0 +1,312 @@
+// Package daytona implements the "daytona" environment provider, which runs
+// one Daytona sandbox per trial instead of a local Docker host. Like the
+// fargate and k8s providers, it shells out to the daytona CLI rather than
+// linking a Go SDK.
+package daytona
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"time"
+
+	"github.com/spachava753/rollout/internal/environment"
+)
+
+// ProviderConfig holds Daytona-specific configuration.
+type ProviderConfig struct {
+	// APIKey authenticates with the Daytona API. Passed to the CLI via the
+	// DAYTONA_API_KEY environment variable rather than a flag, so it never
+	// shows up in process listings.
+	APIKey string
+	// Target is the Daytona target region/runner pool sandboxes are created
+	// in (e.g. "us"). If empty, the CLI's configured default is used.
+	Target string
+}
+
+// ParseProviderConfig extracts Daytona-specific config from the generic config map.
+func ParseProviderConfig(config map[string]any) ProviderConfig {
+	var pc ProviderConfig
+	if config == nil {
+		return pc
+	}
+	if v, ok := config["api_key"].(string); ok {
+		pc.APIKey = v
+	}
+	if v, ok := config["target"].(string); ok {
+		pc.Target = v
+	}
+	return pc
+}
+
+// Provider implements the Daytona environment provider.
+type Provider struct {
+	config ProviderConfig
+}
+
+// NewProvider creates a new Daytona provider.
+func NewProvider(config ProviderConfig) *Provider {
+	return &Provider{config: config}
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "daytona"
+}
+
+func (p *Provider) daytona(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "daytona", args...)
+	cmd.Env = append(cmd.Environ(), "DAYTONA_API_KEY="+p.config.APIKey)
+	return cmd
+}
+
+// BuildImage is not supported by the Daytona provider: sandboxes are created
+// from a pre-built image, and there is no local Docker daemon on the
+// Daytona runner to build against. Push the image to a registry the
+// Daytona API can pull from and reference it with task.toml's docker_image
+// instead.
+func (p *Provider) BuildImage(ctx context.Context, opts environment.BuildImageOptions) (string, error) {
+	return "", fmt.Errorf("daytona provider does not support building images; push %s to a registry and use docker_image instead", opts.Tag)
+}
+
+// PullImage is a no-op: Daytona pulls the sandbox's image itself when the
+// sandbox is created.
+func (p *Provider) PullImage(ctx context.Context, imageRef string) error {
+	slog.Debug("skipping explicit image pull, daytona will pull on sandbox creation", "image", imageRef)
+	return nil
+}
+
+// CreateEnvironment creates a Daytona sandbox from the trial image.
+func (p *Provider) CreateEnvironment(ctx context.Context, opts environment.CreateEnvironmentOptions) (environment.Environment, error) {
+	name := opts.Name
+	if name == "" {
+		name = fmt.Sprintf("rollout-%d", time.Now().UnixNano())
+	}
+
+	args := []string{"sandbox", "create",
+		"--image", opts.ImageRef,
+		"--name", name,
+		"--cpu", fmt.Sprintf("%d", cpuOrDefault(opts.CPUs)),
+		"--memory", fmt.Sprintf("%d", memoryGBOrDefault(opts.MemoryMB)),
+		"--disk", fmt.Sprintf("%d", storageGBOrDefault(opts.StorageMB)),
+		"-y",
+	}
+	if p.config.Target != "" {
+		args = append(args, "--target", p.config.Target)
+	}
+	for k, v := range opts.Env {
+		args = append(args, "--env", fmt.Sprintf("%s=%s", k, v))
+	}
+
+	slog.Debug("creating daytona sandbox", "name", name, "image", opts.ImageRef)
+
+	cmd := p.daytona(ctx, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("creating sandbox: %w: %s", err, stderr.String())
+	}
+
+	var resp struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil || resp.ID == "" {
+		// Fall back to the name we assigned: some daytona CLI versions print
+		// a human-readable summary instead of JSON on success.
+		resp.ID = name
+	}
+
+	slog.Debug("daytona sandbox running", "sandbox", resp.ID)
+
+	return &Environment{
+		provider:  p,
+		sandboxID: resp.ID,
+		cpus:      cpuOrDefault(opts.CPUs),
+		memoryGB:  memoryGBOrDefault(opts.MemoryMB),
+		startTime: time.Now(),
+	}, nil
+}
+
+func cpuOrDefault(cpus int) int {
+	if cpus <= 0 {
+		return 1
+	}
+	return cpus
+}
+
+func memoryGBOrDefault(memoryMB int) int {
+	if memoryMB <= 0 {
+		return 2
+	}
+	gb := (memoryMB + 1023) / 1024
+	if gb <= 0 {
+		gb = 1
+	}
+	return gb
+}
+
+func storageGBOrDefault(storageMB int) int {
+	if storageMB <= 0 {
+		return 10
+	}
+	gb := (storageMB + 1023) / 1024
+	if gb <= 0 {
+		gb = 1
+	}
+	return gb
+}
+
+// Environment represents a running Daytona sandbox.
+type Environment struct {
+	provider  *Provider
+	sandboxID string
+	cpus      int
+	memoryGB  int
+	startTime time.Time
+}
+
+// ID returns the sandbox ID.
+func (e *Environment) ID() string {
+	return e.sandboxID
+}
+
+// CopyTo copies a local file or directory into the sandbox.
+func (e *Environment) CopyTo(ctx context.Context, src, dst string) error {
+	slog.Debug("copying to sandbox", "sandbox", e.sandboxID, "src", src, "dst", dst)
+
+	cmd := e.provider.daytona(ctx, "cp", src, fmt.Sprintf("%s:%s", e.sandboxID, dst))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("copying to sandbox: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// CopyFrom copies a file or directory from the sandbox to a local path.
+func (e *Environment) CopyFrom(ctx context.Context, src, dst string) error {
+	slog.Debug("copying from sandbox", "sandbox", e.sandboxID, "src", src, "dst", dst)
+
+	cmd := e.provider.daytona(ctx, "cp", fmt.Sprintf("%s:%s", e.sandboxID, src), dst)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("copying from sandbox: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// buildShellCommand wraps cmd in the cd/export prefix needed to apply
+// opts.WorkDir and opts.Env before bash runs it, shell-quoting both so a
+// value containing shell metacharacters can't inject extra commands.
+func buildShellCommand(cmd string, opts environment.ExecOptions) string {
+	shellCmd := cmd
+	if opts.WorkDir != "" {
+		shellCmd = fmt.Sprintf("cd %s && %s", environment.ShellQuote(opts.WorkDir), cmd)
+	}
+	for k, v := range opts.Env {
+		shellCmd = fmt.Sprintf("export %s=%s && %s", k, environment.ShellQuote(v), shellCmd)
+	}
+	return shellCmd
+}
+
+// Exec executes a command in the sandbox.
+func (e *Environment) Exec(ctx context.Context, cmd string, stdout, stderr io.Writer, opts environment.ExecOptions) (int, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	shellCmd := buildShellCommand(cmd, opts)
+
+	execCmd := e.provider.daytona(ctx, "exec", e.sandboxID, "--", "bash", "-c", shellCmd)
+	execCmd.Stdout = stdout
+	execCmd.Stderr = stderr
+
+	err := execCmd.Run()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode(), nil
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return -1, fmt.Errorf("command timed out")
+		}
+		return -1, fmt.Errorf("executing command: %w", err)
+	}
+	return 0, nil
+}
+
+// Stop stops the sandbox without removing it, so it can be resumed later.
+func (e *Environment) Stop(ctx context.Context) error {
+	slog.Debug("stopping daytona sandbox", "sandbox", e.sandboxID)
+
+	cmd := e.provider.daytona(ctx, "sandbox", "stop", e.sandboxID)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("stopping sandbox: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// Destroy removes the sandbox and releases its resources.
+func (e *Environment) Destroy(ctx context.Context) error {
+	slog.Debug("destroying daytona sandbox", "sandbox", e.sandboxID)
+
+	cmd := e.provider.daytona(ctx, "sandbox", "delete", e.sandboxID, "-y")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("destroying sandbox: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// Cost returns the cost incurred by this sandbox. It first tries Daytona's
+// own usage reporting for this sandbox, and falls back to a local estimate
+// from its configured resources and wall-clock lifetime if that data isn't
+// available (e.g. the CLI doesn't support it, or usage hasn't been billed
+// yet).
+func (e *Environment) Cost() float64 {
+	if cost, err := e.billedCost(); err == nil {
+		return cost
+	}
+	return e.estimatedCost()
+}
+
+// billedCost queries Daytona's usage reporting for the exact amount billed
+// for this sandbox so far.
+func (e *Environment) billedCost() (float64, error) {
+	cmd := e.provider.daytona(context.Background(), "sandbox", "usage", e.sandboxID, "--format", "json")
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return 0, fmt.Errorf("querying sandbox usage: %w", err)
+	}
+
+	var resp struct {
+		CostUSD float64 `json:"cost_usd"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return 0, fmt.Errorf("parsing sandbox usage: %w", err)
+	}
+	return resp.CostUSD, nil
+}
+
+// estimatedCost approximates the sandbox's cost from its configured
+// resources and wall-clock lifetime, using Daytona's published on-demand
+// rates (approximate, as of 2024):
+// - vCPU: ~$0.02 per vCPU-hour
+// - Memory: ~$0.005 per GB-hour
+func (e *Environment) estimatedCost() float64 {
+	hours := time.Since(e.startTime).Hours()
+	cpuCost := hours * float64(e.cpus) * 0.02
+	memoryCost := hours * float64(e.memoryGB) * 0.005
+	return cpuCost + memoryCost
+}
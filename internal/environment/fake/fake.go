@@ -0,0 +1,312 @@
+// Package fake implements the "fake" environment provider: an in-memory
+// environment with no Docker, VM, or network dependency, for fast
+// deterministic end-to-end tests of orchestration, reporting, and new
+// features. It never builds or runs a real container - BuildImage and
+// PullImage are no-ops, and Exec/CopyTo/CopyFrom operate against an
+// in-memory filesystem instead of shelling out anywhere.
+package fake
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spachava753/rollout/internal/environment"
+)
+
+// ProviderConfig holds fake-provider-specific configuration: the scripted
+// behaviors that make it useful for tests instead of always succeeding
+// instantly.
+type ProviderConfig struct {
+	// DefaultReward is the reward written to /logs/verifier/reward.txt
+	// (where runVerifier reads it from) for any task not named in Rewards.
+	DefaultReward float64
+	// Rewards maps a task name to the reward its environment reports,
+	// overriding DefaultReward for that task.
+	Rewards map[string]float64
+	// Latency delays every CreateEnvironment call by this long, simulating
+	// a real provider's environment startup time.
+	Latency time.Duration
+	// FailureRate is the fraction (0-1) of CreateEnvironment calls that
+	// fail with a simulated provisioning error, for exercising retry and
+	// resume behavior without spending real money on a real provider.
+	FailureRate float64
+	// ExecFailureRate is the fraction (0-1) of Exec calls (other than the
+	// reward.txt read runVerifier depends on) that return a non-zero exit
+	// code, for exercising install/execute/verifier failure handling.
+	ExecFailureRate float64
+	// Seed seeds the provider's random number generator, so FailureRate
+	// and ExecFailureRate are deterministic across runs of the same test.
+	Seed int64
+}
+
+// ParseProviderConfig extracts fake-provider-specific config from the
+// generic config map. Used both for the job-level provider_config and for
+// a task's per-trial provider_hints (opts.Config), letting a single task
+// pin its own reward or failure behavior.
+func ParseProviderConfig(config map[string]any) ProviderConfig {
+	var pc ProviderConfig
+	if config == nil {
+		return pc
+	}
+	if v, ok := config["default_reward"].(float64); ok {
+		pc.DefaultReward = v
+	}
+	if v, ok := config["rewards"].(map[string]any); ok {
+		pc.Rewards = make(map[string]float64, len(v))
+		for name, reward := range v {
+			if r, ok := reward.(float64); ok {
+				pc.Rewards[name] = r
+			}
+		}
+	}
+	if v, ok := config["latency_ms"].(float64); ok {
+		pc.Latency = time.Duration(v) * time.Millisecond
+	}
+	if v, ok := config["failure_rate"].(float64); ok {
+		pc.FailureRate = v
+	}
+	if v, ok := config["exec_failure_rate"].(float64); ok {
+		pc.ExecFailureRate = v
+	}
+	if v, ok := config["seed"].(float64); ok {
+		pc.Seed = int64(v)
+	}
+	return pc
+}
+
+// Provider implements the in-memory "fake" environment provider.
+type Provider struct {
+	config ProviderConfig
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// NewProvider creates a new fake provider.
+func NewProvider(config ProviderConfig) *Provider {
+	return &Provider{config: config, rng: rand.New(rand.NewSource(config.Seed))}
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "fake"
+}
+
+// BuildImage is a no-op: the fake provider never runs a real image, so
+// there's nothing to build. Returns opts.Tag as-is.
+func (p *Provider) BuildImage(ctx context.Context, opts environment.BuildImageOptions) (string, error) {
+	return opts.Tag, nil
+}
+
+// PullImage is a no-op for the same reason as BuildImage.
+func (p *Provider) PullImage(ctx context.Context, imageRef string) error {
+	return nil
+}
+
+// chance draws the next deterministic random float in [0, 1) from the
+// provider's seeded RNG. Guarded by mu since multiple trials call
+// CreateEnvironment/Exec concurrently, and rand.Rand isn't goroutine-safe.
+func (p *Provider) chance() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.rng.Float64()
+}
+
+// rewardFor resolves the reward an environment should report, layering a
+// task's per-trial provider_hints (opts.Config) over the provider's own
+// Rewards/DefaultReward, the same way the modal provider layers sandbox
+// resource hints.
+func (p *Provider) rewardFor(taskName string, hints ProviderConfig) float64 {
+	if r, ok := hints.Rewards[taskName]; ok {
+		return r
+	}
+	if r, ok := p.config.Rewards[taskName]; ok {
+		return r
+	}
+	if hints.DefaultReward != 0 {
+		return hints.DefaultReward
+	}
+	return p.config.DefaultReward
+}
+
+// CreateEnvironment creates an in-memory environment, simulating p.config's
+// latency and failure rate before returning.
+func (p *Provider) CreateEnvironment(ctx context.Context, opts environment.CreateEnvironmentOptions) (environment.Environment, error) {
+	if p.config.Latency > 0 {
+		select {
+		case <-time.After(p.config.Latency):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if p.chance() < p.config.FailureRate {
+		return nil, fmt.Errorf("fake provider: injected environment-creation failure")
+	}
+
+	hints := ParseProviderConfig(opts.Config)
+	taskName, _ := opts.Config["task_name"].(string)
+	reward := p.rewardFor(taskName, hints)
+	execFailureRate := p.config.ExecFailureRate
+	if opts.Config != nil {
+		if v, ok := opts.Config["exec_failure_rate"].(float64); ok {
+			execFailureRate = v
+		}
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = fmt.Sprintf("fake-%d", time.Now().UnixNano())
+	}
+
+	env := &Environment{
+		provider:        p,
+		name:            name,
+		execFailureRate: execFailureRate,
+		files:           map[string][]byte{},
+	}
+	env.files["/logs/verifier/reward.txt"] = []byte(strconv.FormatFloat(reward, 'f', -1, 64))
+	return env, nil
+}
+
+// Environment is an in-memory environment with no backing container,
+// process, or VM. Its files map stands in for a real filesystem: CopyTo/
+// CopyFrom read and write it directly, and Exec answers `cat <path>`
+// against it so the verifier's reward.txt read (see
+// DefaultTrialExecutor.runVerifier) works without any real shell.
+type Environment struct {
+	provider        *Provider
+	name            string
+	execFailureRate float64
+	destroyed       bool
+
+	mu    sync.Mutex
+	files map[string][]byte
+	cost  float64
+}
+
+// ID returns the environment's name.
+func (e *Environment) ID() string {
+	return e.name
+}
+
+// CopyTo stores src's contents under dst in the environment's in-memory
+// filesystem. If src is a directory, every file under it is stored,
+// keyed by its path relative to src joined onto dst.
+func (e *Environment) CopyTo(ctx context.Context, src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("stat %s: %w", src, err)
+	}
+	if !info.IsDir() {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", src, err)
+		}
+		e.setFile(dst, data)
+		return nil
+	}
+	return filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", path, err)
+		}
+		e.setFile(filepath.Join(dst, rel), data)
+		return nil
+	})
+}
+
+// CopyFrom writes the in-memory file at src out to the local path dst.
+func (e *Environment) CopyFrom(ctx context.Context, src, dst string) error {
+	data, ok := e.getFile(src)
+	if !ok {
+		return fmt.Errorf("fake provider: %s not found in environment", src)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(dst), err)
+	}
+	if err := os.WriteFile(dst, data, 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", dst, err)
+	}
+	return nil
+}
+
+// Exec answers `cat <path>` against the environment's in-memory
+// filesystem (the only command the trial pipeline actually depends on the
+// content of, via reward.txt) and otherwise simulates a scripted
+// install/execute/verifier command: a deterministic ExecFailureRate
+// fraction of calls exit 1, the rest exit 0.
+func (e *Environment) Exec(ctx context.Context, cmd string, stdout, stderr io.Writer, opts environment.ExecOptions) (int, error) {
+	if rest, ok := strings.CutPrefix(cmd, "cat "); ok {
+		path := strings.TrimSpace(rest)
+		data, ok := e.getFile(path)
+		if !ok {
+			if stderr != nil {
+				fmt.Fprintf(stderr, "cat: %s: No such file or directory\n", path)
+			}
+			return 1, nil
+		}
+		if stdout != nil {
+			stdout.Write(data)
+		}
+		return 0, nil
+	}
+
+	if e.provider.chance() < e.execFailureRate {
+		if stderr != nil {
+			fmt.Fprintf(stderr, "fake provider: injected exec failure for %q\n", cmd)
+		}
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Stop is a no-op: an in-memory environment has no process to stop.
+func (e *Environment) Stop(ctx context.Context) error {
+	return nil
+}
+
+// Destroy discards the environment's in-memory filesystem.
+func (e *Environment) Destroy(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.destroyed = true
+	e.files = nil
+	return nil
+}
+
+// Cost returns 0: the fake provider never incurs real infrastructure cost.
+func (e *Environment) Cost() float64 {
+	return e.cost
+}
+
+func (e *Environment) setFile(path string, data []byte) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.files[path] = data
+}
+
+func (e *Environment) getFile(path string) ([]byte, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	data, ok := e.files[path]
+	return data, ok
+}
@@ -0,0 +1,48 @@
+package fake
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// BenchmarkCopyTo measures CopyTo's throughput storing a single file into
+// an environment's in-memory filesystem, the fast path every trial's
+// install phase exercises when copying task files into the environment.
+func BenchmarkCopyTo(b *testing.B) {
+	dir := b.TempDir()
+	src := filepath.Join(dir, "payload.bin")
+	data := make([]byte, 1<<20) // 1MB
+	if err := os.WriteFile(src, data, 0644); err != nil {
+		b.Fatal(err)
+	}
+
+	env := &Environment{files: map[string][]byte{}}
+	ctx := context.Background()
+
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		if err := env.CopyTo(ctx, src, "/workdir/payload.bin"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkCopyFrom measures CopyFrom's throughput reading a single file
+// out of an environment's in-memory filesystem, the fast path every
+// trial's result-collection phase exercises.
+func BenchmarkCopyFrom(b *testing.B) {
+	dir := b.TempDir()
+	data := make([]byte, 1<<20) // 1MB
+	env := &Environment{files: map[string][]byte{"/workdir/payload.bin": data}}
+	ctx := context.Background()
+
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		dst := filepath.Join(dir, "out.bin")
+		if err := env.CopyFrom(ctx, "/workdir/payload.bin", dst); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,400 @@
+// Package fargate implements the "fargate" environment provider, which runs
+// one ECS task per trial on AWS Fargate instead of a local Docker host. It
+// shells out to the aws CLI, the same approach the docker and k8s providers
+// take with their respective CLIs, rather than linking the AWS SDK.
+package fargate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"time"
+
+	"github.com/spachava753/rollout/internal/environment"
+)
+
+// ProviderConfig holds Fargate-specific configuration.
+type ProviderConfig struct {
+	// Cluster is the ECS cluster name or ARN trial tasks run in.
+	Cluster string
+	// Region is the AWS region passed to every aws CLI invocation. If
+	// empty, the CLI's default region resolution (profile, env vars) is used.
+	Region string
+	// Subnets are the VPC subnet IDs used for the task's network configuration.
+	Subnets []string
+	// SecurityGroups are the VPC security group IDs attached to the task.
+	SecurityGroups []string
+	// AssignPublicIP controls whether the task ENI gets a public IP.
+	// Required when subnets have no NAT gateway to reach the image registry.
+	AssignPublicIP bool
+	// ExecutionRoleArn is the IAM role ECS assumes to pull the image and
+	// write logs. Required by Fargate task definitions.
+	ExecutionRoleArn string
+	// TaskRoleArn is the IAM role the container itself assumes, if any.
+	TaskRoleArn string
+}
+
+// ParseProviderConfig extracts Fargate-specific config from the generic config map.
+func ParseProviderConfig(config map[string]any) ProviderConfig {
+	var pc ProviderConfig
+	if config == nil {
+		return pc
+	}
+	if v, ok := config["cluster"].(string); ok {
+		pc.Cluster = v
+	}
+	if v, ok := config["region"].(string); ok {
+		pc.Region = v
+	}
+	if v, ok := config["subnets"].([]any); ok {
+		for _, s := range v {
+			if str, ok := s.(string); ok {
+				pc.Subnets = append(pc.Subnets, str)
+			}
+		}
+	}
+	if v, ok := config["security_groups"].([]any); ok {
+		for _, s := range v {
+			if str, ok := s.(string); ok {
+				pc.SecurityGroups = append(pc.SecurityGroups, str)
+			}
+		}
+	}
+	if v, ok := config["assign_public_ip"].(bool); ok {
+		pc.AssignPublicIP = v
+	}
+	if v, ok := config["execution_role_arn"].(string); ok {
+		pc.ExecutionRoleArn = v
+	}
+	if v, ok := config["task_role_arn"].(string); ok {
+		pc.TaskRoleArn = v
+	}
+	return pc
+}
+
+// Provider implements the AWS Fargate environment provider.
+type Provider struct {
+	config ProviderConfig
+}
+
+// NewProvider creates a new Fargate provider.
+func NewProvider(config ProviderConfig) *Provider {
+	return &Provider{config: config}
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "fargate"
+}
+
+// awsArgs returns the --region flag shared by every aws CLI invocation this
+// provider makes, when a region is configured.
+func (p *Provider) awsArgs() []string {
+	if p.config.Region == "" {
+		return nil
+	}
+	return []string{"--region", p.config.Region}
+}
+
+func (p *Provider) aws(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, "aws", append(p.awsArgs(), args...)...)
+}
+
+// BuildImage is not supported by the Fargate provider: there is no local
+// Docker daemon on ECS to build against. Push the image to ECR (or another
+// registry Fargate can pull from) and reference it with task.toml's
+// docker_image instead.
+func (p *Provider) BuildImage(ctx context.Context, opts environment.BuildImageOptions) (string, error) {
+	return "", fmt.Errorf("fargate provider does not support building images; push %s to ECR and use docker_image instead", opts.Tag)
+}
+
+// PullImage is a no-op: Fargate pulls the task definition's image itself
+// when the task is launched.
+func (p *Provider) PullImage(ctx context.Context, imageRef string) error {
+	slog.Debug("skipping explicit image pull, fargate will pull on task launch", "image", imageRef)
+	return nil
+}
+
+// taskDefinition mirrors the subset of the ECS RegisterTaskDefinition
+// request shape this provider needs.
+type taskDefinition struct {
+	Family                  string                `json:"family"`
+	RequiresCompatibilities []string              `json:"requiresCompatibilities"`
+	NetworkMode             string                `json:"networkMode"`
+	Cpu                     string                `json:"cpu"`
+	Memory                  string                `json:"memory"`
+	ExecutionRoleArn        string                `json:"executionRoleArn,omitempty"`
+	TaskRoleArn             string                `json:"taskRoleArn,omitempty"`
+	ContainerDefinitions    []containerDefinition `json:"containerDefinitions"`
+}
+
+type containerDefinition struct {
+	Name            string          `json:"name"`
+	Image           string          `json:"image"`
+	Essential       bool            `json:"essential"`
+	Command         []string        `json:"command"`
+	Environment     []kv            `json:"environment,omitempty"`
+	LinuxParameters map[string]bool `json:"linuxParameters,omitempty"`
+}
+
+type kv struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// fargateCPU converts requested whole vCPUs to Fargate CPU units (1024
+// units == 1 vCPU), defaulting to 1 vCPU. The result is not rounded to the
+// set of values Fargate accepts.
+func fargateCPU(cpus int) string {
+	if cpus <= 0 {
+		cpus = 1
+	}
+	return fmt.Sprintf("%d", cpus*1024)
+}
+
+// fargateMemory formats requested memory in MB, defaulting to 2048, as a
+// string for the task definition's "memory" field.
+func fargateMemory(memoryMB int) string {
+	if memoryMB <= 0 {
+		memoryMB = 2048
+	}
+	return fmt.Sprintf("%d", memoryMB)
+}
+
+// CreateEnvironment registers a task definition for the trial image and
+// runs it, waiting for the task to reach RUNNING.
+func (p *Provider) CreateEnvironment(ctx context.Context, opts environment.CreateEnvironmentOptions) (environment.Environment, error) {
+	family := opts.Name
+	if family == "" {
+		family = fmt.Sprintf("rollout-%d", time.Now().UnixNano())
+	}
+
+	env := make([]kv, 0, len(opts.Env))
+	for k, v := range opts.Env {
+		env = append(env, kv{Name: k, Value: v})
+	}
+
+	def := taskDefinition{
+		Family:                  family,
+		RequiresCompatibilities: []string{"FARGATE"},
+		NetworkMode:             "awsvpc",
+		Cpu:                     fargateCPU(opts.CPUs),
+		Memory:                  fargateMemory(opts.MemoryMB),
+		ExecutionRoleArn:        p.config.ExecutionRoleArn,
+		TaskRoleArn:             p.config.TaskRoleArn,
+		ContainerDefinitions: []containerDefinition{{
+			Name:            "trial",
+			Image:           opts.ImageRef,
+			Essential:       true,
+			Command:         []string{"sleep", "infinity"},
+			Environment:     env,
+			LinuxParameters: map[string]bool{"initProcessEnabled": true},
+		}},
+	}
+	defJSON, err := json.Marshal(def)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling task definition: %w", err)
+	}
+
+	slog.Debug("registering fargate task definition", "family", family, "image", opts.ImageRef)
+
+	registerCmd := p.aws(ctx, "ecs", "register-task-definition", "--cli-input-json", "file:///dev/stdin")
+	registerCmd.Stdin = bytes.NewReader(defJSON)
+	var registerOut, stderr bytes.Buffer
+	registerCmd.Stdout = &registerOut
+	registerCmd.Stderr = &stderr
+	if err := registerCmd.Run(); err != nil {
+		return nil, fmt.Errorf("registering task definition: %w: %s", err, stderr.String())
+	}
+
+	var registerResp struct {
+		TaskDefinition struct {
+			TaskDefinitionArn string `json:"taskDefinitionArn"`
+		} `json:"taskDefinition"`
+	}
+	if err := json.Unmarshal(registerOut.Bytes(), &registerResp); err != nil {
+		return nil, fmt.Errorf("parsing register-task-definition output: %w", err)
+	}
+
+	netConfig := fmt.Sprintf(
+		`{"awsvpcConfiguration":{"subnets":%s,"securityGroups":%s,"assignPublicIp":"%s"}}`,
+		toJSONArray(p.config.Subnets), toJSONArray(p.config.SecurityGroups), assignPublicIPString(p.config.AssignPublicIP),
+	)
+
+	slog.Debug("running fargate task", "cluster", p.config.Cluster, "task_definition", registerResp.TaskDefinition.TaskDefinitionArn)
+
+	runCmd := p.aws(ctx, "ecs", "run-task",
+		"--cluster", p.config.Cluster,
+		"--launch-type", "FARGATE",
+		"--task-definition", registerResp.TaskDefinition.TaskDefinitionArn,
+		"--network-configuration", netConfig,
+	)
+	var runOut bytes.Buffer
+	runCmd.Stdout = &runOut
+	stderr.Reset()
+	runCmd.Stderr = &stderr
+	if err := runCmd.Run(); err != nil {
+		return nil, fmt.Errorf("running task: %w: %s", err, stderr.String())
+	}
+
+	var runResp struct {
+		Tasks []struct {
+			TaskArn string `json:"taskArn"`
+		} `json:"tasks"`
+	}
+	if err := json.Unmarshal(runOut.Bytes(), &runResp); err != nil {
+		return nil, fmt.Errorf("parsing run-task output: %w", err)
+	}
+	if len(runResp.Tasks) == 0 {
+		return nil, fmt.Errorf("run-task started no tasks: %s", stderr.String())
+	}
+	taskArn := runResp.Tasks[0].TaskArn
+
+	waitCmd := p.aws(ctx, "ecs", "wait", "tasks-running", "--cluster", p.config.Cluster, "--tasks", taskArn)
+	stderr.Reset()
+	waitCmd.Stderr = &stderr
+	if err := waitCmd.Run(); err != nil {
+		return nil, fmt.Errorf("waiting for task to become running: %w: %s", err, stderr.String())
+	}
+
+	slog.Debug("task running", "task", taskArn)
+
+	return &FargateEnvironment{
+		provider:  p,
+		taskArn:   taskArn,
+		cpuUnits:  opts.CPUs,
+		memoryMB:  opts.MemoryMB,
+		startTime: time.Now(),
+	}, nil
+}
+
+func toJSONArray(items []string) string {
+	b, _ := json.Marshal(items)
+	return string(b)
+}
+
+func assignPublicIPString(v bool) string {
+	if v {
+		return "ENABLED"
+	}
+	return "DISABLED"
+}
+
+// FargateEnvironment represents a running trial task.
+type FargateEnvironment struct {
+	provider  *Provider
+	taskArn   string
+	cpuUnits  int
+	memoryMB  int
+	startTime time.Time
+}
+
+// ID returns the task ARN.
+func (e *FargateEnvironment) ID() string {
+	return e.taskArn
+}
+
+// CopyTo is not supported: ECS Exec has no direct file-copy equivalent to
+// docker cp or kubectl cp. Stage files through S3 and have the agent fetch
+// them, or bake them into the image instead.
+func (e *FargateEnvironment) CopyTo(ctx context.Context, src, dst string) error {
+	return fmt.Errorf("fargate provider does not support direct file copy; stage %s through S3 instead", src)
+}
+
+// CopyFrom is not supported for the same reason as CopyTo.
+func (e *FargateEnvironment) CopyFrom(ctx context.Context, src, dst string) error {
+	return fmt.Errorf("fargate provider does not support direct file copy; stage %s through S3 instead", src)
+}
+
+// buildShellCommand wraps cmd in the cd/export prefix needed to apply
+// opts.WorkDir and opts.Env before bash runs it, shell-quoting both so a
+// value containing shell metacharacters can't inject extra commands.
+func buildShellCommand(cmd string, opts environment.ExecOptions) string {
+	shellCmd := cmd
+	if opts.WorkDir != "" {
+		shellCmd = fmt.Sprintf("cd %s && %s", environment.ShellQuote(opts.WorkDir), cmd)
+	}
+	for k, v := range opts.Env {
+		shellCmd = fmt.Sprintf("export %s=%s && %s", k, environment.ShellQuote(v), shellCmd)
+	}
+	return shellCmd
+}
+
+// Exec executes a command in the task's container via ECS Exec.
+func (e *FargateEnvironment) Exec(ctx context.Context, cmd string, stdout, stderr io.Writer, opts environment.ExecOptions) (int, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	shellCmd := buildShellCommand(cmd, opts)
+
+	execCmd := e.provider.aws(ctx, "ecs", "execute-command",
+		"--cluster", e.provider.config.Cluster,
+		"--task", e.taskArn,
+		"--container", "trial",
+		"--interactive",
+		"--command", fmt.Sprintf("bash -c %s", environment.ShellQuote(shellCmd)),
+	)
+	execCmd.Stdout = stdout
+	execCmd.Stderr = stderr
+
+	err := execCmd.Run()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode(), nil
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return -1, fmt.Errorf("command timed out")
+		}
+		return -1, fmt.Errorf("executing command: %w", err)
+	}
+	return 0, nil
+}
+
+// Stop stops the ECS task. Fargate tasks have no pause state, so this is
+// equivalent to Destroy.
+func (e *FargateEnvironment) Stop(ctx context.Context) error {
+	return e.stopTask(ctx, "trial stopped")
+}
+
+// Destroy stops the ECS task and releases its resources.
+func (e *FargateEnvironment) Destroy(ctx context.Context) error {
+	return e.stopTask(ctx, "trial destroyed")
+}
+
+func (e *FargateEnvironment) stopTask(ctx context.Context, reason string) error {
+	slog.Debug("stopping fargate task", "task", e.taskArn)
+
+	cmd := e.provider.aws(ctx, "ecs", "stop-task", "--cluster", e.provider.config.Cluster, "--task", e.taskArn, "--reason", reason)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("stopping task: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// Cost returns the cost incurred by this environment.
+// Fargate Linux/x86_64 on-demand pricing (approximate, us-east-1, as of 2024):
+// - vCPU: ~$0.04048 per vCPU-hour
+// - Memory: ~$0.004445 per GB-hour
+func (e *FargateEnvironment) Cost() float64 {
+	hours := time.Since(e.startTime).Hours()
+	cpus := e.cpuUnits
+	if cpus <= 0 {
+		cpus = 1
+	}
+	memoryGB := float64(e.memoryMB) / 1024.0
+	if memoryGB <= 0 {
+		memoryGB = 2
+	}
+	cpuCost := hours * float64(cpus) * 0.04048
+	memoryCost := hours * memoryGB * 0.004445
+	return cpuCost + memoryCost
+}
@@ -0,0 +1,37 @@
+package fargate
+
+import (
+	"fmt"
+	"os/exec"
+	"testing"
+
+	"github.com/spachava753/rollout/internal/environment"
+)
+
+// TestBuildShellCommandNeutralizesShellMetacharacters guards against a
+// regression to fmt's %q, which escapes Go string syntax but leaves shell
+// metacharacters like $(...) live: a value containing one must come out
+// the other side of a real shell unexecuted. ECS Exec's --command is
+// itself run through a shell, so this also exercises the outer
+// "bash -c <quoted>" wrapping built around buildShellCommand's result.
+func TestBuildShellCommandNeutralizesShellMetacharacters(t *testing.T) {
+	pwned := t.TempDir() + "/pwned"
+	opts := environment.ExecOptions{
+		WorkDir: t.TempDir(),
+		Env:     map[string]string{"MYVAR": "pass$(touch " + pwned + ")word"},
+	}
+
+	shellCmd := buildShellCommand("echo ran", opts)
+	outerCmd := fmt.Sprintf("bash -c %s", environment.ShellQuote(shellCmd))
+
+	out, err := exec.Command("sh", "-c", outerCmd).Output()
+	if err != nil {
+		t.Fatalf("running built command: %v", err)
+	}
+	if string(out) != "ran\n" {
+		t.Fatalf("expected command output %q, got %q", "ran\n", out)
+	}
+	if _, err := exec.Command("test", "-e", pwned).Output(); err == nil {
+		t.Fatal("command substitution in env value was executed by the shell")
+	}
+}
@@ -0,0 +1,395 @@
+// Package hetzner implements the "hetzner" environment provider, which
+// rents a Hetzner Cloud server per trial, installs Docker on it via
+// cloud-init at boot, and runs the trial image on it over SSH exactly like
+// the ssh provider does for a user's own remote host. Hetzner's hcloud CLI
+// handles server lifecycle (create/delete); everything from cloud-init
+// finishing to running the trial's container is the same
+// docker-over-ssh approach as the ssh and lambdacloud providers.
+package hetzner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spachava753/rollout/internal/environment"
+)
+
+// cloudInit installs Docker on a fresh server at boot, so the server is
+// ready to accept `docker run` over SSH by the time it's reachable.
+const cloudInit = `#cloud-config
+runcmd:
+  - curl -fsSL https://get.docker.com | sh
+  - systemctl enable --now docker
+`
+
+// ProviderConfig holds Hetzner Cloud-specific configuration.
+type ProviderConfig struct {
+	// APIToken authenticates with the Hetzner Cloud API, passed to hcloud
+	// via the HCLOUD_TOKEN environment variable.
+	APIToken string
+	// ServerType is the Hetzner server type to rent (e.g. "cpx21").
+	// Required.
+	ServerType string
+	// Image is the base OS image (e.g. "ubuntu-24.04"). Required.
+	Image string
+	// Location is the Hetzner datacenter location (e.g. "nbg1"). Optional;
+	// hcloud picks a default when empty.
+	Location string
+	// SSHKeyName is the name of an SSH key already registered with the
+	// Hetzner Cloud project; its corresponding private key is SSHKeyPath.
+	SSHKeyName string
+	// SSHKeyPath is the private key used to reach the server once it's
+	// running.
+	SSHKeyPath string
+}
+
+// ParseProviderConfig extracts Hetzner-specific config from the generic config map.
+func ParseProviderConfig(config map[string]any) ProviderConfig {
+	var pc ProviderConfig
+	if config == nil {
+		return pc
+	}
+	if v, ok := config["api_token"].(string); ok {
+		pc.APIToken = v
+	}
+	if v, ok := config["server_type"].(string); ok {
+		pc.ServerType = v
+	}
+	if v, ok := config["image"].(string); ok {
+		pc.Image = v
+	}
+	if v, ok := config["location"].(string); ok {
+		pc.Location = v
+	}
+	if v, ok := config["ssh_key_name"].(string); ok {
+		pc.SSHKeyName = v
+	}
+	if v, ok := config["ssh_key_path"].(string); ok {
+		pc.SSHKeyPath = v
+	}
+	return pc
+}
+
+// Provider implements the Hetzner Cloud environment provider.
+type Provider struct {
+	config ProviderConfig
+}
+
+// NewProvider creates a new Hetzner Cloud provider.
+func NewProvider(config ProviderConfig) *Provider {
+	return &Provider{config: config}
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "hetzner"
+}
+
+// hcloud runs an hcloud subcommand authenticated via HCLOUD_TOKEN.
+func (p *Provider) hcloud(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "hcloud", args...)
+	cmd.Env = append(os.Environ(), "HCLOUD_TOKEN="+p.config.APIToken)
+	return cmd
+}
+
+// BuildImage is not supported by the Hetzner provider: the server boots a
+// fixed base OS image and has Docker installed via cloud-init, with the
+// task image run as a container on top rather than built into a custom
+// server image. Build the task image locally and push it to a registry the
+// server can pull from; reference it with task.toml's docker_image.
+func (p *Provider) BuildImage(ctx context.Context, opts environment.BuildImageOptions) (string, error) {
+	return "", fmt.Errorf("hetzner provider does not support building images; push %s to a registry and use docker_image instead", opts.Tag)
+}
+
+// PullImage is a no-op here: the image is pulled on the server itself once
+// it's running, as part of CreateEnvironment's docker run.
+func (p *Provider) PullImage(ctx context.Context, imageRef string) error {
+	slog.Debug("skipping explicit image pull, hetzner will pull on server creation", "image", imageRef)
+	return nil
+}
+
+// CreateEnvironment creates a Hetzner Cloud server, waits for cloud-init's
+// Docker install to finish, and starts the trial image as a container on it
+// over SSH.
+func (p *Provider) CreateEnvironment(ctx context.Context, opts environment.CreateEnvironmentOptions) (environment.Environment, error) {
+	name := opts.Name
+	if name == "" {
+		name = fmt.Sprintf("rollout-%d", time.Now().UnixNano())
+	}
+
+	userData, err := os.CreateTemp("", "hetzner-cloud-init-*.yaml")
+	if err != nil {
+		return nil, fmt.Errorf("writing cloud-init file: %w", err)
+	}
+	defer os.Remove(userData.Name())
+	if _, err := userData.WriteString(cloudInit); err != nil {
+		userData.Close()
+		return nil, fmt.Errorf("writing cloud-init file: %w", err)
+	}
+	userData.Close()
+
+	args := []string{"server", "create",
+		"--name", name,
+		"--type", p.config.ServerType,
+		"--image", p.config.Image,
+		"--ssh-key", p.config.SSHKeyName,
+		"--user-data-from-file", userData.Name(),
+		"-o", "json",
+	}
+	if p.config.Location != "" {
+		args = append(args, "--location", p.config.Location)
+	}
+
+	slog.Debug("creating hetzner server", "name", name, "server_type", p.config.ServerType, "image", p.config.Image)
+
+	var stdout, stderr bytes.Buffer
+	createCmd := p.hcloud(ctx, args...)
+	createCmd.Stdout = &stdout
+	createCmd.Stderr = &stderr
+	if err := createCmd.Run(); err != nil {
+		return nil, fmt.Errorf("creating server: %w: %s", err, stderr.String())
+	}
+
+	var created struct {
+		Server struct {
+			ID        int64 `json:"id"`
+			PublicNet struct {
+				IPv4 struct {
+					IP string `json:"ip"`
+				} `json:"ipv4"`
+			} `json:"public_net"`
+		} `json:"server"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &created); err != nil {
+		return nil, fmt.Errorf("parsing server create response: %w: %s", err, stdout.String())
+	}
+
+	env := &Environment{
+		provider:   p,
+		serverID:   created.Server.ID,
+		serverIP:   created.Server.PublicNet.IPv4.IP,
+		serverType: p.config.ServerType,
+		startTime:  time.Now(),
+	}
+
+	if err := env.waitForDocker(ctx); err != nil {
+		return nil, fmt.Errorf("waiting for server %d to finish booting: %w", env.serverID, err)
+	}
+
+	containerID := name
+	args = []string{"run", "-d", "--name", containerID}
+	if opts.CPUs > 0 {
+		args = append(args, "--cpus", strconv.Itoa(opts.CPUs))
+	}
+	if opts.MemoryMB > 0 {
+		args = append(args, "--memory", fmt.Sprintf("%dm", opts.MemoryMB))
+	}
+	for k, v := range opts.Env {
+		args = append(args, "-e", fmt.Sprintf("%s=%s", k, v))
+	}
+	args = append(args, opts.ImageRef, "sleep", "infinity")
+
+	slog.Debug("starting container on hetzner server", "server_id", env.serverID, "image", opts.ImageRef)
+	stderr.Reset()
+	runCmd := env.remoteDocker(ctx, args...)
+	runCmd.Stderr = &stderr
+	if err := runCmd.Run(); err != nil {
+		return nil, fmt.Errorf("starting container on server: %w: %s", err, stderr.String())
+	}
+	env.containerID = containerID
+
+	return env, nil
+}
+
+// waitForDocker polls the server over SSH until `docker info` succeeds,
+// i.e. cloud-init's Docker install has finished, for up to 5 minutes.
+func (e *Environment) waitForDocker(ctx context.Context) error {
+	deadline := time.Now().Add(5 * time.Minute)
+	for time.Now().Before(deadline) {
+		if e.remoteDocker(ctx, "info").Run() == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+		}
+	}
+	return fmt.Errorf("timed out waiting for docker to become ready")
+}
+
+// Environment represents a running Docker container on a rented Hetzner
+// Cloud server.
+type Environment struct {
+	provider    *Provider
+	serverID    int64
+	serverIP    string
+	containerID string
+	serverType  string
+	startTime   time.Time
+}
+
+// ID returns the container ID.
+func (e *Environment) ID() string {
+	return e.containerID
+}
+
+func (e *Environment) sshFlags() []string {
+	return []string{
+		"-i", e.provider.config.SSHKeyPath,
+		"-o", "StrictHostKeyChecking=no",
+		"-o", "UserKnownHostsFile=/dev/null",
+	}
+}
+
+func (e *Environment) target() string {
+	return "root@" + e.serverIP
+}
+
+// remoteDocker runs a docker subcommand on the server over ssh, analogous
+// to the ssh provider's remoteDocker.
+func (e *Environment) remoteDocker(ctx context.Context, args ...string) *exec.Cmd {
+	remoteCmd := "docker " + shellJoin(args)
+	sshArgs := append(append([]string{}, e.sshFlags()...), e.target(), remoteCmd)
+	return exec.CommandContext(ctx, "ssh", sshArgs...)
+}
+
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = environment.ShellQuote(a)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// CopyTo copies a local file or directory into the container, staging it on
+// the server via scp and then into the container with docker cp.
+func (e *Environment) CopyTo(ctx context.Context, src, dst string) error {
+	tmp := fmt.Sprintf("/tmp/rollout-cp-%d", time.Now().UnixNano())
+
+	scpArgs := append(append([]string{}, e.sshFlags()...), "-r", src, e.target()+":"+tmp)
+	scpCmd := exec.CommandContext(ctx, "scp", scpArgs...)
+	var stderr bytes.Buffer
+	scpCmd.Stderr = &stderr
+	if err := scpCmd.Run(); err != nil {
+		return fmt.Errorf("staging file to server: %w: %s", err, stderr.String())
+	}
+
+	stderr.Reset()
+	cpCmd := e.remoteDocker(ctx, "cp", tmp, e.containerID+":"+dst)
+	cpCmd.Stderr = &stderr
+	if err := cpCmd.Run(); err != nil {
+		return fmt.Errorf("copying staged file into container: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// CopyFrom copies a file or directory from the container to a local path,
+// staging it through the server's filesystem with docker cp and then scp.
+func (e *Environment) CopyFrom(ctx context.Context, src, dst string) error {
+	tmp := fmt.Sprintf("/tmp/rollout-cp-%d", time.Now().UnixNano())
+
+	var stderr bytes.Buffer
+	cpCmd := e.remoteDocker(ctx, "cp", e.containerID+":"+src, tmp)
+	cpCmd.Stderr = &stderr
+	if err := cpCmd.Run(); err != nil {
+		return fmt.Errorf("copying file out of container: %w: %s", err, stderr.String())
+	}
+
+	stderr.Reset()
+	scpArgs := append(append([]string{}, e.sshFlags()...), "-r", e.target()+":"+tmp, dst)
+	scpCmd := exec.CommandContext(ctx, "scp", scpArgs...)
+	scpCmd.Stderr = &stderr
+	if err := scpCmd.Run(); err != nil {
+		return fmt.Errorf("fetching staged file from server: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// Exec executes a command in the container via the server's Docker daemon.
+func (e *Environment) Exec(ctx context.Context, cmd string, stdout, stderr io.Writer, opts environment.ExecOptions) (int, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	args := []string{"exec"}
+	for k, v := range opts.Env {
+		args = append(args, "-e", fmt.Sprintf("%s=%s", k, v))
+	}
+	if opts.WorkDir != "" {
+		args = append(args, "-w", opts.WorkDir)
+	}
+	args = append(args, e.containerID, "bash", "-c", cmd)
+
+	execCmd := e.remoteDocker(ctx, args...)
+	execCmd.Stdout = stdout
+	execCmd.Stderr = stderr
+
+	err := execCmd.Run()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode(), nil
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return -1, fmt.Errorf("command timed out")
+		}
+		return -1, fmt.Errorf("executing command: %w", err)
+	}
+	return 0, nil
+}
+
+// Stop stops the container but does not delete the server; the rental keeps
+// billing until Destroy deletes it.
+func (e *Environment) Stop(ctx context.Context) error {
+	if err := e.remoteDocker(ctx, "stop", e.containerID).Run(); err != nil {
+		return fmt.Errorf("stopping container: %w", err)
+	}
+	return nil
+}
+
+// Destroy deletes the rented Hetzner Cloud server, taking the container
+// with it.
+func (e *Environment) Destroy(ctx context.Context) error {
+	slog.Debug("deleting hetzner server", "server_id", e.serverID)
+
+	var stderr bytes.Buffer
+	cmd := e.provider.hcloud(ctx, "server", "delete", strconv.FormatInt(e.serverID, 10))
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("deleting server: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// hourlyRates gives approximate on-demand EUR/hour pricing for Hetzner
+// Cloud's shared-vCPU server types, used to estimate Cost() since the
+// hcloud CLI does not expose a per-server running total.
+var hourlyRates = map[string]float64{
+	"cx22":  0.0064,
+	"cpx11": 0.0065,
+	"cpx21": 0.0119,
+	"cpx31": 0.0237,
+	"cpx41": 0.0475,
+	"cpx51": 0.0950,
+}
+
+// Cost estimates spend so far as elapsed hours times serverType's
+// approximate on-demand rate in hourlyRates, or 0 if the type is unknown.
+func (e *Environment) Cost() float64 {
+	rate, ok := hourlyRates[e.serverType]
+	if !ok {
+		return 0
+	}
+	return time.Since(e.startTime).Hours() * rate
+}
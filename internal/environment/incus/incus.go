@@ -0,0 +1,277 @@
+// Package incus implements the "incus" environment provider, which launches
+// system containers with Incus (the LXD successor) instead of Docker, for
+// tasks that need systemd or other full-OS semantics a Docker container
+// can't provide. Like the k8s and ssh providers, it shells out to the incus
+// CLI rather than linking a client library.
+package incus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/spachava753/rollout/internal/environment"
+)
+
+// ProviderConfig holds incus-specific configuration.
+type ProviderConfig struct {
+	// Remote is the incus remote (server) PullImage copies unqualified image
+	// references from, e.g. "local" or a remote added with
+	// `incus remote add`. Empty uses incus's own default remote. Launching,
+	// exec, file transfer and deletion always run against incus's default
+	// remote.
+	Remote string
+	// Project scopes containers to an incus project. Empty uses the
+	// "default" project.
+	Project string
+	// Profile is an additional incus profile applied to every container
+	// (e.g. one that runs a cloud-init script to prep the rootfs), on top
+	// of "default".
+	Profile string
+}
+
+// ParseProviderConfig extracts incus-specific config from the generic config map.
+func ParseProviderConfig(config map[string]any) ProviderConfig {
+	var pc ProviderConfig
+	if config == nil {
+		return pc
+	}
+	if v, ok := config["remote"].(string); ok {
+		pc.Remote = v
+	}
+	if v, ok := config["project"].(string); ok {
+		pc.Project = v
+	}
+	if v, ok := config["profile"].(string); ok {
+		pc.Profile = v
+	}
+	return pc
+}
+
+// Provider implements the incus environment provider.
+type Provider struct {
+	config ProviderConfig
+}
+
+// NewProvider creates a new incus provider.
+func NewProvider(config ProviderConfig) *Provider {
+	return &Provider{config: config}
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "incus"
+}
+
+// globalFlags returns the incus flags shared by every subcommand.
+func (p *Provider) globalFlags() []string {
+	var flags []string
+	if p.config.Project != "" {
+		flags = append(flags, "--project", p.config.Project)
+	}
+	return flags
+}
+
+// incus runs an incus subcommand with the provider's global flags prepended.
+func (p *Provider) incus(ctx context.Context, args ...string) *exec.Cmd {
+	fullArgs := append(p.globalFlags(), args...)
+	return exec.CommandContext(ctx, "incus", fullArgs...)
+}
+
+// qualify prefixes imageRef with the configured remote, so "images:ubuntu/22.04"
+// becomes an explicit fetch from the images remote while a bare alias like
+// "my-image" resolves against Remote (or incus's own default when empty).
+func (p *Provider) qualify(ref string) string {
+	if p.config.Remote == "" || strings.Contains(ref, ":") {
+		return ref
+	}
+	return p.config.Remote + ":" + ref
+}
+
+// BuildImage is not supported by the incus provider: Incus images are built
+// from an OS rootfs (distrobuilder, a cloud-init profile, or `incus publish`
+// of a hand-configured container), not a Dockerfile. Build the image with
+// distrobuilder or publish a configured container ahead of time, import it
+// with `incus image import`, and reference its alias with task.toml's
+// docker_image instead.
+func (p *Provider) BuildImage(ctx context.Context, opts environment.BuildImageOptions) (string, error) {
+	return "", fmt.Errorf("incus provider does not support building images from a Dockerfile; build %s with distrobuilder or incus publish and import it, then use docker_image", opts.Tag)
+}
+
+// PullImage copies a pre-built image into the local incus image store so
+// CreateEnvironment's launch doesn't pay the fetch cost.
+func (p *Provider) PullImage(ctx context.Context, imageRef string) error {
+	slog.Debug("copying incus image into local store", "image", imageRef)
+
+	cmd := p.incus(ctx, "image", "copy", p.qualify(imageRef), "local:")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("copying image: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// CreateEnvironment launches a new incus system container from an image.
+func (p *Provider) CreateEnvironment(ctx context.Context, opts environment.CreateEnvironmentOptions) (environment.Environment, error) {
+	containerID := opts.Name
+	if containerID == "" {
+		containerID = fmt.Sprintf("rollout-%d", time.Now().UnixNano())
+	}
+
+	args := []string{"launch", opts.ImageRef, containerID}
+	if p.config.Profile != "" {
+		args = append(args, "--profile", "default", "--profile", p.config.Profile)
+	}
+	if opts.CPUs > 0 {
+		args = append(args, "-c", fmt.Sprintf("limits.cpu=%d", opts.CPUs))
+	}
+	if opts.MemoryMB > 0 {
+		args = append(args, "-c", fmt.Sprintf("limits.memory=%dMB", opts.MemoryMB))
+	}
+	for k, v := range opts.Env {
+		args = append(args, "-c", fmt.Sprintf("environment.%s=%s", k, v))
+	}
+
+	slog.Debug("launching incus container", "name", containerID, "image", opts.ImageRef)
+
+	cmd := p.incus(ctx, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("launching incus container: %w: %s", err, stderr.String())
+	}
+
+	return &Environment{provider: p, containerID: containerID}, nil
+}
+
+// Environment represents a running incus container.
+type Environment struct {
+	provider    *Provider
+	containerID string
+}
+
+// ID returns the container name.
+func (e *Environment) ID() string {
+	return e.containerID
+}
+
+// CopyTo copies a local file or directory into the container.
+func (e *Environment) CopyTo(ctx context.Context, src, dst string) error {
+	slog.Debug("copying into incus container", "name", e.containerID, "src", src, "dst", dst)
+
+	cmd := e.provider.incus(ctx, "file", "push", "-r", src, e.containerID+dst)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("copying into container: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// CopyFrom copies a file or directory from the container to a local path.
+func (e *Environment) CopyFrom(ctx context.Context, src, dst string) error {
+	slog.Debug("copying from incus container", "name", e.containerID, "src", src, "dst", dst)
+
+	cmd := e.provider.incus(ctx, "file", "pull", "-r", e.containerID+src, dst)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("copying from container: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// Exec executes a command in the container via `incus exec`.
+func (e *Environment) Exec(ctx context.Context, cmd string, stdout, stderr io.Writer, opts environment.ExecOptions) (int, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	args := []string{"exec", e.containerID}
+	for k, v := range opts.Env {
+		args = append(args, "--env", fmt.Sprintf("%s=%s", k, v))
+	}
+	if opts.WorkDir != "" {
+		args = append(args, "--cwd", opts.WorkDir)
+	}
+	args = append(args, "--", "bash", "-c", cmd)
+
+	execCmd := e.provider.incus(ctx, args...)
+	execCmd.Stdout = stdout
+	execCmd.Stderr = stderr
+
+	err := execCmd.Run()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode(), nil
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return -1, fmt.Errorf("command timed out")
+		}
+		return -1, fmt.Errorf("executing command: %w", err)
+	}
+	return 0, nil
+}
+
+// Stats implements environment.ResourceSampler by querying the instance
+// state API (`incus query /1.0/instances/<name>/state`) for a point-in-time
+// snapshot of the container's memory usage; CPU usage is not reported.
+func (e *Environment) Stats(ctx context.Context) (environment.ResourceUsage, error) {
+	cmd := e.provider.incus(ctx, "query", fmt.Sprintf("/1.0/instances/%s/state", e.containerID))
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return environment.ResourceUsage{}, fmt.Errorf("querying incus instance state: %w", err)
+	}
+
+	var state struct {
+		Memory struct {
+			Usage int64 `json:"usage"`
+		} `json:"memory"`
+		CPU struct {
+			Usage int64 `json:"usage"` // nanoseconds of CPU time consumed
+		} `json:"cpu"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &state); err != nil {
+		return environment.ResourceUsage{}, fmt.Errorf("parsing incus instance state: %w", err)
+	}
+
+	return environment.ResourceUsage{MemoryMB: float64(state.Memory.Usage) / (1024 * 1024)}, nil
+}
+
+// Stop stops the container but does not remove it.
+func (e *Environment) Stop(ctx context.Context) error {
+	slog.Debug("stopping incus container", "name", e.containerID)
+
+	cmd := e.provider.incus(ctx, "stop", e.containerID)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("stopping incus container: %w", err)
+	}
+	return nil
+}
+
+// Destroy force-stops and deletes the container.
+func (e *Environment) Destroy(ctx context.Context) error {
+	slog.Debug("destroying incus container", "name", e.containerID)
+
+	cmd := e.provider.incus(ctx, "delete", "--force", e.containerID)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("removing incus container: %w", err)
+	}
+	return nil
+}
+
+// Cost always returns 0: the incus provider runs against a host the user
+// already owns and operates, with no metered billing rollout can observe.
+func (e *Environment) Cost() float64 {
+	return 0
+}
@@ -0,0 +1,298 @@
+// Package k8s implements the "k8s" environment provider, which runs one Pod
+// per trial in a Kubernetes cluster instead of a local Docker host. It
+// shells out to kubectl, the same approach the docker provider takes with
+// the docker CLI, rather than linking a Kubernetes client library.
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/spachava753/rollout/internal/environment"
+)
+
+// ProviderConfig holds k8s-specific configuration.
+type ProviderConfig struct {
+	// Kubeconfig is the path to a kubeconfig file. If empty, kubectl's
+	// default resolution (KUBECONFIG env var, then ~/.kube/config) is used.
+	Kubeconfig string
+	// Context selects a context within the kubeconfig. If empty, the
+	// current context is used.
+	Context string
+	// Namespace is the namespace Pods are created in. Defaults to "default".
+	Namespace string
+	// NodeSelector constrains which nodes trial Pods are scheduled onto.
+	NodeSelector map[string]string
+}
+
+// ParseProviderConfig extracts k8s-specific config from the generic config map.
+func ParseProviderConfig(config map[string]any) ProviderConfig {
+	pc := ProviderConfig{Namespace: "default"}
+	if config == nil {
+		return pc
+	}
+	if v, ok := config["kubeconfig"].(string); ok {
+		pc.Kubeconfig = v
+	}
+	if v, ok := config["context"].(string); ok {
+		pc.Context = v
+	}
+	if v, ok := config["namespace"].(string); ok && v != "" {
+		pc.Namespace = v
+	}
+	if v, ok := config["node_selector"].(map[string]any); ok {
+		pc.NodeSelector = make(map[string]string, len(v))
+		for k, val := range v {
+			if s, ok := val.(string); ok {
+				pc.NodeSelector[k] = s
+			}
+		}
+	}
+	return pc
+}
+
+// Provider implements the Kubernetes environment provider.
+type Provider struct {
+	config ProviderConfig
+}
+
+// NewProvider creates a new k8s provider.
+func NewProvider(config ProviderConfig) *Provider {
+	return &Provider{config: config}
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "k8s"
+}
+
+// kubectlArgs returns the --kubeconfig/--context/-n flags shared by every
+// kubectl invocation this provider makes.
+func (p *Provider) kubectlArgs() []string {
+	var args []string
+	if p.config.Kubeconfig != "" {
+		args = append(args, "--kubeconfig", p.config.Kubeconfig)
+	}
+	if p.config.Context != "" {
+		args = append(args, "--context", p.config.Context)
+	}
+	args = append(args, "-n", p.config.Namespace)
+	return args
+}
+
+func (p *Provider) kubectl(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, "kubectl", append(p.kubectlArgs(), args...)...)
+}
+
+// BuildImage is not supported by the k8s provider: a cluster has no local
+// Docker daemon to build against. Build and push the image via another
+// provider (e.g. docker) or an external CI pipeline, then reference it with
+// task.toml's docker_image.
+func (p *Provider) BuildImage(ctx context.Context, opts environment.BuildImageOptions) (string, error) {
+	return "", fmt.Errorf("k8s provider does not support building images; push %s to a registry the cluster can pull from and use docker_image instead", opts.Tag)
+}
+
+// PullImage is a no-op: the kubelet on the scheduled node pulls the Pod's
+// image automatically when the Pod is created.
+func (p *Provider) PullImage(ctx context.Context, imageRef string) error {
+	slog.Debug("skipping explicit image pull, kubelet will pull on pod creation", "image", imageRef)
+	return nil
+}
+
+// CreateEnvironment creates a Pod and waits for it to become ready.
+func (p *Provider) CreateEnvironment(ctx context.Context, opts environment.CreateEnvironmentOptions) (environment.Environment, error) {
+	podName := opts.Name
+	if podName == "" {
+		podName = fmt.Sprintf("rollout-%d", time.Now().UnixNano())
+	}
+
+	manifest := buildPodManifest(podName, opts, p.config.NodeSelector)
+
+	slog.Debug("applying trial pod manifest", "pod", podName, "namespace", p.config.Namespace, "image", opts.ImageRef)
+
+	applyCmd := p.kubectl(ctx, "apply", "-f", "-")
+	applyCmd.Stdin = strings.NewReader(manifest)
+	var stderr bytes.Buffer
+	applyCmd.Stderr = &stderr
+	if err := applyCmd.Run(); err != nil {
+		return nil, fmt.Errorf("applying pod manifest: %w: %s", err, stderr.String())
+	}
+
+	waitCmd := p.kubectl(ctx, "wait", "--for=condition=Ready", "pod/"+podName, "--timeout=5m")
+	waitCmd.Stderr = &stderr
+	if err := waitCmd.Run(); err != nil {
+		return nil, fmt.Errorf("waiting for pod to become ready: %w: %s", err, stderr.String())
+	}
+
+	slog.Debug("pod ready", "pod", podName)
+
+	return &K8sEnvironment{provider: p, podName: podName}, nil
+}
+
+// buildPodManifest renders a minimal single-container Pod spec. The
+// container runs "sleep infinity" so the trial can exec into it repeatedly,
+// mirroring how the docker provider keeps containers alive.
+func buildPodManifest(podName string, opts environment.CreateEnvironmentOptions, nodeSelector map[string]string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "apiVersion: v1\nkind: Pod\nmetadata:\n  name: %s\n", podName)
+	if len(nodeSelector) > 0 {
+		b.WriteString("spec:\n  nodeSelector:\n")
+		for k, v := range nodeSelector {
+			fmt.Fprintf(&b, "    %s: %q\n", k, v)
+		}
+	} else {
+		b.WriteString("spec:\n")
+	}
+	b.WriteString("  restartPolicy: Never\n  containers:\n  - name: trial\n")
+	fmt.Fprintf(&b, "    image: %s\n", opts.ImageRef)
+	b.WriteString("    command: [\"sleep\", \"infinity\"]\n")
+
+	if opts.CPUs > 0 || opts.MemoryMB > 0 || opts.StorageMB > 0 || opts.GPUCount > 0 {
+		b.WriteString("    resources:\n      requests:\n")
+		writeResourceList(&b, opts)
+		b.WriteString("      limits:\n")
+		writeResourceList(&b, opts)
+	}
+
+	if len(opts.Env) > 0 {
+		b.WriteString("    env:\n")
+		for k, v := range opts.Env {
+			fmt.Fprintf(&b, "    - name: %s\n      value: %q\n", k, v)
+		}
+	}
+
+	return b.String()
+}
+
+func writeResourceList(b *strings.Builder, opts environment.CreateEnvironmentOptions) {
+	if opts.CPUs > 0 {
+		fmt.Fprintf(b, "        cpu: \"%d\"\n", opts.CPUs)
+	}
+	if opts.MemoryMB > 0 {
+		fmt.Fprintf(b, "        memory: \"%dMi\"\n", opts.MemoryMB)
+	}
+	if opts.StorageMB > 0 {
+		fmt.Fprintf(b, "        ephemeral-storage: \"%dMi\"\n", opts.StorageMB)
+	}
+	if opts.GPUCount > 0 {
+		// nvidia.com/gpu must be equal in requests and limits; Kubernetes
+		// rejects a GPU request without a matching limit. GPUType isn't
+		// expressed here - pin it via the provider's node_selector config
+		// (e.g. "cloud.google.com/gke-accelerator") to schedule onto nodes
+		// with the right GPU model.
+		fmt.Fprintf(b, "        nvidia.com/gpu: \"%d\"\n", opts.GPUCount)
+	}
+}
+
+// K8sEnvironment represents a running trial Pod.
+type K8sEnvironment struct {
+	provider *Provider
+	podName  string
+	cost     float64
+}
+
+// ID returns the Pod name.
+func (e *K8sEnvironment) ID() string {
+	return e.podName
+}
+
+// CopyTo copies a local file or directory into the Pod's container.
+func (e *K8sEnvironment) CopyTo(ctx context.Context, src, dst string) error {
+	slog.Debug("copying to pod", "pod", e.podName, "src", src, "dst", dst)
+
+	cmd := e.provider.kubectl(ctx, "cp", src, fmt.Sprintf("%s:%s", e.podName, dst))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("copying to pod: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// CopyFrom copies a file or directory from the Pod's container to a local path.
+func (e *K8sEnvironment) CopyFrom(ctx context.Context, src, dst string) error {
+	slog.Debug("copying from pod", "pod", e.podName, "src", src, "dst", dst)
+
+	cmd := e.provider.kubectl(ctx, "cp", fmt.Sprintf("%s:%s", e.podName, src), dst)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("copying from pod: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// buildShellCommand wraps cmd in the cd/export prefix needed to apply
+// opts.WorkDir and opts.Env before bash runs it, shell-quoting both so a
+// value containing shell metacharacters can't inject extra commands.
+func buildShellCommand(cmd string, opts environment.ExecOptions) string {
+	shellCmd := cmd
+	if opts.WorkDir != "" {
+		shellCmd = fmt.Sprintf("cd %s && %s", environment.ShellQuote(opts.WorkDir), cmd)
+	}
+	for k, v := range opts.Env {
+		shellCmd = fmt.Sprintf("export %s=%s && %s", k, environment.ShellQuote(v), shellCmd)
+	}
+	return shellCmd
+}
+
+// Exec executes a command in the Pod's container.
+func (e *K8sEnvironment) Exec(ctx context.Context, cmd string, stdout, stderr io.Writer, opts environment.ExecOptions) (int, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	shellCmd := buildShellCommand(cmd, opts)
+
+	args := append(e.provider.kubectlArgs(), "exec", e.podName, "--", "bash", "-c", shellCmd)
+	execCmd := exec.CommandContext(ctx, "kubectl", args...)
+	execCmd.Stdout = stdout
+	execCmd.Stderr = stderr
+
+	err := execCmd.Run()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode(), nil
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return -1, fmt.Errorf("command timed out")
+		}
+		return -1, fmt.Errorf("executing command: %w", err)
+	}
+	return 0, nil
+}
+
+// Stop is a no-op: Kubernetes Pods have no paused state short of deletion,
+// so there is nothing to stop without also destroying the environment.
+func (e *K8sEnvironment) Stop(ctx context.Context) error {
+	slog.Debug("stop is a no-op for the k8s provider", "pod", e.podName)
+	return nil
+}
+
+// Destroy deletes the Pod.
+func (e *K8sEnvironment) Destroy(ctx context.Context) error {
+	slog.Debug("deleting pod", "pod", e.podName)
+
+	cmd := e.provider.kubectl(ctx, "delete", "pod", e.podName, "--ignore-not-found", "--grace-period=0", "--force")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("deleting pod: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// Cost returns the cost incurred by this environment. The k8s provider runs
+// on infrastructure the operator already owns, so cost tracking is left to
+// the operator's own cluster billing.
+func (e *K8sEnvironment) Cost() float64 {
+	return e.cost
+}
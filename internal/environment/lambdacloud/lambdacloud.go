@@ -0,0 +1,395 @@
+// Package lambdacloud implements the "lambda_cloud" environment provider,
+// which runs trials on a rented Lambda Cloud GPU instance instead of a
+// local Docker host, for tasks whose verifier or agent require CUDA. Lambda
+// has no CLI analogous to aws/gcloud/kubectl, so this provider talks to its
+// REST API directly over HTTPS (shelling out to curl, the same
+// shell-a-process approach most other providers take with their own
+// platform's CLI) to launch and terminate instances, then drives Docker on
+// the running instance over SSH exactly like the ssh provider does for a
+// user's own remote host.
+package lambdacloud
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/spachava753/rollout/internal/environment"
+)
+
+const apiBase = "https://cloud.lambdalabs.com/api/v1"
+
+// ProviderConfig holds Lambda Cloud-specific configuration.
+type ProviderConfig struct {
+	// APIKey authenticates with the Lambda Cloud API, sent as the HTTP
+	// Basic auth username with no password (curl's -u "<key>:").
+	APIKey string
+	// InstanceType is the Lambda instance type name to rent (e.g.
+	// "gpu_1x_a100"). Required.
+	InstanceType string
+	// Region is the Lambda region name (e.g. "us-east-1"). Required.
+	Region string
+	// SSHKeyName is the name of an SSH key already registered with the
+	// Lambda Cloud account; its corresponding private key is SSHKeyPath.
+	SSHKeyName string
+	// SSHKeyPath is the private key used to reach the instance once it's
+	// running.
+	SSHKeyPath string
+}
+
+// ParseProviderConfig extracts Lambda Cloud-specific config from the generic config map.
+func ParseProviderConfig(config map[string]any) ProviderConfig {
+	var pc ProviderConfig
+	if config == nil {
+		return pc
+	}
+	if v, ok := config["api_key"].(string); ok {
+		pc.APIKey = v
+	}
+	if v, ok := config["instance_type"].(string); ok {
+		pc.InstanceType = v
+	}
+	if v, ok := config["region"].(string); ok {
+		pc.Region = v
+	}
+	if v, ok := config["ssh_key_name"].(string); ok {
+		pc.SSHKeyName = v
+	}
+	if v, ok := config["ssh_key_path"].(string); ok {
+		pc.SSHKeyPath = v
+	}
+	return pc
+}
+
+// Provider implements the Lambda Cloud environment provider.
+type Provider struct {
+	config ProviderConfig
+}
+
+// NewProvider creates a new Lambda Cloud provider.
+func NewProvider(config ProviderConfig) *Provider {
+	return &Provider{config: config}
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "lambda_cloud"
+}
+
+// api calls path with method and body (or nil), returning the parsed JSON
+// response's "data" field unmarshaled into out.
+func (p *Provider) api(ctx context.Context, method, path string, body any, out any) error {
+	args := []string{"-sS", "-X", method, "-u", p.config.APIKey + ":",
+		"-H", "Content-Type: application/json", apiBase + path}
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshaling request body: %w", err)
+		}
+		args = append(args, "-d", string(payload))
+	}
+
+	cmd := exec.CommandContext(ctx, "curl", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s", err, stderr.String())
+	}
+
+	var resp struct {
+		Data  json.RawMessage `json:"data"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return fmt.Errorf("parsing response: %w: %s", err, stdout.String())
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("lambda cloud API error: %s", resp.Error.Message)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(resp.Data, out)
+}
+
+// BuildImage is not supported by the Lambda Cloud provider: instances boot
+// a fixed Lambda-provided OS image with Docker and NVIDIA drivers
+// preinstalled, and the task image is run as a container on top rather than
+// built into the instance itself. Build the task image locally and push it
+// to a registry the instance can pull from; reference it with task.toml's
+// docker_image.
+func (p *Provider) BuildImage(ctx context.Context, opts environment.BuildImageOptions) (string, error) {
+	return "", fmt.Errorf("lambda_cloud provider does not support building images; push %s to a registry and use docker_image instead", opts.Tag)
+}
+
+// PullImage is a no-op here: the image is pulled on the instance itself
+// once it's running, as part of CreateEnvironment's docker run.
+func (p *Provider) PullImage(ctx context.Context, imageRef string) error {
+	slog.Debug("skipping explicit image pull, lambda_cloud will pull on instance creation", "image", imageRef)
+	return nil
+}
+
+// CreateEnvironment launches a Lambda Cloud instance, waits for it to come
+// up, and starts the trial image as a Docker container on it over SSH.
+func (p *Provider) CreateEnvironment(ctx context.Context, opts environment.CreateEnvironmentOptions) (environment.Environment, error) {
+	slog.Debug("launching lambda cloud instance", "instance_type", p.config.InstanceType, "region", p.config.Region)
+
+	var launchResp struct {
+		InstanceIDs []string `json:"instance_ids"`
+	}
+	launchReq := map[string]any{
+		"region_name":        p.config.Region,
+		"instance_type_name": p.config.InstanceType,
+		"ssh_key_names":      []string{p.config.SSHKeyName},
+		"quantity":           1,
+	}
+	if err := p.api(ctx, "POST", "/instance-operations/launch", launchReq, &launchResp); err != nil {
+		return nil, fmt.Errorf("launching instance: %w", err)
+	}
+	if len(launchResp.InstanceIDs) == 0 {
+		return nil, fmt.Errorf("launch response contained no instance ids")
+	}
+	instanceID := launchResp.InstanceIDs[0]
+
+	ip, err := p.waitForActive(ctx, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("waiting for instance %s to become active: %w", instanceID, err)
+	}
+
+	env := &Environment{
+		provider:     p,
+		instanceID:   instanceID,
+		ip:           ip,
+		instanceType: p.config.InstanceType,
+		startTime:    time.Now(),
+	}
+
+	containerID := opts.Name
+	if containerID == "" {
+		containerID = fmt.Sprintf("rollout-%d", time.Now().UnixNano())
+	}
+
+	args := []string{"run", "-d", "--name", containerID, "--gpus", "all"}
+	if opts.MemoryMB > 0 {
+		args = append(args, "--memory", fmt.Sprintf("%dm", opts.MemoryMB))
+	}
+	for k, v := range opts.Env {
+		args = append(args, "-e", fmt.Sprintf("%s=%s", k, v))
+	}
+	args = append(args, opts.ImageRef, "sleep", "infinity")
+
+	slog.Debug("starting container on lambda cloud instance", "instance", instanceID, "image", opts.ImageRef)
+	if err := env.remoteDocker(ctx, args...).Run(); err != nil {
+		return nil, fmt.Errorf("starting container on instance: %w", err)
+	}
+	env.containerID = containerID
+
+	return env, nil
+}
+
+// waitForActive polls the instance until it reports status "active" with an
+// assigned IP and SSH is reachable, for up to 10 minutes (Lambda instances
+// can take a while to boot).
+func (p *Provider) waitForActive(ctx context.Context, instanceID string) (string, error) {
+	deadline := time.Now().Add(10 * time.Minute)
+	for time.Now().Before(deadline) {
+		var status struct {
+			Status string `json:"status"`
+			IP     string `json:"ip"`
+		}
+		if err := p.api(ctx, "GET", "/instances/"+instanceID, nil, &status); err == nil &&
+			status.Status == "active" && status.IP != "" {
+			if sshReachable(ctx, status.IP, p.config.SSHKeyPath) {
+				return status.IP, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(10 * time.Second):
+		}
+	}
+	return "", fmt.Errorf("timed out waiting for instance to become active")
+}
+
+func sshReachable(ctx context.Context, ip, keyPath string) bool {
+	args := []string{"-i", keyPath, "-o", "StrictHostKeyChecking=no", "-o", "UserKnownHostsFile=/dev/null",
+		"-o", "ConnectTimeout=5", "ubuntu@" + ip, "true"}
+	return exec.CommandContext(ctx, "ssh", args...).Run() == nil
+}
+
+// Environment represents a running Docker container on a rented Lambda
+// Cloud GPU instance.
+type Environment struct {
+	provider     *Provider
+	instanceID   string
+	ip           string
+	containerID  string
+	instanceType string
+	startTime    time.Time
+}
+
+// ID returns the container ID.
+func (e *Environment) ID() string {
+	return e.containerID
+}
+
+func (e *Environment) sshFlags() []string {
+	return []string{
+		"-i", e.provider.config.SSHKeyPath,
+		"-o", "StrictHostKeyChecking=no",
+		"-o", "UserKnownHostsFile=/dev/null",
+	}
+}
+
+func (e *Environment) target() string {
+	return "ubuntu@" + e.ip
+}
+
+// remoteDocker runs a docker subcommand on the instance over ssh, analogous
+// to the ssh provider's remoteDocker.
+func (e *Environment) remoteDocker(ctx context.Context, args ...string) *exec.Cmd {
+	remoteCmd := "docker " + shellJoin(args)
+	sshArgs := append(append([]string{}, e.sshFlags()...), e.target(), remoteCmd)
+	return exec.CommandContext(ctx, "ssh", sshArgs...)
+}
+
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = environment.ShellQuote(a)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// CopyTo copies a local file or directory into the container, staging it on
+// the instance via scp and then into the container with docker cp.
+func (e *Environment) CopyTo(ctx context.Context, src, dst string) error {
+	tmp := fmt.Sprintf("/tmp/rollout-cp-%d", time.Now().UnixNano())
+
+	scpArgs := append(append([]string{}, e.sshFlags()...), "-r", src, e.target()+":"+tmp)
+	scpCmd := exec.CommandContext(ctx, "scp", scpArgs...)
+	var stderr bytes.Buffer
+	scpCmd.Stderr = &stderr
+	if err := scpCmd.Run(); err != nil {
+		return fmt.Errorf("staging file to instance: %w: %s", err, stderr.String())
+	}
+
+	stderr.Reset()
+	cpCmd := e.remoteDocker(ctx, "cp", tmp, e.containerID+":"+dst)
+	cpCmd.Stderr = &stderr
+	if err := cpCmd.Run(); err != nil {
+		return fmt.Errorf("copying staged file into container: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// CopyFrom copies a file or directory from the container to a local path,
+// staging it through the instance's filesystem with docker cp and then scp.
+func (e *Environment) CopyFrom(ctx context.Context, src, dst string) error {
+	tmp := fmt.Sprintf("/tmp/rollout-cp-%d", time.Now().UnixNano())
+
+	var stderr bytes.Buffer
+	cpCmd := e.remoteDocker(ctx, "cp", e.containerID+":"+src, tmp)
+	cpCmd.Stderr = &stderr
+	if err := cpCmd.Run(); err != nil {
+		return fmt.Errorf("copying file out of container: %w: %s", err, stderr.String())
+	}
+
+	stderr.Reset()
+	scpArgs := append(append([]string{}, e.sshFlags()...), "-r", e.target()+":"+tmp, dst)
+	scpCmd := exec.CommandContext(ctx, "scp", scpArgs...)
+	scpCmd.Stderr = &stderr
+	if err := scpCmd.Run(); err != nil {
+		return fmt.Errorf("fetching staged file from instance: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// Exec executes a command in the container via the instance's Docker daemon.
+func (e *Environment) Exec(ctx context.Context, cmd string, stdout, stderr io.Writer, opts environment.ExecOptions) (int, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	args := []string{"exec"}
+	for k, v := range opts.Env {
+		args = append(args, "-e", fmt.Sprintf("%s=%s", k, v))
+	}
+	if opts.WorkDir != "" {
+		args = append(args, "-w", opts.WorkDir)
+	}
+	args = append(args, e.containerID, "bash", "-c", cmd)
+
+	execCmd := e.remoteDocker(ctx, args...)
+	execCmd.Stdout = stdout
+	execCmd.Stderr = stderr
+
+	err := execCmd.Run()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode(), nil
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return -1, fmt.Errorf("command timed out")
+		}
+		return -1, fmt.Errorf("executing command: %w", err)
+	}
+	return 0, nil
+}
+
+// Stop stops the container but does not terminate the instance; the
+// instance rental keeps billing until Destroy terminates it.
+func (e *Environment) Stop(ctx context.Context) error {
+	if err := e.remoteDocker(ctx, "stop", e.containerID).Run(); err != nil {
+		return fmt.Errorf("stopping container: %w", err)
+	}
+	return nil
+}
+
+// Destroy terminates the rented Lambda Cloud instance, taking the container
+// with it.
+func (e *Environment) Destroy(ctx context.Context) error {
+	slog.Debug("terminating lambda cloud instance", "instance", e.instanceID)
+
+	var resp struct {
+		TerminatedInstances []string `json:"terminated_instances"`
+	}
+	req := map[string]any{"instance_ids": []string{e.instanceID}}
+	if err := e.provider.api(ctx, "POST", "/instance-operations/terminate", req, &resp); err != nil {
+		return fmt.Errorf("terminating instance: %w", err)
+	}
+	return nil
+}
+
+// hourlyRates gives approximate on-demand $/hour pricing for Lambda Cloud's
+// GPU instance types, used to estimate Cost() since the API does not expose
+// a per-instance running total.
+var hourlyRates = map[string]float64{
+	"gpu_1x_a10":   0.75,
+	"gpu_1x_a100":  1.29,
+	"gpu_8x_a100":  10.32,
+	"gpu_1x_h100":  2.49,
+	"gpu_1x_gh200": 1.49,
+}
+
+// Cost estimates spend so far as elapsed hours times instanceType's
+// approximate on-demand rate in hourlyRates, or 0 if the type is unknown.
+func (e *Environment) Cost() float64 {
+	rate, ok := hourlyRates[e.instanceType]
+	if !ok {
+		return 0
+	}
+	return time.Since(e.startTime).Hours() * rate
+}
@@ -1,6 +1,8 @@
 package modal
 
 import (
+	"archive/tar"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -9,6 +11,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -22,8 +26,60 @@ type ProviderConfig struct {
 	AppName string
 	// Regions specifies the Modal regions (e.g., "us-east", "us-west").
 	Regions []string
+	// Cloud selects the cloud provider Modal runs the sandbox on (e.g.
+	// "aws", "gcp", "oci"). Empty lets Modal choose.
+	Cloud string
+	// CPURequest is the fractional physical CPU cores reserved for a
+	// sandbox. Falls back to CreateEnvironmentOptions.CPUs, then 1, when
+	// zero.
+	CPURequest float64
+	// CPULimit hard-caps CPU usage, in fractional physical cores. Zero
+	// means no limit, letting a sandbox burst past its request when spare
+	// capacity is available.
+	CPULimit float64
+	// MemoryRequestMB is the memory reserved for a sandbox, in MB. Falls
+	// back to CreateEnvironmentOptions.MemoryMB, then 2048, when zero.
+	MemoryRequestMB int
+	// MemoryLimitMB hard-caps memory usage, in MB. Zero means no limit.
+	MemoryLimitMB int
+	// GPUType selects a GPU model (e.g. "A10G", "H100") to request for every
+	// sandbox. A non-empty CreateEnvironmentOptions.GPUType replaces it
+	// (together with CreateEnvironmentOptions.GPUCount); no GPU is requested
+	// when both are empty.
+	GPUType string
+	// GPUCount is how many of GPUType to request; zero requests 1.
+	// CreateEnvironmentOptions.GPUCount is used only together with a
+	// non-empty CreateEnvironmentOptions.GPUType, never on its own.
+	GPUCount int
 	// Verbose enables detailed sandbox logging.
 	Verbose bool
+	// Volumes mounts persistent Modal Volumes into the sandbox, so package
+	// caches, model weights, and datasets survive across trials instead of
+	// being re-downloaded into every fresh sandbox.
+	Volumes []VolumeMount
+	// ExposePorts declares container ports to tunnel into the sandbox with
+	// TLS encryption, so ModalEnvironment.ExposePort can return a reachable
+	// URL for them later. Modal tunnels must be declared at sandbox creation
+	// time, so a port not listed here can't be exposed after the fact.
+	ExposePorts []int
+	// NetworkAllowlist lists CIDRs the sandbox may reach when
+	// CreateEnvironmentOptions.Network (or a task's environment.network) is
+	// "restricted". Ignored for "full" and "none", where network access is
+	// either left open or blocked outright via Modal's BlockNetwork.
+	NetworkAllowlist []string
+}
+
+// VolumeMount mounts a single Modal Volume into a sandbox.
+type VolumeMount struct {
+	// Path is the absolute path inside the sandbox to mount the volume at.
+	Path string
+	// Name is the Modal Volume's name. Created automatically if it doesn't
+	// already exist.
+	Name string
+	// ReadOnly mounts the volume read-only, letting multiple concurrent
+	// sandboxes share it safely (e.g. a shared model weights cache) without
+	// risking concurrent writers corrupting it.
+	ReadOnly bool
 }
 
 // ParseProviderConfig extracts Modal-specific config from the generic config map.
@@ -45,12 +101,188 @@ func ParseProviderConfig(config map[string]any) ProviderConfig {
 			}
 		}
 	}
+	if v, ok := config["cloud"].(string); ok {
+		pc.Cloud = v
+	}
+	pc.CPURequest = floatField(config, "cpu_request")
+	pc.CPULimit = floatField(config, "cpu_limit")
+	pc.MemoryRequestMB = intField(config, "memory_request_mb")
+	pc.MemoryLimitMB = intField(config, "memory_limit_mb")
+	if v, ok := config["gpu_type"].(string); ok {
+		pc.GPUType = v
+	}
+	pc.GPUCount = intField(config, "gpu_count")
 	if v, ok := config["verbose"].(bool); ok {
 		pc.Verbose = v
 	}
+	if v, ok := config["volumes"].([]any); ok {
+		for _, entry := range v {
+			m, ok := entry.(map[string]any)
+			if !ok {
+				continue
+			}
+			path, _ := m["path"].(string)
+			name, _ := m["name"].(string)
+			if path == "" || name == "" {
+				continue
+			}
+			readOnly, _ := m["read_only"].(bool)
+			pc.Volumes = append(pc.Volumes, VolumeMount{Path: path, Name: name, ReadOnly: readOnly})
+		}
+	}
+	if v, ok := config["expose_ports"].([]any); ok {
+		for _, entry := range v {
+			switch port := entry.(type) {
+			case int:
+				pc.ExposePorts = append(pc.ExposePorts, port)
+			case float64:
+				pc.ExposePorts = append(pc.ExposePorts, int(port))
+			}
+		}
+	}
+	if v, ok := config["network_allowlist"].([]any); ok {
+		for _, entry := range v {
+			if cidr, ok := entry.(string); ok {
+				pc.NetworkAllowlist = append(pc.NetworkAllowlist, cidr)
+			}
+		}
+	}
 	return pc
 }
 
+// floatField reads a numeric field from a generic config map, accepting
+// both int and float64 (the two shapes a JSON/YAML decoder produces).
+func floatField(config map[string]any, key string) float64 {
+	switch v := config[key].(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	default:
+		return 0
+	}
+}
+
+// intField reads an integer field from a generic config map, accepting both
+// int and float64.
+func intField(config map[string]any, key string) int {
+	switch v := config[key].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	default:
+		return 0
+	}
+}
+
+// sandboxResources is the effective resource/placement configuration for a
+// single sandbox, after layering a task's per-trial provider_hints (opts.Config)
+// over the provider's own ProviderConfig defaults.
+type sandboxResources struct {
+	cpuRequest       float64
+	cpuLimit         float64
+	memRequest       int
+	memLimit         int
+	gpuType          string
+	gpuCount         int
+	cloud            string
+	regions          []string
+	volumes          []VolumeMount
+	exposePorts      []int
+	network          string
+	networkAllowlist []string
+}
+
+// gpu formats gpuType/gpuCount into a modal.SandboxCreateParams.GPU-style
+// spec ("A10G" or "A10G:2"), empty if no GPU was requested.
+func (r sandboxResources) gpu() string {
+	if r.gpuType == "" {
+		return ""
+	}
+	if r.gpuCount > 1 {
+		return fmt.Sprintf("%s:%d", r.gpuType, r.gpuCount)
+	}
+	return r.gpuType
+}
+
+// resolveSandboxResources merges p.config's defaults with opts' per-trial
+// overrides, letting a task pin a specific region or cloud (e.g. for GPU
+// availability) without changing every other trial's placement.
+func (p *Provider) resolveSandboxResources(opts environment.CreateEnvironmentOptions) sandboxResources {
+	r := sandboxResources{
+		cpuRequest:       p.config.CPURequest,
+		cpuLimit:         p.config.CPULimit,
+		memRequest:       p.config.MemoryRequestMB,
+		memLimit:         p.config.MemoryLimitMB,
+		gpuType:          p.config.GPUType,
+		gpuCount:         p.config.GPUCount,
+		cloud:            p.config.Cloud,
+		regions:          p.config.Regions,
+		volumes:          p.config.Volumes,
+		exposePorts:      p.config.ExposePorts,
+		network:          opts.Network,
+		networkAllowlist: p.config.NetworkAllowlist,
+	}
+
+	if opts.CPUs > 0 && r.cpuRequest == 0 {
+		r.cpuRequest = float64(opts.CPUs)
+	}
+	if r.cpuRequest <= 0 {
+		r.cpuRequest = 1
+	}
+	if opts.MemoryMB > 0 && r.memRequest == 0 {
+		r.memRequest = opts.MemoryMB
+	}
+	if r.memRequest <= 0 {
+		r.memRequest = 2048
+	}
+
+	if opts.GPUType != "" {
+		r.gpuType = opts.GPUType
+		r.gpuCount = opts.GPUCount
+	} else if opts.GPUCount > 0 {
+		slog.Warn("modal provider requires a gpu type to request a gpu, ignoring gpu_count with no gpu_type", "gpu_count", opts.GPUCount)
+	}
+
+	if opts.Config != nil {
+		hints := ParseProviderConfig(opts.Config)
+		if hints.CPURequest > 0 {
+			r.cpuRequest = hints.CPURequest
+		}
+		if hints.CPULimit > 0 {
+			r.cpuLimit = hints.CPULimit
+		}
+		if hints.MemoryRequestMB > 0 {
+			r.memRequest = hints.MemoryRequestMB
+		}
+		if hints.MemoryLimitMB > 0 {
+			r.memLimit = hints.MemoryLimitMB
+		}
+		if hints.GPUType != "" {
+			r.gpuType = hints.GPUType
+			r.gpuCount = hints.GPUCount
+		}
+		if hints.Cloud != "" {
+			r.cloud = hints.Cloud
+		}
+		if len(hints.Regions) > 0 {
+			r.regions = hints.Regions
+		}
+		// Task-level volumes add to the job's defaults rather than replacing
+		// them, since a task typically wants its own dataset/weights cache
+		// mounted alongside shared caches the whole job relies on, not
+		// instead of them.
+		r.volumes = append(r.volumes, hints.Volumes...)
+		// Same rationale as volumes: a task's own exposed ports add to the
+		// job's defaults rather than replacing them.
+		r.exposePorts = append(r.exposePorts, hints.ExposePorts...)
+		r.networkAllowlist = append(r.networkAllowlist, hints.NetworkAllowlist...)
+	}
+
+	return r
+}
+
 // Provider implements the Modal environment provider using Modal Sandboxes.
 type Provider struct {
 	client *modal.Client
@@ -143,10 +375,18 @@ func (p *Provider) Name() string {
 // BuildImage builds a container image from the given context directory.
 // For Modal, we return the context directory path as the "image reference".
 // The actual image building happens lazily when the sandbox is created.
-// LIMITATION: This provider does not support COPY/ADD instructions in Dockerfiles
-// that reference local files, as the modal-go SDK does not support build contexts.
-// Images must be self-contained or use public URLs.
+// LIMITATION: COPY/ADD instructions that reference local files are not baked
+// into the image itself, since the modal-go SDK's image build API has no
+// concept of a build context - instead, CreateEnvironment stages those files
+// into the sandbox's filesystem once it's running, before the trial's first
+// command executes. This means a COPY'd file is present by the time anything
+// the trial runs can observe it, but it won't show up in `docker history`-
+// style image inspection and earlier RUN commands can't see it. ADD's
+// remote-URL and archive-extraction forms are still unsupported.
 func (p *Provider) BuildImage(ctx context.Context, opts environment.BuildImageOptions) (string, error) {
+	if len(opts.BuildArgs) > 0 || opts.Target != "" {
+		return "", fmt.Errorf("modal provider does not support build_args or target: it always substitutes a Dockerfile's own ARG defaults and builds its final stage, so there is nothing for a caller-supplied build arg or --target to override")
+	}
 	dockerfilePath := filepath.Join(opts.ContextDir, "Dockerfile")
 	if _, err := os.Stat(dockerfilePath); err != nil {
 		return "", fmt.Errorf("Dockerfile not found at %s: %w", dockerfilePath, err)
@@ -186,28 +426,46 @@ func (p *Provider) CreateEnvironment(ctx context.Context, opts environment.Creat
 
 	// Build the image
 	var image *modal.Image
-	if isDockerContextPath(opts.ImageRef) {
+	var contextDir string
+	var copies []dockerCopy
+	switch {
+	case strings.HasPrefix(opts.ImageRef, modalSnapshotRefPrefix):
+		// ImageRef is a previously-snapshotted Modal image (see Snapshot).
+		imageID := strings.TrimPrefix(opts.ImageRef, modalSnapshotRefPrefix)
+		slog.Debug("starting modal sandbox from filesystem snapshot", "image_id", imageID)
+		image, err = p.client.Images.FromID(ctx, imageID)
+		if err != nil {
+			return nil, fmt.Errorf("resolving snapshot image %s: %w", imageID, err)
+		}
+	case isDockerContextPath(opts.ImageRef):
 		// ImageRef is a path to a directory with a Dockerfile
-		slog.Debug("building modal image from dockerfile", "context", opts.ImageRef)
-		image, err = p.buildImageFromDockerfile(ctx, app, opts.ImageRef)
+		contextDir = opts.ImageRef
+		slog.Debug("building modal image from dockerfile", "context", contextDir)
+		image, copies, err = p.buildImageFromDockerfile(ctx, app, contextDir)
 		if err != nil {
 			return nil, fmt.Errorf("building image from dockerfile: %w", err)
 		}
-	} else {
+	default:
 		// ImageRef is a registry image reference
 		slog.Debug("using registry image for modal", "image", opts.ImageRef)
 		image = p.client.Images.FromRegistry(opts.ImageRef, nil)
 	}
 
-	// Parse resource specs
-	cpuCount := opts.CPUs
-	if cpuCount <= 0 {
-		cpuCount = 1
+	// Resolve effective CPU/memory request+limit and cloud/region
+	// placement, layering this task's provider_hints over the provider's
+	// own defaults.
+	resources := p.resolveSandboxResources(opts)
+
+	if opts.Constraints.Arch != "" && opts.Constraints.Arch != "amd64" {
+		return nil, fmt.Errorf("modal provider only runs sandboxes on amd64, cannot satisfy constraints.arch=%q", opts.Constraints.Arch)
+	}
+	if opts.Constraints.Region != "" {
+		resources.regions = []string{opts.Constraints.Region}
 	}
-	// Memory is already passed as MB
-	memoryMiB := opts.MemoryMB
-	if memoryMiB <= 0 {
-		memoryMiB = 2048
+	if opts.Constraints.MaxPriceHourly > 0 {
+		if estimate := estimateHourlyUSD(resources); estimate > opts.Constraints.MaxPriceHourly {
+			return nil, fmt.Errorf("modal provider estimates $%.2f/hr for the requested resources, exceeding constraints.max_price=$%.2f/hr", estimate, opts.Constraints.MaxPriceHourly)
+		}
 	}
 
 	// Build environment variables map including opts.Env
@@ -216,21 +474,60 @@ func (p *Provider) CreateEnvironment(ctx context.Context, opts environment.Creat
 		envVars[k] = v
 	}
 
+	// Bound the sandbox's own lifetime to roughly how long the trial
+	// running inside it should take, so an abandoned or hung sandbox
+	// self-terminates quickly instead of idling (and billing) for up to
+	// Modal's 24-hour maximum. Fall back to that maximum when the caller
+	// didn't compute a trial-derived timeout.
+	sandboxTimeout := opts.Timeout
+	if sandboxTimeout <= 0 || sandboxTimeout > 24*time.Hour {
+		sandboxTimeout = 24 * time.Hour
+	}
+
+	volumes, err := p.resolveVolumes(ctx, resources.volumes)
+	if err != nil {
+		return nil, fmt.Errorf("resolving modal volumes: %w", err)
+	}
+
 	// Create sandbox parameters
 	createParams := &modal.SandboxCreateParams{
-		CPU:       float64(cpuCount),
-		MemoryMiB: memoryMiB,
-		Env:       envVars,
-		Timeout:   24 * time.Hour, // Maximum allowed
-		Verbose:   p.config.Verbose,
-		Regions:   p.config.Regions,
+		CPU:            resources.cpuRequest,
+		CPULimit:       resources.cpuLimit,
+		MemoryMiB:      resources.memRequest,
+		MemoryLimitMiB: resources.memLimit,
+		GPU:            resources.gpu(),
+		Env:            envVars,
+		Timeout:        sandboxTimeout,
+		Verbose:        p.config.Verbose,
+		Cloud:          resources.cloud,
+		Regions:        resources.regions,
+		Volumes:        volumes,
+		EncryptedPorts: resources.exposePorts,
+	}
+
+	switch resources.network {
+	case "none":
+		createParams.BlockNetwork = true
+	case "restricted":
+		if len(resources.networkAllowlist) == 0 {
+			slog.Warn("modal provider has no network_allowlist configured, network: restricted has no effect - set provider_config.network_allowlist or use network: none for a hard guarantee")
+		}
+		createParams.CIDRAllowlist = resources.networkAllowlist
 	}
 
 	slog.Debug("creating modal sandbox",
 		"app", appName,
-		"cpus", cpuCount,
-		"memory_mib", memoryMiB,
-		"regions", p.config.Regions)
+		"cpu_request", resources.cpuRequest,
+		"cpu_limit", resources.cpuLimit,
+		"memory_request_mib", resources.memRequest,
+		"memory_limit_mib", resources.memLimit,
+		"gpu", resources.gpu(),
+		"cloud", resources.cloud,
+		"regions", resources.regions,
+		"volumes", resources.volumes,
+		"expose_ports", resources.exposePorts,
+		"network", resources.network,
+		"timeout", sandboxTimeout)
 
 	// Create the sandbox
 	sandbox, err := p.client.Sandboxes.Create(ctx, app, image, createParams)
@@ -240,34 +537,77 @@ func (p *Provider) CreateEnvironment(ctx context.Context, opts environment.Creat
 
 	slog.Debug("modal sandbox created", "sandbox_id", sandbox.SandboxID)
 
-	return &ModalEnvironment{
+	env := &ModalEnvironment{
 		client:    p.client,
 		sandbox:   sandbox,
 		app:       app,
 		appName:   appName,
 		startTime: time.Now(),
-		cpuCount:  cpuCount,
-		memoryMiB: memoryMiB,
-	}, nil
+		cpuCount:  resources.cpuRequest,
+		memoryMiB: resources.memRequest,
+		gpu:       resources.gpu(),
+	}
+
+	// Stage the Dockerfile's COPY/local-ADD sources into the now-running
+	// sandbox, since modal-go's image build has no build-context equivalent
+	// to bake them in (see BuildImage's doc comment).
+	for _, c := range copies {
+		src := filepath.Join(contextDir, c.src)
+		slog.Debug("staging dockerfile copy into modal sandbox", "src", src, "dst", c.dst)
+		if err := env.CopyTo(ctx, src, c.dst); err != nil {
+			env.Destroy(ctx)
+			return nil, fmt.Errorf("staging %s into sandbox: %w", c.src, err)
+		}
+	}
+
+	return env, nil
 }
 
-// buildImageFromDockerfile creates a Modal image from a Dockerfile.
-func (p *Provider) buildImageFromDockerfile(ctx context.Context, app *modal.App, contextDir string) (*modal.Image, error) {
+// resolveVolumes looks up (creating if missing) the named Modal Volume for
+// each configured mount, returning a mount-path-to-Volume map ready for
+// modal.SandboxCreateParams.Volumes.
+func (p *Provider) resolveVolumes(ctx context.Context, mounts []VolumeMount) (map[string]*modal.Volume, error) {
+	if len(mounts) == 0 {
+		return nil, nil
+	}
+
+	volumes := make(map[string]*modal.Volume, len(mounts))
+	for _, m := range mounts {
+		vol, err := p.client.Volumes.FromName(ctx, m.Name, &modal.VolumeFromNameParams{
+			CreateIfMissing: true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("looking up volume %q: %w", m.Name, err)
+		}
+		if m.ReadOnly {
+			vol = vol.ReadOnly()
+		}
+		volumes[m.Path] = vol
+	}
+	return volumes, nil
+}
+
+// buildImageFromDockerfile creates a Modal image from a Dockerfile. The
+// returned copies are the Dockerfile's COPY/local-ADD instructions, for the
+// caller to stage into the sandbox's filesystem once it's running (see the
+// BuildImage doc comment for why they can't be baked into the image itself).
+func (p *Provider) buildImageFromDockerfile(ctx context.Context, app *modal.App, contextDir string) (*modal.Image, []dockerCopy, error) {
 	dockerfilePath := filepath.Join(contextDir, "Dockerfile")
 	content, err := os.ReadFile(dockerfilePath)
 	if err != nil {
-		return nil, fmt.Errorf("reading Dockerfile: %w", err)
+		return nil, nil, fmt.Errorf("reading Dockerfile: %w", err)
 	}
 
 	// Parse the Dockerfile to extract the base image and commands
-	baseImage, commands, err := parseDockerfile(string(content))
+	baseImage, commands, copies, err := parseDockerfile(string(content))
 	if err != nil {
-		return nil, fmt.Errorf("parsing Dockerfile: %w", err)
+		return nil, nil, fmt.Errorf("parsing Dockerfile: %w", err)
 	}
 
 	slog.Debug("parsed dockerfile",
 		"base_image", baseImage,
-		"commands", len(commands))
+		"commands", len(commands),
+		"copies", len(copies))
 
 	// Start with the base image
 	image := p.client.Images.FromRegistry(baseImage, nil)
@@ -281,10 +621,10 @@ func (p *Provider) buildImageFromDockerfile(ctx context.Context, app *modal.App,
 	slog.Debug("building modal image")
 	builtImage, err := image.Build(ctx, app)
 	if err != nil {
-		return nil, fmt.Errorf("building image: %w", err)
+		return nil, nil, fmt.Errorf("building image: %w", err)
 	}
 
-	return builtImage, nil
+	return builtImage, copies, nil
 }
 
 // isDockerContextPath checks if the imageRef looks like a local directory path.
@@ -297,71 +637,196 @@ func isDockerContextPath(imageRef string) bool {
 	return err == nil && info.IsDir()
 }
 
-// parseDockerfile extracts base image and commands from a Dockerfile.
-func parseDockerfile(content string) (baseImage string, commands []string, err error) {
-	lines := strings.Split(content, "\n")
-	var currentCmd strings.Builder
+// dockerStage holds one FROM...FROM block's resolved base image, optional
+// stage name (the "AS <name>" suffix), and the Modal-supported instructions
+// it contains.
+type dockerStage struct {
+	name     string
+	image    string
+	commands []string
+	copies   []dockerCopy
+}
+
+// dockerCopy is a single COPY or local-file ADD instruction's source (relative
+// to the build context directory) and destination path inside the sandbox.
+type dockerCopy struct {
+	src string
+	dst string
+}
+
+// argRefPattern matches a Dockerfile ARG/ENV-style variable reference in
+// either $NAME or ${NAME} form.
+var argRefPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// substituteArgs replaces $NAME and ${NAME} references with values from
+// args, leaving unknown references untouched (matching docker build, which
+// leaves an unset, undeclared ARG reference as literal text).
+func substituteArgs(s string, args map[string]string) string {
+	return argRefPattern.ReplaceAllStringFunc(s, func(match string) string {
+		name := strings.Trim(match, "${}")
+		if v, ok := args[name]; ok {
+			return v
+		}
+		return match
+	})
+}
+
+// parseArgDecl splits an "ARG NAME[=default]" instruction's argument into
+// its name and default value (empty if the ARG has no default).
+func parseArgDecl(decl string) (name, value string) {
+	name, value, _ = strings.Cut(decl, "=")
+	name = strings.TrimSpace(name)
+	value = strings.Trim(strings.TrimSpace(value), `"'`)
+	return name, value
+}
+
+// joinContinuations collapses backslash line continuations across the
+// whole file (not just inside instructions Modal recognizes), so a
+// continued FROM or ARG line joins the same way a continued RUN does.
+// Comment and blank lines pass through unchanged and untouched by
+// continuation joining, matching how docker build itself treats them.
+func joinContinuations(content string) []string {
+	var result []string
+	var cur strings.Builder
 	inContinuation := false
 
-	for _, line := range lines {
+	for _, line := range strings.Split(content, "\n") {
 		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			result = append(result, trimmed)
+			continue
+		}
+		if inContinuation {
+			cur.WriteString(" ")
+		}
+		if strings.HasSuffix(trimmed, "\\") {
+			cur.WriteString(strings.TrimSuffix(trimmed, "\\"))
+			inContinuation = true
+			continue
+		}
+		cur.WriteString(trimmed)
+		result = append(result, cur.String())
+		cur.Reset()
+		inContinuation = false
+	}
+	return result
+}
 
-		// Skip empty lines and comments
+// parseDockerfile extracts a base image and a flat list of Modal-supported
+// instructions from a Dockerfile. It doesn't vendor a real Dockerfile
+// parser (e.g. buildkit's): there's no network access in this sandbox to
+// add a new dependency, and Modal's DockerfileCommands API just wants a
+// flat instruction list anyway, not a build graph. It still tracks global
+// ARG defaults with substitution into FROM and later instructions, splits
+// the file into per-stage instruction groups and keeps only the final
+// stage - matching docker build's own default when no --target is given,
+// which is also the only mode BuildImage allows (see its opts.Target
+// check) - and rejects heredocs explicitly instead of silently
+// mis-parsing them. COPY and local-file ADD instructions are collected
+// rather than applied here - the modal-go SDK's image build has no concept
+// of a build context, so the caller stages them into the sandbox's
+// filesystem after it's created instead (see CreateEnvironment).
+func parseDockerfile(content string) (baseImage string, commands []string, copies []dockerCopy, err error) {
+	globalArgs := map[string]string{}
+	var stages []dockerStage
+
+	for _, trimmed := range joinContinuations(content) {
 		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
 			continue
 		}
 
-		// Handle line continuations
-		if inContinuation {
-			currentCmd.WriteString(" ")
-			if strings.HasSuffix(trimmed, "\\") {
-				currentCmd.WriteString(strings.TrimSuffix(trimmed, "\\"))
-			} else {
-				currentCmd.WriteString(trimmed)
-				commands = append(commands, currentCmd.String())
-				currentCmd.Reset()
-				inContinuation = false
+		if strings.Contains(trimmed, "<<") {
+			return "", nil, nil, fmt.Errorf("heredoc syntax is not supported in Modal environment Dockerfiles: %q", trimmed)
+		}
+
+		upper := strings.ToUpper(trimmed)
+		instruction, rest, _ := strings.Cut(trimmed, " ")
+		rest = strings.TrimSpace(rest)
+
+		if strings.EqualFold(instruction, "ARG") {
+			if len(stages) == 0 {
+				name, value := parseArgDecl(rest)
+				globalArgs[name] = value
 			}
+			// Stage-local ARGs (after a FROM) aren't tracked: nothing earlier
+			// in the file could reference them anyway.
 			continue
 		}
 
-		// Parse FROM instruction
-		if strings.HasPrefix(strings.ToUpper(trimmed), "FROM ") {
-			parts := strings.Fields(trimmed)
-			if len(parts) >= 2 {
-				baseImage = parts[1]
+		if strings.EqualFold(instruction, "FROM") {
+			fields := strings.Fields(rest)
+			if len(fields) == 0 {
+				return "", nil, nil, fmt.Errorf("malformed FROM instruction: %q", trimmed)
 			}
+			stage := dockerStage{image: substituteArgs(fields[0], globalArgs)}
+			if len(fields) >= 3 && strings.EqualFold(fields[1], "AS") {
+				stage.name = fields[2]
+			}
+			stages = append(stages, stage)
+			continue
+		}
+
+		if len(stages) == 0 {
+			// Nothing before the first FROM other than ARG/comments is
+			// meaningful to Modal's flat instruction list; ignore it and let
+			// the "no FROM instruction found" check below report the real
+			// problem if there's never a FROM at all.
 			continue
 		}
 
-		// Check for unsupported instructions
-		upper := strings.ToUpper(trimmed)
 		if strings.HasPrefix(upper, "COPY ") || strings.HasPrefix(upper, "ADD ") {
-			return "", nil, fmt.Errorf("COPY and ADD instructions are not supported in Modal environment Dockerfiles")
-		}
-
-		// Parse Dockerfile instructions that Modal supports
-		if strings.HasPrefix(upper, "RUN ") ||
-			strings.HasPrefix(upper, "WORKDIR ") ||
-			strings.HasPrefix(upper, "ENV ") ||
-			strings.HasPrefix(upper, "USER ") ||
-			strings.HasPrefix(upper, "EXPOSE ") ||
-			strings.HasPrefix(upper, "LABEL ") {
-
-			if strings.HasSuffix(trimmed, "\\") {
-				currentCmd.WriteString(strings.TrimSuffix(trimmed, "\\"))
-				inContinuation = true
-			} else {
-				commands = append(commands, trimmed)
+			copy, err := parseCopyInstruction(instruction, rest)
+			if err != nil {
+				return "", nil, nil, fmt.Errorf("%w: %q", err, trimmed)
 			}
+			stage := &stages[len(stages)-1]
+			stage.copies = append(stage.copies, copy)
+			continue
+		}
+
+		switch strings.ToUpper(instruction) {
+		case "RUN", "WORKDIR", "ENV", "USER", "EXPOSE", "LABEL":
+			stage := &stages[len(stages)-1]
+			stage.commands = append(stage.commands, substituteArgs(trimmed, globalArgs))
 		}
+		// Other instructions (CMD, ENTRYPOINT, VOLUME, ONBUILD, HEALTHCHECK,
+		// STOPSIGNAL, SHELL) have no equivalent in Modal's sandbox model - a
+		// sandbox's command is set by rollout, not the image - so they're
+		// silently ignored rather than rejected.
 	}
 
-	if baseImage == "" {
-		return "", nil, fmt.Errorf("no FROM instruction found in Dockerfile")
+	if len(stages) == 0 {
+		return "", nil, nil, fmt.Errorf("no FROM instruction found in Dockerfile")
 	}
 
-	return baseImage, commands, nil
+	final := stages[len(stages)-1]
+	return final.image, final.commands, final.copies, nil
+}
+
+// parseCopyInstruction parses a COPY or local-file ADD instruction's
+// argument list into a single source/destination pair. Flags like
+// --from/--chown/--chmod and multi-source forms aren't supported, since
+// rollout task Dockerfiles only ever copy one fixture path at a time;
+// neither is ADD's remote-URL or archive-extraction form, since staging
+// those into a running sandbox is a materially different operation than a
+// plain file copy.
+func parseCopyInstruction(instruction, rest string) (dockerCopy, error) {
+	fields := strings.Fields(rest)
+	var srcDst []string
+	for _, f := range fields {
+		if strings.HasPrefix(f, "--") {
+			return dockerCopy{}, fmt.Errorf("%s flags (e.g. --from, --chown) are not supported in Modal environment Dockerfiles", instruction)
+		}
+		srcDst = append(srcDst, f)
+	}
+	if len(srcDst) != 2 {
+		return dockerCopy{}, fmt.Errorf("only a single source and destination are supported in Modal environment %s instructions", instruction)
+	}
+	src, dst := srcDst[0], srcDst[1]
+	if strings.EqualFold(instruction, "ADD") && (strings.Contains(src, "://") || strings.HasSuffix(src, ".tar") || strings.HasSuffix(src, ".tar.gz") || strings.HasSuffix(src, ".tgz")) {
+		return dockerCopy{}, fmt.Errorf("ADD's remote-URL and archive-extraction forms are not supported in Modal environment Dockerfiles")
+	}
+	return dockerCopy{src: src, dst: dst}, nil
 }
 
 // parseCPUs converts a CPU string to a count.
@@ -390,8 +855,12 @@ type ModalEnvironment struct {
 	app       *modal.App
 	appName   string
 	startTime time.Time
-	cpuCount  int
+	cpuCount  float64
 	memoryMiB int
+	// gpu is the modal.SandboxCreateParams.GPU-style spec this sandbox was
+	// created with ("A10G", "A10G:2", or "" for no GPU), used by Cost() to
+	// estimate GPU spend.
+	gpu string
 }
 
 // ID returns the sandbox ID.
@@ -399,18 +868,65 @@ func (e *ModalEnvironment) ID() string {
 	return e.sandbox.SandboxID
 }
 
-// CopyTo copies a local file or directory into the sandbox.
+// modalSnapshotRefPrefix marks a CreateEnvironmentOptions.ImageRef as a
+// previously-snapshotted Modal image ID returned by Snapshot, rather than a
+// registry tag or Dockerfile context directory path.
+const modalSnapshotRefPrefix = "modal-snapshot:"
+
+// snapshotTimeout bounds how long Snapshot waits for Modal to finish
+// snapshotting the sandbox's filesystem.
+const snapshotTimeout = 5 * time.Minute
+
+// Snapshot takes a filesystem snapshot of the running sandbox and encodes
+// it as an ImageRef a later CreateEnvironment call can pass back in to
+// start a fresh sandbox from this one's filesystem instead of rebuilding
+// the image and reinstalling an agent from scratch.
+func (e *ModalEnvironment) Snapshot(ctx context.Context) (string, error) {
+	image, err := e.sandbox.SnapshotFilesystem(ctx, snapshotTimeout)
+	if err != nil {
+		return "", fmt.Errorf("snapshotting sandbox filesystem: %w", err)
+	}
+	return modalSnapshotRefPrefix + image.ImageID, nil
+}
+
+// tunnelTimeout bounds how long ExposePort waits for Modal to report the
+// sandbox's tunnel assignments.
+const tunnelTimeout = 30 * time.Second
+
+// ExposePort returns the TLS tunnel URL Modal assigned to port. The port
+// must have been listed in ProviderConfig.ExposePorts (or a task's
+// provider_hints.expose_ports) when the sandbox was created - Modal tunnels
+// can't be added to a sandbox after the fact.
+func (e *ModalEnvironment) ExposePort(ctx context.Context, port int) (string, error) {
+	tunnels, err := e.sandbox.Tunnels(ctx, tunnelTimeout)
+	if err != nil {
+		return "", fmt.Errorf("fetching modal sandbox tunnels: %w", err)
+	}
+	tunnel, ok := tunnels[port]
+	if !ok {
+		return "", fmt.Errorf("port %d was not tunneled - add it to provider_config.expose_ports or the task's provider_hints.expose_ports", port)
+	}
+	return tunnel.URL(), nil
+}
+
+// CopyTo copies a local file or directory into the sandbox by streaming a
+// single tarball through an exec'd `tar -x`, rather than issuing one RPC per
+// file: copying a tests/ tree with thousands of files through e.sandbox.Open
+// calls took minutes, the same problem the apple provider avoids by
+// shelling out to `container cp` instead of doing its own file-by-file copy.
 func (e *ModalEnvironment) CopyTo(ctx context.Context, src, dst string) error {
 	info, err := os.Stat(src)
 	if err != nil {
 		return fmt.Errorf("stat source: %w", err)
 	}
 
-	// Ensure destination directory exists via exec
-	dstDir := filepath.Dir(dst)
-	if dstDir != "/" && dstDir != "." {
-		if _, err := e.execSimple(ctx, fmt.Sprintf("mkdir -p %q", dstDir)); err != nil {
-			return fmt.Errorf("creating directory %s: %w", dstDir, err)
+	extractDir := dst
+	if !info.IsDir() {
+		extractDir = filepath.Dir(dst)
+	}
+	if extractDir != "/" && extractDir != "." {
+		if _, err := e.execSimple(ctx, fmt.Sprintf("mkdir -p %q", extractDir)); err != nil {
+			return fmt.Errorf("creating directory %s: %w", extractDir, err)
 		}
 	}
 
@@ -420,140 +936,241 @@ func (e *ModalEnvironment) CopyTo(ctx context.Context, src, dst string) error {
 		"dst", dst,
 		"is_dir", info.IsDir())
 
-	if info.IsDir() {
-		return e.copyDirTo(ctx, src, dst)
-	}
-	return e.copyFileTo(ctx, src, dst)
-}
-
-// copyFileTo copies a single file to the sandbox.
-func (e *ModalEnvironment) copyFileTo(ctx context.Context, src, dst string) error {
-	content, err := os.ReadFile(src)
+	process, err := e.sandbox.Exec(ctx, []string{"tar", "-x", "-C", extractDir}, &modal.SandboxExecParams{})
 	if err != nil {
-		return fmt.Errorf("reading source file: %w", err)
+		return fmt.Errorf("starting tar extract: %w", err)
 	}
 
-	f, err := e.sandbox.Open(ctx, dst, "w")
-	if err != nil {
-		return fmt.Errorf("opening destination file: %w", err)
-	}
+	var stderr bytes.Buffer
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(io.Discard, process.Stdout)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(&stderr, process.Stderr)
+		done <- struct{}{}
+	}()
 
-	if _, err := f.Write(content); err != nil {
-		f.Close()
-		return fmt.Errorf("writing to destination: %w", err)
-	}
+	tarErr := writeTarArchive(process.Stdin, src, dst, info)
+	closeErr := process.Stdin.Close()
+	<-done
+	<-done
 
-	if err := f.Flush(); err != nil {
-		f.Close()
-		return fmt.Errorf("flushing file: %w", err)
+	exitCode, waitErr := process.Wait(ctx)
+	if tarErr != nil {
+		return fmt.Errorf("building tar stream to sandbox: %w", tarErr)
 	}
-
-	return f.Close()
+	if closeErr != nil {
+		return fmt.Errorf("closing tar stream to sandbox: %w", closeErr)
+	}
+	if waitErr != nil {
+		return fmt.Errorf("waiting for tar extract: %w", waitErr)
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("tar extract in sandbox exited %d: %s", exitCode, stderr.String())
+	}
+	return nil
 }
 
-// copyDirTo recursively copies a directory to the sandbox.
-func (e *ModalEnvironment) copyDirTo(ctx context.Context, src, dst string) error {
-	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
+// writeTarArchive writes src (a file or a directory tree) to w as a tar
+// stream and closes tw when done. Directory entries are written relative to
+// src, so extracting with `tar -x -C dst` reproduces src's contents
+// directly under dst; a single file is written under the basename of dst.
+func writeTarArchive(w io.Writer, src, dst string, info os.FileInfo) error {
+	tw := tar.NewWriter(w)
+	if !info.IsDir() {
+		if err := writeTarEntry(tw, src, filepath.Base(dst), info); err != nil {
 			return err
 		}
+		return tw.Close()
+	}
 
-		relPath, err := filepath.Rel(src, path)
+	err := filepath.Walk(src, func(path string, fi os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		return writeTarEntry(tw, path, filepath.ToSlash(rel), fi)
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
 
-		dstPath := filepath.Join(dst, relPath)
-
-		if info.IsDir() {
-			_, err := e.execSimple(ctx, fmt.Sprintf("mkdir -p %q", dstPath))
+// writeTarEntry writes a single tar header (plus file content, for regular
+// files) for the file at path under name.
+func writeTarEntry(tw *tar.Writer, path, name string, info os.FileInfo) error {
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return fmt.Errorf("reading symlink %s: %w", path, err)
+		}
+		hdr, err := tar.FileInfoHeader(info, target)
+		if err != nil {
 			return err
 		}
+		hdr.Name = name
+		return tw.WriteHeader(hdr)
+	}
 
-		return e.copyFileTo(ctx, path, dstPath)
-	})
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(tw, f)
+	return err
 }
 
-// CopyFrom copies a file or directory from the sandbox to local path.
+// CopyFrom copies a file or directory from the sandbox to a local path by
+// streaming a single tarball through an exec'd `tar -c`, the reverse of
+// CopyTo.
 func (e *ModalEnvironment) CopyFrom(ctx context.Context, src, dst string) error {
 	slog.Debug("copying from modal sandbox",
 		"sandbox_id", e.sandbox.SandboxID,
 		"src", src,
 		"dst", dst)
 
-	// Check if source is a directory by trying to list it
 	exitCode, _ := e.execSimple(ctx, fmt.Sprintf("test -d %q", src))
-	if exitCode == 0 {
-		return e.copyDirFrom(ctx, src, dst)
+	isDir := exitCode == 0
+
+	tarDir, tarName := filepath.Dir(src), filepath.Base(src)
+	if isDir {
+		tarDir, tarName = src, "."
 	}
-	return e.copyFileFrom(ctx, src, dst)
-}
 
-// copyFileFrom copies a single file from the sandbox.
-func (e *ModalEnvironment) copyFileFrom(ctx context.Context, src, dst string) error {
-	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+	if isDir {
+		if err := os.MkdirAll(dst, 0755); err != nil {
+			return fmt.Errorf("creating local directory: %w", err)
+		}
+	} else if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
 		return fmt.Errorf("creating local directory: %w", err)
 	}
 
-	f, err := e.sandbox.Open(ctx, src, "r")
+	process, err := e.sandbox.Exec(ctx, []string{"tar", "-c", "-C", tarDir, tarName}, &modal.SandboxExecParams{})
 	if err != nil {
-		return fmt.Errorf("opening source file: %w", err)
+		return fmt.Errorf("starting tar archive: %w", err)
 	}
 
-	content, err := io.ReadAll(f)
-	f.Close()
-	if err != nil {
-		return fmt.Errorf("reading source file: %w", err)
-	}
+	var stderr bytes.Buffer
+	stderrDone := make(chan struct{})
+	go func() {
+		io.Copy(&stderr, process.Stderr)
+		close(stderrDone)
+	}()
 
-	if err := os.WriteFile(dst, content, 0644); err != nil {
-		return fmt.Errorf("writing destination file: %w", err)
-	}
+	extractErr := extractTarArchive(process.Stdout, dst, isDir)
+	<-stderrDone
 
-	return nil
-}
-
-// copyDirFrom recursively copies a directory from the sandbox.
-func (e *ModalEnvironment) copyDirFrom(ctx context.Context, src, dst string) error {
-	if err := os.MkdirAll(dst, 0755); err != nil {
-		return fmt.Errorf("creating local directory: %w", err)
+	exitCode, waitErr := process.Wait(ctx)
+	if extractErr != nil {
+		return fmt.Errorf("extracting tar stream from sandbox: %w", extractErr)
 	}
-
-	// List directory contents using find command
-	var stdout strings.Builder
-	process, err := e.sandbox.Exec(ctx, []string{"find", src, "-maxdepth", "1", "-mindepth", "1"}, &modal.SandboxExecParams{})
-	if err != nil {
-		return fmt.Errorf("listing sandbox directory: %w", err)
+	if waitErr != nil {
+		return fmt.Errorf("waiting for tar archive: %w", waitErr)
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("tar archive in sandbox exited %d: %s", exitCode, stderr.String())
 	}
+	return nil
+}
 
-	io.Copy(&stdout, process.Stdout)
-	if _, err := process.Wait(ctx); err != nil {
-		return fmt.Errorf("waiting for find: %w", err)
+// safeJoin joins dst and name (a tar entry path) and rejects the result if
+// it escapes dst, e.g. via a ".." component or an absolute path. The
+// sandbox we tar-extract from runs an untrusted agent, which can put its
+// own "tar" earlier on its PATH and have CopyFrom's exec return a crafted
+// archive with header names like "../../../etc/passwd" - this is the
+// standard zip-slip defense any extractor of untrusted archives needs.
+func safeJoin(dst, name string) (string, error) {
+	target := filepath.Join(dst, filepath.FromSlash(name))
+	cleanDst := filepath.Clean(dst)
+	if target != cleanDst && !strings.HasPrefix(target, cleanDst+string(os.PathSeparator)) {
+		return "", fmt.Errorf("tar entry %q escapes destination %q", name, dst)
 	}
+	return target, nil
+}
 
-	entries := strings.Split(strings.TrimSpace(stdout.String()), "\n")
-	for _, entry := range entries {
-		if entry == "" {
-			continue
+// extractTarArchive reads a tar stream from r and writes it to dst. When
+// isDir is true, each entry is written relative to dst (the tarball was
+// built with -C src .); otherwise the stream holds exactly one entry,
+// written to dst directly regardless of its recorded name.
+func extractTarArchive(r io.Reader, dst string, isDir bool) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
 		}
 
-		baseName := filepath.Base(entry)
-		dstPath := filepath.Join(dst, baseName)
+		target := dst
+		if isDir {
+			name := strings.TrimPrefix(hdr.Name, "./")
+			if name == "" || name == "." {
+				continue
+			}
+			var err error
+			target, err = safeJoin(dst, name)
+			if err != nil {
+				return err
+			}
+		}
 
-		// Check if it's a directory
-		exitCode, _ := e.execSimple(ctx, fmt.Sprintf("test -d %q", entry))
-		if exitCode == 0 {
-			if err := e.copyDirFrom(ctx, entry, dstPath); err != nil {
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.FileMode(hdr.Mode)); err != nil {
 				return err
 			}
-		} else {
-			if err := e.copyFileFrom(ctx, entry, dstPath); err != nil {
+		case tar.TypeSymlink:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			os.Remove(target)
+			if err := os.Symlink(hdr.Linkname, target); err != nil {
+				return err
+			}
+		default:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(hdr.Mode))
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
 				return err
 			}
 		}
 	}
-
-	return nil
 }
 
 // execSimple runs a simple command and returns the exit code.
@@ -589,7 +1206,16 @@ func (e *ModalEnvironment) Exec(ctx context.Context, cmd string, stdout, stderr
 		"command", cmdPreview,
 		"timeout", opts.Timeout)
 
-	process, err := e.sandbox.Exec(ctx, []string{"bash", "-c", cmd}, execParams)
+	// libmodal's SandboxExecParams has no user field, so running as a
+	// different user is done by wrapping the command in su rather than
+	// passing it through the SDK, unlike opts.User on docker (-u) and
+	// apple (--user).
+	execScript := cmd
+	if opts.User != "" {
+		execScript = fmt.Sprintf("su - %s -c %s", shellQuote(opts.User), shellQuote(cmd))
+	}
+
+	process, err := e.sandbox.Exec(ctx, []string{"bash", "-c", execScript}, execParams)
 	if err != nil {
 		return -1, fmt.Errorf("executing command: %w", err)
 	}
@@ -633,17 +1259,28 @@ func (e *ModalEnvironment) Exec(ctx context.Context, cmd string, stdout, stderr
 	return exitCode, nil
 }
 
+// shellQuote wraps s in single quotes for inclusion in a bash -c string,
+// escaping any single quotes it contains.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
 // Stop stops the sandbox but does not remove it.
 func (e *ModalEnvironment) Stop(ctx context.Context) error {
 	slog.Debug("stopping modal sandbox", "sandbox_id", e.sandbox.SandboxID)
 	return e.sandbox.Terminate(ctx)
 }
 
-// Destroy removes the sandbox and cleans up all resources.
+// Destroy terminates the sandbox. It deliberately does not also stop the
+// Modal app: the modal-go SDK doesn't expose an AppStop API, and shelling
+// out to `modal app stop` would make the Python CLI a hard runtime
+// dependency just to tidy up the console. An app left running with zero
+// sandboxes costs nothing and does not count against any running-sandbox
+// limit, so the only cost of skipping this is cosmetic (the app lingers in
+// Modal's dashboard until its environment is cleaned up separately).
 func (e *ModalEnvironment) Destroy(ctx context.Context) error {
 	slog.Debug("destroying modal sandbox", "sandbox_id", e.sandbox.SandboxID, "app", e.appName)
 
-	// Terminate the sandbox first
 	if err := e.sandbox.Terminate(ctx); err != nil {
 		if !strings.Contains(err.Error(), "already terminated") &&
 			!strings.Contains(err.Error(), "not found") {
@@ -651,46 +1288,67 @@ func (e *ModalEnvironment) Destroy(ctx context.Context) error {
 		}
 	}
 
-	// Stop the Modal app to clean it up from the console.
-	// The modal-go SDK doesn't expose AppStop on the public API, so we use the CLI.
-	if err := e.stopApp(ctx); err != nil {
-		return fmt.Errorf("stopping app: %w", err)
-	}
-
 	slog.Debug("modal sandbox destroyed", "sandbox_id", e.sandbox.SandboxID)
 	return nil
 }
 
-// stopApp stops the Modal app using the modal CLI.
-func (e *ModalEnvironment) stopApp(ctx context.Context) error {
-	modalPath, err := exec.LookPath("modal")
-	if err != nil {
-		return fmt.Errorf("modal CLI not found: the modal-go SDK does not expose the AppStop API, " +
-			"so the CLI is required to clean up apps. Install it with: pip install modal")
-	}
-
-	cmd := exec.CommandContext(ctx, modalPath, "app", "stop", e.appName)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		// Ignore errors if app is already stopped or not found
-		outStr := string(output)
-		if strings.Contains(outStr, "already stopped") ||
-			strings.Contains(outStr, "not found") ||
-			strings.Contains(outStr, "Could not find") {
-			return nil
-		}
-		return fmt.Errorf("modal app stop failed: %s", outStr)
-	}
-	return nil
-}
-
 // Cost returns the cost incurred by this environment.
 // Modal pricing (approximate, as of 2024):
 // - CPU: ~$0.000463 per CPU-second
 // - Memory: ~$0.000058 per GiB-second
 func (e *ModalEnvironment) Cost() float64 {
 	duration := time.Since(e.startTime).Seconds()
-	cpuCost := duration * float64(e.cpuCount) * 0.000463
+	cpuCost := duration * e.cpuCount * 0.000463
 	memoryCost := duration * (float64(e.memoryMiB) / 1024.0) * 0.000058
-	return cpuCost + memoryCost
+	gpuCost := duration * gpuRatePerSecond(e.gpu)
+	return cpuCost + memoryCost + gpuCost
+}
+
+// gpuHourlyUSD gives Modal's approximate per-GPU-hour list price for each
+// supported GPU type, used to estimate GPU spend in Cost() the same way
+// cpuCost/memoryCost already estimate CPU/memory spend. Update if Modal's
+// pricing page changes.
+var gpuHourlyUSD = map[string]float64{
+	"T4":        0.59,
+	"L4":        0.80,
+	"A10G":      1.10,
+	"A100":      2.10,
+	"A100-80GB": 2.50,
+	"L40S":      1.95,
+	"H100":      3.95,
+	"H200":      4.54,
+	"B200":      6.25,
+}
+
+// gpuRatePerSecond parses a modal.SandboxCreateParams.GPU-style spec
+// ("A10G" or "A10G:2") into its estimated per-second USD rate, multiplying
+// the per-GPU rate by the requested count. Returns 0 for an empty or
+// unrecognized spec.
+func gpuRatePerSecond(gpu string) float64 {
+	if gpu == "" {
+		return 0
+	}
+	typ, countStr, hasCount := strings.Cut(gpu, ":")
+	hourlyRate, ok := gpuHourlyUSD[typ]
+	if !ok {
+		return 0
+	}
+	count := 1
+	if hasCount {
+		if n, err := strconv.Atoi(countStr); err == nil && n > 0 {
+			count = n
+		}
+	}
+	return (hourlyRate / 3600.0) * float64(count)
+}
+
+// estimateHourlyUSD estimates a sandbox's USD/hour cost from its requested
+// (not limit) CPU/memory/GPU, using the same per-second rates Cost() uses
+// to bill an already-running sandbox, for the constraints.max_price
+// preflight check in CreateEnvironment.
+func estimateHourlyUSD(r sandboxResources) float64 {
+	cpuPerSec := r.cpuRequest * 0.000463
+	memPerSec := (float64(r.memRequest) / 1024.0) * 0.000058
+	gpuPerSec := gpuRatePerSecond(r.gpu())
+	return (cpuPerSec + memPerSec + gpuPerSec) * 3600.0
 }
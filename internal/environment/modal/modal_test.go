@@ -11,6 +11,7 @@ func TestParseDockerfile(t *testing.T) {
 		content     string
 		wantBase    string
 		wantCmds    int
+		wantCopies  int
 		wantErr     bool
 		errContains string
 	}{
@@ -31,18 +32,29 @@ ENV MY_VAR=test
 FROM python:3.10
 COPY . /app
 RUN pip install -r requirements.txt
+`,
+			wantBase:   "python:3.10",
+			wantCmds:   1,
+			wantCopies: 1,
+			wantErr:    false,
+		},
+		{
+			name: "dockerfile with COPY flags rejected",
+			content: `
+FROM python:3.10
+COPY --chown=user:user . /app
 `,
 			wantErr:     true,
-			errContains: "COPY and ADD instructions are not supported",
+			errContains: "flags",
 		},
 		{
-			name: "dockerfile with ADD",
+			name: "dockerfile with remote ADD rejected",
 			content: `
 FROM alpine:latest
 ADD https://example.com/file.tar.gz /tmp/
 `,
 			wantErr:     true,
-			errContains: "COPY and ADD instructions are not supported",
+			errContains: "remote-URL",
 		},
 		{
 			name: "dockerfile with line continuations",
@@ -65,16 +77,50 @@ RUN echo "hello"
 			errContains: "no FROM instruction found",
 		},
 		{
-			name: "multiple FROM - uses last",
+			name: "multiple FROM - uses last stage's instructions only",
+			content: `
+FROM golang:1.21 AS builder
+RUN go build ./...
+FROM alpine:latest
+RUN apk add --no-cache ca-certificates
+`,
+			wantBase: "alpine:latest",
+			wantCmds: 1,
+			wantErr:  false,
+		},
+		{
+			name: "multiple FROM with no instructions in final stage",
 			content: `
 FROM golang:1.21
 RUN go version
 FROM alpine:latest
 `,
 			wantBase: "alpine:latest",
+			wantCmds: 0,
+			wantErr:  false,
+		},
+		{
+			name: "ARG substitution into FROM",
+			content: `
+ARG BASE_VERSION=3.11
+FROM python:${BASE_VERSION}
+RUN pip install requests
+`,
+			wantBase: "python:3.11",
 			wantCmds: 1,
 			wantErr:  false,
 		},
+		{
+			name: "heredoc instruction rejected",
+			content: `
+FROM ubuntu:22.04
+RUN <<EOF
+apt-get update
+EOF
+`,
+			wantErr:     true,
+			errContains: "heredoc",
+		},
 		{
 			name: "comments and empty lines",
 			content: `
@@ -104,7 +150,7 @@ workdir /app
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			base, cmds, err := parseDockerfile(tt.content)
+			base, cmds, copies, err := parseDockerfile(tt.content)
 
 			if tt.wantErr {
 				if err == nil {
@@ -125,6 +171,9 @@ workdir /app
 				if len(cmds) != tt.wantCmds {
 					t.Errorf("expected %d commands, got %d", tt.wantCmds, len(cmds))
 				}
+				if len(copies) != tt.wantCopies {
+					t.Errorf("expected %d copies, got %d", tt.wantCopies, len(copies))
+				}
 			}
 		})
 	}
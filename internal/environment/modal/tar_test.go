@@ -0,0 +1,112 @@
+package modal
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteAndExtractTarArchiveFile(t *testing.T) {
+	srcDir := t.TempDir()
+	src := filepath.Join(srcDir, "hello.txt")
+	if err := os.WriteFile(src, []byte("hello world"), 0644); err != nil {
+		t.Fatalf("writing source file: %v", err)
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		t.Fatalf("stat source: %v", err)
+	}
+
+	dstDir := t.TempDir()
+	dst := filepath.Join(dstDir, "copied.txt")
+
+	var buf bytes.Buffer
+	if err := writeTarArchive(&buf, src, dst, info); err != nil {
+		t.Fatalf("writeTarArchive: %v", err)
+	}
+	if err := extractTarArchive(&buf, dst, false); err != nil {
+		t.Fatalf("extractTarArchive: %v", err)
+	}
+
+	got, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatalf("reading extracted file: %v", err)
+	}
+	if string(got) != "hello world" {
+		t.Errorf("expected %q, got %q", "hello world", string(got))
+	}
+}
+
+func TestWriteAndExtractTarArchiveDir(t *testing.T) {
+	srcDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(srcDir, "sub"), 0755); err != nil {
+		t.Fatalf("creating subdir: %v", err)
+	}
+	files := map[string]string{
+		"top.txt":      "top",
+		"sub/deep.txt": "deep",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+	info, err := os.Stat(srcDir)
+	if err != nil {
+		t.Fatalf("stat source: %v", err)
+	}
+
+	dstDir := filepath.Join(t.TempDir(), "extracted")
+
+	var buf bytes.Buffer
+	if err := writeTarArchive(&buf, srcDir, dstDir, info); err != nil {
+		t.Fatalf("writeTarArchive: %v", err)
+	}
+	if err := extractTarArchive(&buf, dstDir, true); err != nil {
+		t.Fatalf("extractTarArchive: %v", err)
+	}
+
+	for name, want := range files {
+		got, err := os.ReadFile(filepath.Join(dstDir, name))
+		if err != nil {
+			t.Fatalf("reading extracted %s: %v", name, err)
+		}
+		if string(got) != want {
+			t.Errorf("%s: expected %q, got %q", name, want, string(got))
+		}
+	}
+}
+
+// TestExtractTarArchiveRejectsPathTraversal guards against a hostile "tar"
+// binary inside the sandbox (CopyFrom execs "tar" there and trusts
+// whatever it returns) smuggling a header name that escapes dst.
+func TestExtractTarArchiveRejectsPathTraversal(t *testing.T) {
+	dstDir := filepath.Join(t.TempDir(), "extracted")
+	outsideMarker := filepath.Join(filepath.Dir(dstDir), "escaped.txt")
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	payload := []byte("pwned")
+	if err := tw.WriteHeader(&tar.Header{
+		Name: "../escaped.txt",
+		Mode: 0644,
+		Size: int64(len(payload)),
+	}); err != nil {
+		t.Fatalf("writing tar header: %v", err)
+	}
+	if _, err := tw.Write(payload); err != nil {
+		t.Fatalf("writing tar entry: %v", err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("closing tar writer: %v", err)
+	}
+
+	if err := extractTarArchive(&buf, dstDir, true); err == nil {
+		t.Fatal("expected extractTarArchive to reject a path-traversing entry")
+	}
+	if _, err := os.Stat(outsideMarker); !os.IsNotExist(err) {
+		t.Errorf("expected no file written outside dst, stat err = %v", err)
+	}
+}
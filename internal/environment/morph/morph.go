@@ -0,0 +1,304 @@
+// Package morph implements the "morph" environment provider, which runs
+// trials as Morph Cloud MicroVM instances instead of local Docker
+// containers. Like the fargate and k8s providers, it shells out to a CLI
+// (morphcloud) rather than linking a Go SDK.
+//
+// Unlike most other providers, BuildImage here is not a stub: Morph has no
+// Docker daemon to build against, but it does let a running instance be
+// snapshotted, and new instances can be started from a snapshot in seconds
+// instead of rebuilding from scratch. BuildImage starts an instance from the
+// provider's configured base snapshot, runs the task Dockerfile's RUN
+// instructions against it, and snapshots the result; that snapshot ID is
+// returned as the image ref and passed straight to CreateEnvironment for
+// every trial, so n_attempts runs pay the build cost once instead of once
+// per trial.
+package morph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spachava753/rollout/internal/environment"
+)
+
+// ProviderConfig holds Morph-specific configuration.
+type ProviderConfig struct {
+	// APIKey authenticates with the Morph Cloud API. Passed to the CLI via
+	// the MORPH_API_KEY environment variable rather than a flag, so it
+	// never shows up in process listings.
+	APIKey string
+	// BaseSnapshotID is the Morph snapshot BuildImage starts an instance
+	// from before running the task Dockerfile's RUN instructions and
+	// re-snapshotting. Required for BuildImage; not needed if every task
+	// sets docker_image to an existing snapshot ID instead.
+	BaseSnapshotID string
+}
+
+// ParseProviderConfig extracts Morph-specific config from the generic config map.
+func ParseProviderConfig(config map[string]any) ProviderConfig {
+	var pc ProviderConfig
+	if config == nil {
+		return pc
+	}
+	if v, ok := config["api_key"].(string); ok {
+		pc.APIKey = v
+	}
+	if v, ok := config["base_snapshot_id"].(string); ok {
+		pc.BaseSnapshotID = v
+	}
+	return pc
+}
+
+// Provider implements the Morph Cloud environment provider.
+type Provider struct {
+	config ProviderConfig
+}
+
+// NewProvider creates a new Morph provider.
+func NewProvider(config ProviderConfig) *Provider {
+	return &Provider{config: config}
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "morph"
+}
+
+func (p *Provider) morph(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "morphcloud", args...)
+	cmd.Env = append(cmd.Environ(), "MORPH_API_KEY="+p.config.APIKey)
+	return cmd
+}
+
+var runRe = regexp.MustCompile(`(?im)^\s*RUN\s+(.+)$`)
+
+// BuildImage starts an instance from the configured base snapshot, runs the
+// task Dockerfile's RUN instructions against it, and snapshots the result.
+// Only RUN instructions are honored: FROM is ignored in favor of
+// BaseSnapshotID, and instructions like COPY have no equivalent without a
+// build context already present on the instance.
+func (p *Provider) BuildImage(ctx context.Context, opts environment.BuildImageOptions) (string, error) {
+	if p.config.BaseSnapshotID == "" {
+		return "", fmt.Errorf("morph provider requires provider_config.base_snapshot_id to build images")
+	}
+
+	dockerfile, err := os.ReadFile(filepath.Join(opts.ContextDir, "Dockerfile"))
+	if err != nil {
+		return "", fmt.Errorf("reading Dockerfile: %w", err)
+	}
+	var runCmds []string
+	for _, m := range runRe.FindAllStringSubmatch(string(dockerfile), -1) {
+		runCmds = append(runCmds, strings.TrimSpace(m[1]))
+	}
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	slog.Debug("starting instance from base snapshot", "base_snapshot", p.config.BaseSnapshotID)
+	instanceID, err := p.startInstance(ctx, p.config.BaseSnapshotID)
+	if err != nil {
+		return "", fmt.Errorf("starting build instance: %w", err)
+	}
+	defer func() {
+		if err := p.stopInstance(context.Background(), instanceID); err != nil {
+			slog.Warn("failed to stop build instance", "instance", instanceID, "error", err)
+		}
+	}()
+
+	for _, runCmd := range runCmds {
+		slog.Debug("running build instruction", "instance", instanceID, "cmd", runCmd)
+		execCmd := p.morph(ctx, "instance", "exec", instanceID, "--", "bash", "-c", runCmd)
+		var stderr bytes.Buffer
+		execCmd.Stderr = &stderr
+		if err := execCmd.Run(); err != nil {
+			return "", fmt.Errorf("running %q on build instance: %w: %s", runCmd, err, stderr.String())
+		}
+	}
+
+	slog.Debug("snapshotting build instance", "instance", instanceID, "tag", opts.Tag)
+	cmd := p.morph(ctx, "instance", "snapshot", instanceID, "--json")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("snapshotting build instance: %w: %s", err, stderr.String())
+	}
+
+	var resp struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil || resp.ID == "" {
+		return "", fmt.Errorf("parsing snapshot response: %w", err)
+	}
+
+	slog.Debug("built snapshot", "snapshot", resp.ID, "tag", opts.Tag)
+	return resp.ID, nil
+}
+
+// PullImage verifies that imageRef (a Morph snapshot ID, not a Docker image)
+// already exists; there is nothing to pull since instances start directly
+// from snapshots already stored in Morph's own account.
+func (p *Provider) PullImage(ctx context.Context, imageRef string) error {
+	cmd := p.morph(ctx, "snapshot", "get", imageRef)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("snapshot %s not found: %w: %s", imageRef, err, stderr.String())
+	}
+	return nil
+}
+
+// CreateEnvironment starts a new Morph instance from the trial snapshot.
+func (p *Provider) CreateEnvironment(ctx context.Context, opts environment.CreateEnvironmentOptions) (environment.Environment, error) {
+	slog.Debug("starting morph instance", "snapshot", opts.ImageRef)
+	instanceID, err := p.startInstance(ctx, opts.ImageRef)
+	if err != nil {
+		return nil, fmt.Errorf("starting instance: %w", err)
+	}
+	slog.Debug("morph instance running", "instance", instanceID)
+
+	return &Environment{provider: p, instanceID: instanceID}, nil
+}
+
+func (p *Provider) startInstance(ctx context.Context, snapshotID string) (string, error) {
+	cmd := p.morph(ctx, "instance", "start", snapshotID, "--json")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%w: %s", err, stderr.String())
+	}
+
+	var resp struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil || resp.ID == "" {
+		return "", fmt.Errorf("parsing instance start response: %w", err)
+	}
+	return resp.ID, nil
+}
+
+func (p *Provider) stopInstance(ctx context.Context, instanceID string) error {
+	cmd := p.morph(ctx, "instance", "stop", instanceID)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// Environment represents a running Morph instance.
+type Environment struct {
+	provider   *Provider
+	instanceID string
+}
+
+// ID returns the instance ID.
+func (e *Environment) ID() string {
+	return e.instanceID
+}
+
+// CopyTo copies a local file or directory into the instance.
+func (e *Environment) CopyTo(ctx context.Context, src, dst string) error {
+	slog.Debug("copying to morph instance", "instance", e.instanceID, "src", src, "dst", dst)
+
+	cmd := e.provider.morph(ctx, "instance", "copy", src, fmt.Sprintf("%s:%s", e.instanceID, dst))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("copying to instance: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// CopyFrom copies a file or directory from the instance to a local path.
+func (e *Environment) CopyFrom(ctx context.Context, src, dst string) error {
+	slog.Debug("copying from morph instance", "instance", e.instanceID, "src", src, "dst", dst)
+
+	cmd := e.provider.morph(ctx, "instance", "copy", fmt.Sprintf("%s:%s", e.instanceID, src), dst)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("copying from instance: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// buildShellCommand wraps cmd in the cd/export prefix needed to apply
+// opts.WorkDir and opts.Env before bash runs it, shell-quoting both so a
+// value containing shell metacharacters can't inject extra commands.
+func buildShellCommand(cmd string, opts environment.ExecOptions) string {
+	shellCmd := cmd
+	if opts.WorkDir != "" {
+		shellCmd = fmt.Sprintf("cd %s && %s", environment.ShellQuote(opts.WorkDir), cmd)
+	}
+	for k, v := range opts.Env {
+		shellCmd = fmt.Sprintf("export %s=%s && %s", k, environment.ShellQuote(v), shellCmd)
+	}
+	return shellCmd
+}
+
+// Exec executes a command in the instance.
+func (e *Environment) Exec(ctx context.Context, cmd string, stdout, stderr io.Writer, opts environment.ExecOptions) (int, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	shellCmd := buildShellCommand(cmd, opts)
+
+	execCmd := e.provider.morph(ctx, "instance", "exec", e.instanceID, "--", "bash", "-c", shellCmd)
+	execCmd.Stdout = stdout
+	execCmd.Stderr = stderr
+
+	err := execCmd.Run()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode(), nil
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return -1, fmt.Errorf("command timed out")
+		}
+		return -1, fmt.Errorf("executing command: %w", err)
+	}
+	return 0, nil
+}
+
+// Stop pauses the instance but does not remove it.
+func (e *Environment) Stop(ctx context.Context) error {
+	return e.provider.stopInstance(ctx, e.instanceID)
+}
+
+// Destroy stops and deletes the instance.
+func (e *Environment) Destroy(ctx context.Context) error {
+	slog.Debug("deleting morph instance", "instance", e.instanceID)
+
+	cmd := e.provider.morph(ctx, "instance", "stop", e.instanceID, "--delete")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("deleting instance: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// Cost returns the cost incurred by this environment. Morph bills
+// per-second based on exact vCPU/memory allocation, which this provider
+// does not query, so cost tracking is left to Morph's own billing.
+func (e *Environment) Cost() float64 {
+	return 0
+}
@@ -3,7 +3,10 @@ package environment
 import (
 	"context"
 	"io"
+	"os"
 	"time"
+
+	"github.com/spachava753/rollout/internal/audit"
 )
 
 // Environment represents a running container environment.
@@ -31,11 +34,102 @@ type Environment interface {
 	Cost() float64
 }
 
+// ResourceUsage is a point-in-time snapshot of an environment's resource
+// consumption.
+type ResourceUsage struct {
+	CPUPercent float64
+	MemoryMB   float64
+}
+
+// ResourceSampler is implemented by environments that can report live
+// resource usage (e.g. via `docker stats`). Not every provider supports
+// this, so callers must type-assert an Environment before using it.
+type ResourceSampler interface {
+	Stats(ctx context.Context) (ResourceUsage, error)
+}
+
+// OOMDetector is implemented by environments that can report whether their
+// container was killed by the kernel OOM killer. Not every provider
+// supports this, so callers must type-assert an Environment before use.
+type OOMDetector interface {
+	WasOOMKilled(ctx context.Context) (bool, error)
+}
+
+// InterruptionDetector is implemented by environments backed by
+// preemptible/spot infrastructure that can report whether their underlying
+// instance was reclaimed out from under a trial (outbid, evicted, etc).
+// Not every provider supports this, so callers must type-assert an
+// Environment before use.
+type InterruptionDetector interface {
+	WasInterrupted(ctx context.Context) (bool, error)
+}
+
+// RuntimeNoter is implemented by providers whose configured container
+// runtime carries a resource or performance tradeoff worth surfacing on the
+// trial result rather than leaving buried in a provider doc comment (e.g.
+// Kata Containers' per-sandbox VM overhead). RuntimeNote returns an empty
+// string when the configured runtime has nothing unusual to report. Not
+// every provider supports this, so callers must type-assert a Provider
+// before use.
+type RuntimeNoter interface {
+	RuntimeNote() string
+}
+
+// Snapshotter is implemented by environments that can snapshot their
+// current filesystem state into a reusable image reference, so a later
+// CreateEnvironment call can start straight from it instead of rebuilding
+// an image and reinstalling an agent from scratch. Not every provider
+// supports this, so callers must type-assert an Environment before use.
+type Snapshotter interface {
+	Snapshot(ctx context.Context) (imageRef string, err error)
+}
+
+// PortExposer is implemented by environments that can expose a port a
+// service inside them is listening on, returning a URL reachable from the
+// host - useful for tasks whose verifier (or a developer debugging a
+// failure) needs to probe an HTTP service the agent started, rather than
+// only exec-ing commands into the environment. Not every provider supports
+// this, so callers must type-assert an Environment before use.
+type PortExposer interface {
+	ExposePort(ctx context.Context, port int) (url string, err error)
+}
+
+// ProviderReporter is implemented by environments created through a
+// multi-provider failover list (see JobEnvironmentConfig.Type), letting the
+// caller record which entry in that list actually created this environment
+// rather than assuming it was always the first. Not every provider supports
+// this, so callers must type-assert an Environment before use.
+type ProviderReporter interface {
+	ProviderName() string
+}
+
+// InteractiveExecutor is implemented by environments that can run a command
+// with a real TTY allocated and the calling process's own stdin/stdout/
+// stderr wired straight to it, for a human debugging a failing environment
+// interactively rather than scripting individual Exec calls. Not every
+// provider supports this, so callers must type-assert an Environment
+// before use.
+type InteractiveExecutor interface {
+	// ExecInteractive runs cmd with a TTY allocated, blocking until the
+	// session ends (e.g. the user exits the shell). stdin/stdout/stderr
+	// are almost always os.Stdin/os.Stdout/os.Stderr - terminal raw mode,
+	// window size, and job control all depend on them being the process's
+	// real file descriptors, not arbitrary io.Reader/Writer values.
+	ExecInteractive(ctx context.Context, cmd string, stdin *os.File, stdout, stderr *os.File) error
+}
+
 // ExecOptions configures command execution.
 type ExecOptions struct {
 	Env     map[string]string
 	Timeout time.Duration
 	WorkDir string
+	// User runs the command as this user/UID instead of the image's
+	// default, for images whose default user can't do what a given phase
+	// needs (e.g. an install script that needs root on an image that
+	// otherwise runs as an unprivileged user). Empty uses the image's own
+	// default. Not every provider supports this, so it's a no-op on
+	// providers that don't.
+	User string
 }
 
 // Provider is a factory for creating environments.
@@ -59,6 +153,108 @@ type BuildImageOptions struct {
 	Tag        string
 	Timeout    time.Duration
 	NoCache    bool
+	// ReuseExisting skips the build and returns Tag as-is if an image with
+	// that tag already exists locally. Used with deterministic tags (see
+	// models.ImageCacheConfig) to avoid rebuilding unchanged task images.
+	ReuseExisting bool
+	// BuildArgs are passed as --build-arg NAME=VALUE, letting a task
+	// parameterize its Dockerfile (e.g. a base image version) without
+	// maintaining near-duplicate Dockerfiles.
+	BuildArgs map[string]string
+	// Target selects a build stage in a multi-stage Dockerfile, passed as
+	// --target. Empty builds the Dockerfile's final stage as usual.
+	Target string
+	// AuditLogger, if set, records this build into the job's audit.jsonl.
+	// Currently only honored by the docker provider; other providers
+	// ignore it.
+	AuditLogger *audit.Logger
+}
+
+// NetworkShaping configures artificial latency/bandwidth constraints on an
+// environment's network interface, for benchmarks about resilience and
+// retry logic under degraded networks. Zero on either field disables that
+// dimension; both zero disables shaping entirely.
+type NetworkShaping struct {
+	LatencyMs     int
+	BandwidthKbps int
+}
+
+// Service describes a sidecar container a provider should start alongside
+// the main environment container on a shared network, reachable from the
+// main container by Name, before the agent phase begins. Not every
+// provider supports this; providers without a shared-network mechanism
+// ignore it and log a warning.
+type Service struct {
+	Name  string
+	Image string
+	Env   map[string]string
+	// Ports are published to the host, same as the main container's
+	// published ports.
+	Ports []int
+	// Healthcheck is a shell command the provider runs inside the service
+	// container until it exits 0 (or HealthcheckTimeout elapses), gating
+	// the agent phase on the service actually being ready rather than just
+	// started. Empty skips readiness gating.
+	Healthcheck        string
+	HealthcheckTimeout time.Duration
+}
+
+// Constraints restricts where, or how cheaply, a provider may place an
+// environment. Unlike most CreateEnvironmentOptions fields, a provider that
+// can't satisfy a non-zero field here must fail CreateEnvironment instead of
+// silently ignoring it, so a task requiring (say) a region a provider can't
+// reach fails the trial fast rather than running somewhere that quietly
+// doesn't meet it.
+type Constraints struct {
+	// Region restricts placement to a specific provider region (e.g.
+	// "us-east"). Empty lets the provider choose.
+	Region string
+	// Arch restricts placement to a CPU architecture (e.g. "amd64",
+	// "arm64"), using Go's GOARCH naming. Empty lets the provider choose.
+	Arch string
+	// MaxPriceHourly caps the estimated USD/hour cost of the environment
+	// the provider would create. Zero disables the cap. Providers without
+	// a cost model to check against ignore it and log a warning.
+	MaxPriceHourly float64
+}
+
+// Security hardens an environment beyond its provider's defaults, for
+// tasks that run untrusted agent code. Currently only honored by the
+// docker provider; other providers ignore non-zero fields and log a
+// warning.
+type Security struct {
+	// SeccompProfile is a path to a seccomp JSON profile file, or one of
+	// the special values "unconfined" or "default". Empty uses the
+	// provider's default.
+	SeccompProfile string
+	// AppArmorProfile selects an AppArmor profile by name. Empty uses the
+	// provider's default.
+	AppArmorProfile string
+	// CapDrop lists Linux capabilities to drop from the container. "ALL"
+	// drops every capability.
+	CapDrop []string
+	// CapAdd lists Linux capabilities to add back on top of CapDrop.
+	CapAdd []string
+	// NoNewPrivileges disables privilege escalation inside the container.
+	NoNewPrivileges bool
+}
+
+// Mount describes a host path a provider should bind-mount into the
+// environment at Path, for large datasets or model weights a task needs
+// without baking them into the image or copying them into every trial.
+type Mount struct {
+	HostPath string
+	Path     string
+	ReadOnly bool
+}
+
+// Cache describes a named volume a provider should mount into the
+// environment at Path, shared across every trial (and job) that names the
+// same Name, so repeated dependency downloads in install phases hit a warm
+// cache instead of re-fetching from the network each time.
+type Cache struct {
+	Name string
+	Path string
 }
 
 // CreateEnvironmentOptions configures environment creation.
@@ -71,6 +267,79 @@ type CreateEnvironmentOptions struct {
 	CPUs      int
 	MemoryMB  int
 	StorageMB int
-	Env       map[string]string
-	Config    map[string]any
+	// GPUCount requests this many GPUs for the environment. Zero requests
+	// none. Providers without GPU support ignore it.
+	GPUCount int
+	// GPUType selects a GPU model (e.g. "A100", "T4"), for providers that
+	// can choose among multiple types. Empty lets the provider pick its
+	// default when GPUCount > 0.
+	GPUType string
+	// Network is one of "full" (the default when empty), "none", or
+	// "restricted", controlling the environment's outbound network access.
+	// "restricted" allows only a provider-defined egress allowlist, read
+	// from Config by providers that support one; providers without an
+	// allowlist mechanism log a warning and fall back to "full".
+	Network string
+	// NetworkShaping applies artificial latency/bandwidth constraints to the
+	// environment's network interface. Currently only honored by the docker
+	// provider (via tc); other providers ignore it.
+	NetworkShaping NetworkShaping
+	// Services are sidecar containers to start alongside the main
+	// container on a shared network before the agent phase begins.
+	// Currently only honored by the docker provider; other providers
+	// ignore it.
+	Services []Service
+	Env      map[string]string
+	Config   map[string]any
+	// Timeout is the trial's aggregate phase timeout (install + agent
+	// execution + verification, plus a margin), for providers whose
+	// environments enforce their own hard lifetime independent of the
+	// caller (e.g. Modal sandboxes). Providers that don't need one, like
+	// docker, ignore it. Zero means the caller didn't compute one; the
+	// provider should fall back to its own default.
+	Timeout time.Duration
+	// AuditLogger, if set, records this environment's lifecycle
+	// (container create, exec, copy, destroy) into the job's audit.jsonl.
+	// Currently only honored by the docker provider; other providers
+	// ignore it.
+	AuditLogger *audit.Logger
+	// MaxLogMB caps the size of /logs inside the environment, so an agent
+	// that writes an enormous log file fills that quota instead of the
+	// sandbox's root disk. Zero disables the cap. Currently only honored
+	// by the docker provider (mounted as a size-limited tmpfs); other
+	// providers ignore it and log a warning.
+	MaxLogMB int
+	// Caches are named volumes to mount into the environment, shared across
+	// every trial (and job) that names the same cache. Currently only
+	// honored by the docker provider (as Docker named volumes); other
+	// providers ignore it and log a warning.
+	Caches []Cache
+	// Constraints restricts the region/architecture/price a provider may
+	// place this environment under. See Constraints' doc comment for its
+	// fail-fast semantics.
+	Constraints Constraints
+	// Mounts are host paths to bind-mount into the environment. Currently
+	// only honored by the docker provider; other providers ignore it and
+	// log a warning.
+	Mounts []Mount
+	// Security hardens the environment beyond its provider's defaults. See
+	// Security's doc comment.
+	Security Security
+	// PidsLimit caps the number of processes/threads the environment can
+	// have live at once. Zero means unset (the provider's own default).
+	// Currently only honored by the docker provider; other providers
+	// ignore it and log a warning.
+	PidsLimit int
+	// Ulimits sets POSIX resource limits inside the environment. Currently
+	// only honored by the docker provider; other providers ignore it and
+	// log a warning.
+	Ulimits []Ulimit
+}
+
+// Ulimit is a single POSIX resource limit to set inside an environment,
+// named the same as docker's --ulimit (e.g. "nofile", "nproc", "memlock").
+type Ulimit struct {
+	Name string
+	Soft int64
+	Hard int64
 }
@@ -0,0 +1,399 @@
+// Package qemu implements the "qemu" environment provider, which boots a
+// full virtual machine per trial with libvirt/QEMU instead of a container,
+// for tasks that need kernel modules, nested virtualization, or a
+// non-Linux guest a Docker container can't provide. Like the k8s and ssh
+// providers, it shells out to the host's CLI tooling (virsh, virt-install,
+// qemu-img, cloud-localds) rather than linking libvirt's client library.
+// Exec, CopyTo, and CopyFrom all go over SSH into the guest, the same way
+// the ssh provider reaches its remote Docker host.
+package qemu
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spachava753/rollout/internal/environment"
+)
+
+// ProviderConfig holds qemu/libvirt-specific configuration.
+type ProviderConfig struct {
+	// Connect is the libvirt connection URI, e.g. "qemu:///system". Defaults
+	// to "qemu:///system" when empty.
+	Connect string
+	// Pool is the libvirt storage pool backing each VM's overlay disk.
+	// Defaults to "default" when empty.
+	Pool string
+	// Network is the libvirt network each VM's NIC is attached to. Defaults
+	// to "default" when empty.
+	Network string
+	// SSHUser is the user cloud-init's seed image configures and Exec/
+	// CopyTo/CopyFrom connect as. Defaults to "root" when empty.
+	SSHUser string
+	// SSHKeyPath is the private key used to connect; its matching public
+	// key (SSHKeyPath + ".pub") is injected into the guest via cloud-init.
+	// Required: there is no password fallback.
+	SSHKeyPath string
+}
+
+// ParseProviderConfig extracts qemu-specific config from the generic config map.
+func ParseProviderConfig(config map[string]any) ProviderConfig {
+	var pc ProviderConfig
+	if config == nil {
+		return pc
+	}
+	if v, ok := config["connect"].(string); ok {
+		pc.Connect = v
+	}
+	if v, ok := config["pool"].(string); ok {
+		pc.Pool = v
+	}
+	if v, ok := config["network"].(string); ok {
+		pc.Network = v
+	}
+	if v, ok := config["ssh_user"].(string); ok {
+		pc.SSHUser = v
+	}
+	if v, ok := config["ssh_key_path"].(string); ok {
+		pc.SSHKeyPath = v
+	}
+	return pc
+}
+
+// Provider implements the qemu/libvirt environment provider.
+type Provider struct {
+	config ProviderConfig
+}
+
+// NewProvider creates a new qemu provider.
+func NewProvider(config ProviderConfig) *Provider {
+	if config.Connect == "" {
+		config.Connect = "qemu:///system"
+	}
+	if config.Pool == "" {
+		config.Pool = "default"
+	}
+	if config.Network == "" {
+		config.Network = "default"
+	}
+	if config.SSHUser == "" {
+		config.SSHUser = "root"
+	}
+	return &Provider{config: config}
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "qemu"
+}
+
+// virsh runs a virsh subcommand against the configured libvirt connection.
+func (p *Provider) virsh(ctx context.Context, args ...string) *exec.Cmd {
+	fullArgs := append([]string{"--connect", p.config.Connect}, args...)
+	return exec.CommandContext(ctx, "virsh", fullArgs...)
+}
+
+// BuildImage is not supported by the qemu provider: VM base images are qcow2
+// disk images (built with packer, virt-builder, or a cloud distro's own
+// cloud image), not a Dockerfile. Build or download a qcow2 base image
+// ahead of time and reference its path with task.toml's docker_image.
+func (p *Provider) BuildImage(ctx context.Context, opts environment.BuildImageOptions) (string, error) {
+	return "", fmt.Errorf("qemu provider does not support building images from a Dockerfile; point docker_image at a qcow2 base image path instead of building %s", opts.Tag)
+}
+
+// PullImage verifies imageRef (a path to a qcow2 base image) is present on
+// disk. There is no registry to pull from; the base image must already
+// exist at that path before the job runs.
+func (p *Provider) PullImage(ctx context.Context, imageRef string) error {
+	if _, err := os.Stat(imageRef); err != nil {
+		return fmt.Errorf("base image %q not found: %w", imageRef, err)
+	}
+	return nil
+}
+
+// CreateEnvironment boots a new VM from a copy-on-write overlay of the base
+// qcow2 image, seeded with a cloud-init ISO that authorizes the provider's
+// configured SSH key, then waits for the guest to report an IP address.
+func (p *Provider) CreateEnvironment(ctx context.Context, opts environment.CreateEnvironmentOptions) (environment.Environment, error) {
+	if p.config.SSHKeyPath == "" {
+		return nil, fmt.Errorf("qemu provider requires provider_config.ssh_key_path")
+	}
+
+	domain := opts.Name
+	if domain == "" {
+		domain = fmt.Sprintf("rollout-%d", time.Now().UnixNano())
+	}
+
+	workDir := filepath.Join(os.TempDir(), "rollout-qemu-"+domain)
+	if err := os.MkdirAll(workDir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating work dir: %w", err)
+	}
+
+	overlayPath := filepath.Join(workDir, "overlay.qcow2")
+	if err := runCmd(ctx, "qemu-img", "create", "-f", "qcow2", "-b", opts.ImageRef, "-F", "qcow2", overlayPath); err != nil {
+		return nil, fmt.Errorf("creating overlay disk: %w", err)
+	}
+
+	seedPath, err := writeSeedImage(ctx, workDir, domain, p.config.SSHUser, p.config.SSHKeyPath+".pub", opts.Env)
+	if err != nil {
+		return nil, fmt.Errorf("preparing cloud-init seed: %w", err)
+	}
+
+	args := []string{
+		"--connect", p.config.Connect,
+		"--name", domain,
+		"--memory", strconv.Itoa(maxInt(opts.MemoryMB, 512)),
+		"--vcpus", strconv.Itoa(maxInt(opts.CPUs, 1)),
+		"--disk", "path=" + overlayPath + ",bus=virtio",
+		"--disk", "path=" + seedPath + ",device=cdrom",
+		"--network", "network=" + p.config.Network,
+		"--import",
+		"--os-variant", "generic",
+		"--graphics", "none",
+		"--noautoconsole",
+	}
+	slog.Debug("creating qemu VM", "name", domain, "image", opts.ImageRef)
+	if err := runCmd(ctx, "virt-install", args...); err != nil {
+		return nil, fmt.Errorf("defining VM: %w", err)
+	}
+
+	ip, err := waitForIP(ctx, p, domain)
+	if err != nil {
+		return nil, fmt.Errorf("waiting for VM IP: %w", err)
+	}
+
+	return &Environment{provider: p, domain: domain, ip: ip, workDir: workDir}, nil
+}
+
+// cloudInitEnvLine returns a single cloud-init runcmd entry that appends
+// "export k=v" to the guest's rollout-env.sh profile script. v is
+// shell-quoted twice over: once for the export line written into the
+// profile script, and again for the echo argument cloud-init's own shell
+// runs it through, so a value containing shell metacharacters can't
+// inject extra commands at either layer.
+func cloudInitEnvLine(k, v string) string {
+	line := fmt.Sprintf("export %s=%s", k, environment.ShellQuote(v))
+	return fmt.Sprintf("  - echo %s >> /etc/profile.d/rollout-env.sh\n", environment.ShellQuote(line))
+}
+
+// writeSeedImage writes a cloud-init NoCloud user-data/meta-data pair
+// authorizing pubKeyPath and exporting env, then packs them into an ISO
+// with cloud-localds.
+func writeSeedImage(ctx context.Context, workDir, domain, sshUser, pubKeyPath string, env map[string]string) (string, error) {
+	pubKey, err := os.ReadFile(pubKeyPath)
+	if err != nil {
+		return "", fmt.Errorf("reading ssh public key: %w", err)
+	}
+
+	var envLines strings.Builder
+	for k, v := range env {
+		envLines.WriteString(cloudInitEnvLine(k, v))
+	}
+
+	userData := fmt.Sprintf(`#cloud-config
+users:
+  - name: %s
+    ssh_authorized_keys:
+      - %s
+    sudo: ALL=(ALL) NOPASSWD:ALL
+    shell: /bin/bash
+runcmd:
+%s`, sshUser, strings.TrimSpace(string(pubKey)), envLines.String())
+
+	metaData := fmt.Sprintf("instance-id: %s\nlocal-hostname: %s\n", domain, domain)
+
+	userDataPath := filepath.Join(workDir, "user-data")
+	metaDataPath := filepath.Join(workDir, "meta-data")
+	if err := os.WriteFile(userDataPath, []byte(userData), 0o644); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(metaDataPath, []byte(metaData), 0o644); err != nil {
+		return "", err
+	}
+
+	seedPath := filepath.Join(workDir, "seed.iso")
+	if err := runCmd(ctx, "cloud-localds", seedPath, userDataPath, metaDataPath); err != nil {
+		return "", fmt.Errorf("building seed iso: %w", err)
+	}
+	return seedPath, nil
+}
+
+// waitForIP polls `virsh domifaddr` until the VM reports a DHCP lease or ctx
+// is done.
+func waitForIP(ctx context.Context, p *Provider, domain string) (string, error) {
+	deadline := time.Now().Add(2 * time.Minute)
+	for time.Now().Before(deadline) {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		var stdout bytes.Buffer
+		cmd := p.virsh(ctx, "domifaddr", domain)
+		cmd.Stdout = &stdout
+		if err := cmd.Run(); err == nil {
+			if ip := parseDomifaddr(stdout.String()); ip != "" {
+				return ip, nil
+			}
+		}
+		time.Sleep(2 * time.Second)
+	}
+	return "", fmt.Errorf("timed out waiting for %s to report an IP address", domain)
+}
+
+// parseDomifaddr extracts the first IPv4 address from `virsh domifaddr`
+// output, stripping its CIDR suffix.
+func parseDomifaddr(out string) string {
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[2] != "ipv4" {
+			continue
+		}
+		addr := fields[3]
+		if idx := strings.Index(addr, "/"); idx >= 0 {
+			addr = addr[:idx]
+		}
+		if addr != "" {
+			return addr
+		}
+	}
+	return ""
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func runCmd(ctx context.Context, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// Environment represents a running qemu/libvirt VM.
+type Environment struct {
+	provider *Provider
+	domain   string
+	ip       string
+	workDir  string
+}
+
+// ID returns the libvirt domain name.
+func (e *Environment) ID() string {
+	return e.domain
+}
+
+func (e *Environment) target() string {
+	return e.provider.config.SSHUser + "@" + e.ip
+}
+
+func (e *Environment) sshFlags() []string {
+	return []string{
+		"-i", e.provider.config.SSHKeyPath,
+		"-o", "StrictHostKeyChecking=no",
+		"-o", "UserKnownHostsFile=/dev/null",
+	}
+}
+
+// CopyTo copies a local file or directory into the VM over scp.
+func (e *Environment) CopyTo(ctx context.Context, src, dst string) error {
+	slog.Debug("copying into qemu VM", "domain", e.domain, "src", src, "dst", dst)
+
+	args := append(append([]string{}, e.sshFlags()...), "-r", src, e.target()+":"+dst)
+	return runCmd(ctx, "scp", args...)
+}
+
+// CopyFrom copies a file or directory from the VM to a local path over scp.
+func (e *Environment) CopyFrom(ctx context.Context, src, dst string) error {
+	slog.Debug("copying from qemu VM", "domain", e.domain, "src", src, "dst", dst)
+
+	args := append(append([]string{}, e.sshFlags()...), "-r", e.target()+":"+src, dst)
+	return runCmd(ctx, "scp", args...)
+}
+
+// buildShellCommand assembles the "export ...; cd ...; cmd" one-liner bash
+// runs, shell-quoting opts.Env and opts.WorkDir so a value containing
+// shell metacharacters can't inject extra commands.
+func buildShellCommand(cmd string, opts environment.ExecOptions) string {
+	var sb strings.Builder
+	for k, v := range opts.Env {
+		fmt.Fprintf(&sb, "export %s=%s; ", k, environment.ShellQuote(v))
+	}
+	if opts.WorkDir != "" {
+		fmt.Fprintf(&sb, "cd %s; ", environment.ShellQuote(opts.WorkDir))
+	}
+	sb.WriteString(cmd)
+	return sb.String()
+}
+
+// Exec runs a command inside the VM over ssh.
+func (e *Environment) Exec(ctx context.Context, cmd string, stdout, stderr io.Writer, opts environment.ExecOptions) (int, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	args := append(append([]string{}, e.sshFlags()...), e.target(), "bash", "-c", buildShellCommand(cmd, opts))
+	execCmd := exec.CommandContext(ctx, "ssh", args...)
+	execCmd.Stdout = stdout
+	execCmd.Stderr = stderr
+
+	err := execCmd.Run()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode(), nil
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return -1, fmt.Errorf("command timed out")
+		}
+		return -1, fmt.Errorf("executing command: %w", err)
+	}
+	return 0, nil
+}
+
+// Stop gracefully shuts down the VM but does not remove it.
+func (e *Environment) Stop(ctx context.Context) error {
+	slog.Debug("stopping qemu VM", "domain", e.domain)
+
+	if err := e.provider.virsh(ctx, "shutdown", e.domain).Run(); err != nil {
+		return fmt.Errorf("stopping VM: %w", err)
+	}
+	return nil
+}
+
+// Destroy force-stops the VM, undefines it along with its storage, and
+// removes the local overlay/seed work directory.
+func (e *Environment) Destroy(ctx context.Context) error {
+	slog.Debug("destroying qemu VM", "domain", e.domain)
+
+	_ = e.provider.virsh(ctx, "destroy", e.domain).Run()
+	if err := e.provider.virsh(ctx, "undefine", e.domain, "--remove-all-storage").Run(); err != nil {
+		return fmt.Errorf("undefining VM: %w", err)
+	}
+	if err := os.RemoveAll(e.workDir); err != nil {
+		slog.Warn("failed to clean up qemu work dir", "dir", e.workDir, "error", err)
+	}
+	return nil
+}
+
+// Cost always returns 0: the qemu provider runs against a hypervisor host
+// the user already owns and operates, with no metered billing rollout can
+// observe.
+func (e *Environment) Cost() float64 {
+	return 0
+}
@@ -0,0 +1,55 @@
+package qemu
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/spachava753/rollout/internal/environment"
+)
+
+// TestBuildShellCommandNeutralizesShellMetacharacters guards against a
+// regression to fmt's %q, which escapes Go string syntax but leaves shell
+// metacharacters like $(...) live: a value containing one must come out
+// the other side of a real shell unexecuted.
+func TestBuildShellCommandNeutralizesShellMetacharacters(t *testing.T) {
+	pwned := t.TempDir() + "/pwned"
+	opts := environment.ExecOptions{
+		WorkDir: t.TempDir(),
+		Env:     map[string]string{"MYVAR": "pass$(touch " + pwned + ")word"},
+	}
+
+	shellCmd := buildShellCommand("echo ran", opts)
+
+	out, err := exec.Command("bash", "-c", shellCmd).Output()
+	if err != nil {
+		t.Fatalf("running built command: %v", err)
+	}
+	if string(out) != "ran\n" {
+		t.Fatalf("expected command output %q, got %q", "ran\n", out)
+	}
+	if _, err := exec.Command("test", "-e", pwned).Output(); err == nil {
+		t.Fatal("command substitution in env value was executed by the shell")
+	}
+}
+
+// TestCloudInitEnvLineNeutralizesShellMetacharacters exercises the other
+// shell-interpreted site in this provider, the cloud-init runcmd line
+// written into the seed image, the same way: a value containing shell
+// metacharacters must survive both the echo's own shell and the profile
+// script it's appended to without executing anything extra.
+func TestCloudInitEnvLineNeutralizesShellMetacharacters(t *testing.T) {
+	pwned := t.TempDir() + "/pwned"
+	line := cloudInitEnvLine("MYVAR", "pass$(touch "+pwned+")word")
+
+	// Strip the leading "  - " YAML list marker to get the raw shell command
+	// cloud-init's runcmd would hand to /bin/sh -c.
+	shellCmd := strings.TrimPrefix(strings.TrimSuffix(line, "\n"), "  - ")
+
+	if _, err := exec.Command("sh", "-c", shellCmd).Output(); err != nil {
+		t.Fatalf("running cloud-init env line: %v", err)
+	}
+	if _, err := exec.Command("test", "-e", pwned).Output(); err == nil {
+		t.Fatal("command substitution in env value was executed by the shell")
+	}
+}
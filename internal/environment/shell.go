@@ -0,0 +1,16 @@
+package environment
+
+import "strings"
+
+// ShellQuote single-quotes s for safe interpolation into a POSIX shell
+// command line, escaping any embedded single quote by closing the quoted
+// string, emitting an escaped literal quote, then reopening it. Providers
+// that build a remote Exec command by string-formatting env vars or paths
+// into a shell one-liner (rather than passing them through the remote
+// CLI's own exec flags) must quote with this, not fmt's %q: %q only
+// escapes Go string syntax, so $, backticks, and ! inside the quotes it
+// produces are still live to the shell - e.g. a value of
+// "pass$(touch /tmp/pwned)" would have the command substitution execute.
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,36 @@
+package environment
+
+import (
+	"os/exec"
+	"testing"
+)
+
+// TestShellQuoteRoundTripsThroughAShell feeds ShellQuote's output through a
+// real shell and checks the value comes back unchanged, the same
+// correctness bar a shell-quoting helper actually needs to clear.
+func TestShellQuoteRoundTripsThroughAShell(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"command substitution", "pass$(touch /tmp/pwned)word"},
+		{"backticks", "pass`touch /tmp/pwned`word"},
+		{"variable expansion", "$HOME/secret"},
+		{"history expansion", "loud!bang"},
+		{"embedded single quote", "it's a secret"},
+		{"double quotes", `say "hi"`},
+		{"empty string", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := exec.Command("sh", "-c", "printf '%s' "+ShellQuote(tt.input)).Output()
+			if err != nil {
+				t.Fatalf("running quoted value through sh: %v", err)
+			}
+			if string(out) != tt.input {
+				t.Errorf("ShellQuote(%q) round-tripped to %q", tt.input, out)
+			}
+		})
+	}
+}
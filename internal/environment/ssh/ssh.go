@@ -0,0 +1,319 @@
+// Package ssh implements the "ssh" environment provider, which runs trials
+// against a Docker daemon on a remote Linux host reachable over SSH. It lets
+// users point rollout at a beefy build server without installing anything
+// on it but sshd and Docker: every docker subcommand this provider would
+// otherwise run locally (see the docker provider) is instead run remotely
+// via `ssh`, and CopyTo/CopyFrom stage files through the remote host with
+// scp before handing them to `docker cp`.
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spachava753/rollout/internal/environment"
+)
+
+// ProviderConfig holds SSH-specific configuration.
+type ProviderConfig struct {
+	// Host is the remote host's address (hostname or IP).
+	Host string
+	// User is the SSH user to connect as. If empty, ssh falls back to its
+	// own default resolution (current user, ~/.ssh/config).
+	User string
+	// Port is the SSH port. Defaults to 22 when zero.
+	Port int
+	// IdentityFile is the private key passed to ssh/scp via -i. If empty,
+	// ssh's own key discovery (agent, ~/.ssh/config) is used.
+	IdentityFile string
+}
+
+// ParseProviderConfig extracts SSH-specific config from the generic config map.
+func ParseProviderConfig(config map[string]any) ProviderConfig {
+	var pc ProviderConfig
+	if config == nil {
+		return pc
+	}
+	if v, ok := config["host"].(string); ok {
+		pc.Host = v
+	}
+	if v, ok := config["user"].(string); ok {
+		pc.User = v
+	}
+	if v, ok := config["port"].(int); ok {
+		pc.Port = v
+	} else if v, ok := config["port"].(float64); ok {
+		pc.Port = int(v)
+	}
+	if v, ok := config["identity_file"].(string); ok {
+		pc.IdentityFile = v
+	}
+	return pc
+}
+
+// Provider implements the SSH environment provider.
+type Provider struct {
+	config ProviderConfig
+}
+
+// NewProvider creates a new SSH provider.
+func NewProvider(config ProviderConfig) *Provider {
+	return &Provider{config: config}
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "ssh"
+}
+
+// target returns the "user@host" (or just "host") ssh/scp connects to.
+func (p *Provider) target() string {
+	if p.config.User != "" {
+		return p.config.User + "@" + p.config.Host
+	}
+	return p.config.Host
+}
+
+func (p *Provider) sshFlags() []string {
+	var flags []string
+	if p.config.IdentityFile != "" {
+		flags = append(flags, "-i", p.config.IdentityFile)
+	}
+	if p.config.Port != 0 {
+		flags = append(flags, "-p", strconv.Itoa(p.config.Port))
+	}
+	return flags
+}
+
+func (p *Provider) scpFlags() []string {
+	var flags []string
+	if p.config.IdentityFile != "" {
+		flags = append(flags, "-i", p.config.IdentityFile)
+	}
+	if p.config.Port != 0 {
+		// scp uses -P (capital) for port, unlike ssh's -p.
+		flags = append(flags, "-P", strconv.Itoa(p.config.Port))
+	}
+	return flags
+}
+
+// remoteDocker runs a docker subcommand on the remote host, analogous to
+// exec.Command("docker", args...) in the docker provider but over ssh.
+func (p *Provider) remoteDocker(ctx context.Context, args ...string) *exec.Cmd {
+	remoteCmd := "docker " + shellJoin(args)
+	sshArgs := append(p.sshFlags(), p.target(), remoteCmd)
+	return exec.CommandContext(ctx, "ssh", sshArgs...)
+}
+
+// shellJoin quotes each argument for safe inclusion in the remote shell
+// command ssh passes to the remote host's shell.
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = environment.ShellQuote(a)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// BuildImage is not supported by the SSH provider: the build context lives
+// on the local machine, not the remote Docker host. Build locally with the
+// docker provider (or your own pipeline), push to a registry, and reference
+// it with task.toml's docker_image instead.
+func (p *Provider) BuildImage(ctx context.Context, opts environment.BuildImageOptions) (string, error) {
+	return "", fmt.Errorf("ssh provider does not support building images; build %s locally and push it, then use docker_image", opts.Tag)
+}
+
+// PullImage pulls a pre-built image on the remote Docker daemon.
+func (p *Provider) PullImage(ctx context.Context, imageRef string) error {
+	slog.Debug("pulling docker image on remote host", "host", p.config.Host, "image", imageRef)
+
+	cmd := p.remoteDocker(ctx, "pull", imageRef)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("pulling image on remote host: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// CreateEnvironment creates and starts a Docker container on the remote host.
+func (p *Provider) CreateEnvironment(ctx context.Context, opts environment.CreateEnvironmentOptions) (environment.Environment, error) {
+	containerID := opts.Name
+	if containerID == "" {
+		containerID = fmt.Sprintf("rollout-%d", time.Now().UnixNano())
+	}
+
+	args := []string{"run", "-d", "--name", containerID}
+	if opts.CPUs > 0 {
+		args = append(args, "--cpus", strconv.Itoa(opts.CPUs))
+	}
+	if opts.MemoryMB > 0 {
+		args = append(args, "--memory", fmt.Sprintf("%dm", opts.MemoryMB))
+	}
+	for k, v := range opts.Env {
+		args = append(args, "-e", fmt.Sprintf("%s=%s", k, v))
+	}
+	args = append(args, opts.ImageRef, "sleep", "infinity")
+
+	slog.Debug("creating docker container on remote host",
+		"host", p.config.Host,
+		"name", containerID,
+		"image", opts.ImageRef)
+
+	cmd := p.remoteDocker(ctx, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("creating container on remote host: %w: %s", err, stderr.String())
+	}
+
+	return &Environment{provider: p, containerID: containerID}, nil
+}
+
+// Environment represents a running Docker container on the remote host.
+type Environment struct {
+	provider    *Provider
+	containerID string
+}
+
+// ID returns the container ID.
+func (e *Environment) ID() string {
+	return e.containerID
+}
+
+// remoteTempPath returns a unique path under /tmp on the remote host to
+// stage a CopyTo/CopyFrom transfer through.
+func remoteTempPath() string {
+	return fmt.Sprintf("/tmp/rollout-cp-%d", time.Now().UnixNano())
+}
+
+// CopyTo copies a local file or directory into the container. The file is
+// staged onto the remote host via scp, then moved into the container with
+// `docker cp`, since docker cp can only read from paths on the Docker host.
+func (e *Environment) CopyTo(ctx context.Context, src, dst string) error {
+	tmp := remoteTempPath()
+
+	slog.Debug("staging file to remote host", "host", e.provider.config.Host, "src", src, "remote_tmp", tmp)
+	scpArgs := append(e.provider.scpFlags(), "-r", src, e.provider.target()+":"+tmp)
+	scpCmd := exec.CommandContext(ctx, "scp", scpArgs...)
+	var stderr bytes.Buffer
+	scpCmd.Stderr = &stderr
+	if err := scpCmd.Run(); err != nil {
+		return fmt.Errorf("staging file to remote host: %w: %s", err, stderr.String())
+	}
+
+	cpCmd := e.provider.remoteDocker(ctx, "cp", tmp, e.containerID+":"+dst)
+	stderr.Reset()
+	cpCmd.Stderr = &stderr
+	if err := cpCmd.Run(); err != nil {
+		return fmt.Errorf("copying staged file into container: %w: %s", err, stderr.String())
+	}
+
+	e.removeRemoteTemp(ctx, tmp)
+	return nil
+}
+
+// CopyFrom copies a file or directory from the container to a local path,
+// staging it through the remote host's filesystem with `docker cp` and then
+// fetching it locally with scp.
+func (e *Environment) CopyFrom(ctx context.Context, src, dst string) error {
+	tmp := remoteTempPath()
+
+	cpCmd := e.provider.remoteDocker(ctx, "cp", e.containerID+":"+src, tmp)
+	var stderr bytes.Buffer
+	cpCmd.Stderr = &stderr
+	if err := cpCmd.Run(); err != nil {
+		return fmt.Errorf("copying file out of container: %w: %s", err, stderr.String())
+	}
+
+	slog.Debug("fetching staged file from remote host", "host", e.provider.config.Host, "remote_tmp", tmp, "dst", dst)
+	scpArgs := append(e.provider.scpFlags(), "-r", e.provider.target()+":"+tmp, dst)
+	scpCmd := exec.CommandContext(ctx, "scp", scpArgs...)
+	stderr.Reset()
+	scpCmd.Stderr = &stderr
+	if err := scpCmd.Run(); err != nil {
+		return fmt.Errorf("fetching staged file from remote host: %w: %s", err, stderr.String())
+	}
+
+	e.removeRemoteTemp(ctx, tmp)
+	return nil
+}
+
+// removeRemoteTemp best-effort removes a staging path left on the remote
+// host by CopyTo/CopyFrom. Failures are logged and otherwise ignored: a
+// leftover temp file under /tmp should never fail an otherwise-successful copy.
+func (e *Environment) removeRemoteTemp(ctx context.Context, path string) {
+	sshArgs := append(e.provider.sshFlags(), e.provider.target(), fmt.Sprintf("rm -rf %q", path))
+	if err := exec.CommandContext(ctx, "ssh", sshArgs...).Run(); err != nil {
+		slog.Warn("failed to clean up remote staging path", "path", path, "error", err)
+	}
+}
+
+// Exec executes a command in the container via the remote Docker daemon.
+func (e *Environment) Exec(ctx context.Context, cmd string, stdout, stderr io.Writer, opts environment.ExecOptions) (int, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	args := []string{"exec"}
+	for k, v := range opts.Env {
+		args = append(args, "-e", fmt.Sprintf("%s=%s", k, v))
+	}
+	if opts.WorkDir != "" {
+		args = append(args, "-w", opts.WorkDir)
+	}
+	args = append(args, e.containerID, "bash", "-c", cmd)
+
+	execCmd := e.provider.remoteDocker(ctx, args...)
+	execCmd.Stdout = stdout
+	execCmd.Stderr = stderr
+
+	err := execCmd.Run()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode(), nil
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return -1, fmt.Errorf("command timed out")
+		}
+		return -1, fmt.Errorf("executing command: %w", err)
+	}
+	return 0, nil
+}
+
+// Stop stops the container but does not remove it.
+func (e *Environment) Stop(ctx context.Context) error {
+	slog.Debug("stopping docker container on remote host", "host", e.provider.config.Host, "container_id", e.containerID)
+
+	cmd := e.provider.remoteDocker(ctx, "stop", e.containerID)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("stopping container on remote host: %w", err)
+	}
+	return nil
+}
+
+// Destroy removes the container and cleans up resources.
+func (e *Environment) Destroy(ctx context.Context) error {
+	slog.Debug("destroying docker container on remote host", "host", e.provider.config.Host, "container_id", e.containerID)
+
+	cmd := e.provider.remoteDocker(ctx, "rm", "-f", e.containerID)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("removing container on remote host: %w", err)
+	}
+	return nil
+}
+
+// Cost always returns 0: the SSH provider runs against a host the user
+// already owns and operates, with no metered billing rollout can observe.
+func (e *Environment) Cost() float64 {
+	return 0
+}
@@ -0,0 +1,383 @@
+// Package vastai implements the "vastai" environment provider, which rents
+// a spot GPU instance per trial from the Vast.ai marketplace instead of
+// using a local Docker host. Like the fargate and k8s providers, it shells
+// out to a CLI (vastai) rather than linking a Go SDK.
+//
+// Spot instances can be outbid and reclaimed by Vast.ai at any time; this
+// provider reports that as a distinct models.ErrEnvironmentInterrupted
+// error (via environment.InterruptionDetector) rather than leaving it to
+// look like an ordinary agent or verifier failure.
+package vastai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spachava753/rollout/internal/environment"
+)
+
+// ProviderConfig holds Vast.ai-specific configuration.
+type ProviderConfig struct {
+	// APIKey authenticates with the Vast.ai API. Passed to the CLI via the
+	// VASTAI_API_KEY environment variable rather than a flag, so it never
+	// shows up in process listings.
+	APIKey string
+	// GPUFilter is a Vast.ai search offers query string (e.g.
+	// "gpu_name=RTX_4090 num_gpus=1") used to select candidate instances.
+	GPUFilter string
+	// BidPrice is the max $/hour bid for the instance, passed to `vastai
+	// create instance --bid`.
+	BidPrice float64
+	// DiskGB is the disk size requested for the instance. Defaults to 20
+	// when zero.
+	DiskGB int
+	// SSHKeyPath is the private key used to reach the instance once it's
+	// running. Its corresponding .pub key must already be registered with
+	// the Vast.ai account.
+	SSHKeyPath string
+}
+
+// ParseProviderConfig extracts Vast.ai-specific config from the generic config map.
+func ParseProviderConfig(config map[string]any) ProviderConfig {
+	var pc ProviderConfig
+	if config == nil {
+		return pc
+	}
+	if v, ok := config["api_key"].(string); ok {
+		pc.APIKey = v
+	}
+	if v, ok := config["gpu_filter"].(string); ok {
+		pc.GPUFilter = v
+	}
+	if v, ok := config["bid_price"].(float64); ok {
+		pc.BidPrice = v
+	}
+	if v, ok := config["disk_gb"].(int); ok {
+		pc.DiskGB = v
+	} else if v, ok := config["disk_gb"].(float64); ok {
+		pc.DiskGB = int(v)
+	}
+	if v, ok := config["ssh_key_path"].(string); ok {
+		pc.SSHKeyPath = v
+	}
+	return pc
+}
+
+// Provider implements the Vast.ai environment provider.
+type Provider struct {
+	config ProviderConfig
+}
+
+// NewProvider creates a new Vast.ai provider.
+func NewProvider(config ProviderConfig) *Provider {
+	return &Provider{config: config}
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "vastai"
+}
+
+func (p *Provider) vastai(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "vastai", args...)
+	cmd.Env = append(cmd.Environ(), "VASTAI_API_KEY="+p.config.APIKey)
+	return cmd
+}
+
+// BuildImage is not supported by the Vast.ai provider: instances are rented
+// already running a given Docker image, and there is no local Docker daemon
+// on the marketplace host to build against. Push the image to a registry
+// the instance can pull from and reference it with task.toml's docker_image
+// instead.
+func (p *Provider) BuildImage(ctx context.Context, opts environment.BuildImageOptions) (string, error) {
+	return "", fmt.Errorf("vastai provider does not support building images; push %s to a registry and use docker_image instead", opts.Tag)
+}
+
+// PullImage is a no-op: the rented instance pulls its own image when
+// created.
+func (p *Provider) PullImage(ctx context.Context, imageRef string) error {
+	slog.Debug("skipping explicit image pull, vastai will pull on instance creation", "image", imageRef)
+	return nil
+}
+
+// CreateEnvironment searches Vast.ai for the cheapest offer matching the
+// provider's GPU filter, rents it with the configured bid price running
+// opts.ImageRef, and waits for it to come up for SSH access.
+func (p *Provider) CreateEnvironment(ctx context.Context, opts environment.CreateEnvironmentOptions) (environment.Environment, error) {
+	offerID, err := p.cheapestOffer(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("searching offers: %w", err)
+	}
+
+	diskGB := p.config.DiskGB
+	if diskGB <= 0 {
+		diskGB = 20
+	}
+
+	args := []string{"create", "instance", offerID,
+		"--image", opts.ImageRef,
+		"--disk", strconv.Itoa(diskGB),
+		"--bid", strconv.FormatFloat(p.config.BidPrice, 'f', -1, 64),
+		"--ssh", "--raw",
+	}
+	for k, v := range opts.Env {
+		args = append(args, "--env", fmt.Sprintf("%s=%s", k, v))
+	}
+
+	slog.Debug("renting vastai instance", "offer", offerID, "image", opts.ImageRef, "bid", p.config.BidPrice)
+
+	cmd := p.vastai(ctx, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("creating instance: %w: %s", err, stderr.String())
+	}
+
+	var resp struct {
+		NewContract int `json:"new_contract"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil || resp.NewContract == 0 {
+		return nil, fmt.Errorf("parsing instance creation response: %w", err)
+	}
+	instanceID := strconv.Itoa(resp.NewContract)
+
+	host, port, err := p.waitForSSH(ctx, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("waiting for instance %s to come up: %w", instanceID, err)
+	}
+
+	slog.Debug("vastai instance running", "instance", instanceID, "host", host, "port", port)
+
+	return &Environment{
+		provider:   p,
+		instanceID: instanceID,
+		sshHost:    host,
+		sshPort:    port,
+		bidPrice:   p.config.BidPrice,
+		startTime:  time.Now(),
+	}, nil
+}
+
+// cheapestOffer returns the ID of the lowest $/hour offer matching the
+// provider's GPU filter, narrowed further by opts.GPUType/GPUCount when the
+// task itself requests a specific GPU model or count.
+func (p *Provider) cheapestOffer(ctx context.Context, opts environment.CreateEnvironmentOptions) (string, error) {
+	args := []string{"search", "offers", "-o", "dph_total", "--raw"}
+	if p.config.GPUFilter != "" {
+		args = append(args, strings.Fields(p.config.GPUFilter)...)
+	}
+	if opts.GPUType != "" {
+		args = append(args, fmt.Sprintf("gpu_name=%s", opts.GPUType))
+	}
+	if opts.GPUCount > 0 {
+		args = append(args, fmt.Sprintf("num_gpus=%d", opts.GPUCount))
+	}
+
+	cmd := p.vastai(ctx, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%w: %s", err, stderr.String())
+	}
+
+	var offers []struct {
+		ID int `json:"id"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &offers); err != nil {
+		return "", fmt.Errorf("parsing offers: %w", err)
+	}
+	if len(offers) == 0 {
+		return "", fmt.Errorf("no offers matched gpu_filter %q", p.config.GPUFilter)
+	}
+	return strconv.Itoa(offers[0].ID), nil
+}
+
+// instanceStatus mirrors the subset of `vastai show instance --raw`'s
+// response this provider needs.
+type instanceStatus struct {
+	ActualStatus string `json:"actual_status"`
+	SSHHost      string `json:"ssh_host"`
+	SSHPort      int    `json:"ssh_port"`
+}
+
+func (p *Provider) showInstance(ctx context.Context, instanceID string) (instanceStatus, error) {
+	cmd := p.vastai(ctx, "show", "instance", instanceID, "--raw")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return instanceStatus{}, fmt.Errorf("%w: %s", err, stderr.String())
+	}
+	var status instanceStatus
+	if err := json.Unmarshal(stdout.Bytes(), &status); err != nil {
+		return instanceStatus{}, fmt.Errorf("parsing instance status: %w", err)
+	}
+	return status, nil
+}
+
+// waitForSSH polls the instance until it reports "running" with an assigned
+// SSH host/port, for up to 5 minutes.
+func (p *Provider) waitForSSH(ctx context.Context, instanceID string) (string, int, error) {
+	deadline := time.Now().Add(5 * time.Minute)
+	for time.Now().Before(deadline) {
+		status, err := p.showInstance(ctx, instanceID)
+		if err == nil && status.ActualStatus == "running" && status.SSHHost != "" && status.SSHPort != 0 {
+			return status.SSHHost, status.SSHPort, nil
+		}
+		select {
+		case <-ctx.Done():
+			return "", 0, ctx.Err()
+		case <-time.After(5 * time.Second):
+		}
+	}
+	return "", 0, fmt.Errorf("timed out waiting for instance to start")
+}
+
+// Environment represents a rented Vast.ai instance.
+type Environment struct {
+	provider   *Provider
+	instanceID string
+	sshHost    string
+	sshPort    int
+	bidPrice   float64
+	startTime  time.Time
+}
+
+// ID returns the instance ID (Vast.ai's contract ID).
+func (e *Environment) ID() string {
+	return e.instanceID
+}
+
+func (e *Environment) sshFlags() []string {
+	return []string{
+		"-i", e.provider.config.SSHKeyPath,
+		"-p", strconv.Itoa(e.sshPort),
+		"-o", "StrictHostKeyChecking=no",
+		"-o", "UserKnownHostsFile=/dev/null",
+	}
+}
+
+func (e *Environment) target() string {
+	return "root@" + e.sshHost
+}
+
+// CopyTo copies a local file or directory into the instance over scp.
+func (e *Environment) CopyTo(ctx context.Context, src, dst string) error {
+	slog.Debug("copying into vastai instance", "instance", e.instanceID, "src", src, "dst", dst)
+
+	args := append(append([]string{}, e.sshFlags()...), "-r", src, e.target()+":"+dst)
+	return runCmd(ctx, "scp", args...)
+}
+
+// CopyFrom copies a file or directory from the instance to a local path over scp.
+func (e *Environment) CopyFrom(ctx context.Context, src, dst string) error {
+	slog.Debug("copying from vastai instance", "instance", e.instanceID, "src", src, "dst", dst)
+
+	args := append(append([]string{}, e.sshFlags()...), "-r", e.target()+":"+src, dst)
+	return runCmd(ctx, "scp", args...)
+}
+
+// buildShellCommand assembles the "export ...; cd ...; cmd" one-liner bash
+// runs, shell-quoting opts.Env and opts.WorkDir so a value containing
+// shell metacharacters can't inject extra commands.
+func buildShellCommand(cmd string, opts environment.ExecOptions) string {
+	var sb strings.Builder
+	for k, v := range opts.Env {
+		fmt.Fprintf(&sb, "export %s=%s; ", k, environment.ShellQuote(v))
+	}
+	if opts.WorkDir != "" {
+		fmt.Fprintf(&sb, "cd %s; ", environment.ShellQuote(opts.WorkDir))
+	}
+	sb.WriteString(cmd)
+	return sb.String()
+}
+
+// Exec runs a command inside the instance over ssh.
+func (e *Environment) Exec(ctx context.Context, cmd string, stdout, stderr io.Writer, opts environment.ExecOptions) (int, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	args := append(append([]string{}, e.sshFlags()...), e.target(), "bash", "-c", buildShellCommand(cmd, opts))
+	execCmd := exec.CommandContext(ctx, "ssh", args...)
+	execCmd.Stdout = stdout
+	execCmd.Stderr = stderr
+
+	err := execCmd.Run()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode(), nil
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return -1, fmt.Errorf("command timed out")
+		}
+		return -1, fmt.Errorf("executing command: %w", err)
+	}
+	return 0, nil
+}
+
+// Stop is not supported by the Vast.ai provider: rented instances bill for
+// the whole rental regardless of running state, so there is no cheaper
+// "stopped" state worth pausing into. Use Destroy to end the rental.
+func (e *Environment) Stop(ctx context.Context) error {
+	return fmt.Errorf("vastai provider does not support stop; use destroy to end the rental")
+}
+
+// Destroy ends the instance rental.
+func (e *Environment) Destroy(ctx context.Context) error {
+	slog.Debug("destroying vastai instance", "instance", e.instanceID)
+
+	cmd := e.provider.vastai(ctx, "destroy", "instance", e.instanceID, "--raw")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("destroying instance: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// Cost estimates spend so far as elapsed hours times the bid price that was
+// actually used to rent the instance.
+func (e *Environment) Cost() float64 {
+	return time.Since(e.startTime).Hours() * e.bidPrice
+}
+
+// WasInterrupted implements environment.InterruptionDetector, reporting
+// whether the instance was reclaimed (outbid, or the host evicted it)
+// rather than still running normally.
+func (e *Environment) WasInterrupted(ctx context.Context) (bool, error) {
+	status, err := e.provider.showInstance(ctx, e.instanceID)
+	if err != nil {
+		// An instance that can no longer be queried has most likely been
+		// reclaimed out from under us.
+		return true, nil
+	}
+	switch status.ActualStatus {
+	case "running", "loading":
+		return false, nil
+	default:
+		return true, nil
+	}
+}
+
+func runCmd(ctx context.Context, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s", err, stderr.String())
+	}
+	return nil
+}
@@ -0,0 +1,397 @@
+// Package wsl implements the "wsl" environment provider, which runs each
+// trial inside a throwaway WSL2 distro imported from a rootfs tarball,
+// for Windows hosts that want to run rollout natively without a Docker
+// Desktop license. It shells out to wsl.exe, the same CLI a user would run
+// by hand, rather than any Windows-specific client library - the same
+// approach most other providers take with their own platform's CLI.
+//
+// WSL2 has no notion of pulling a container image directly: `wsl --import`
+// only understands a flat rootfs tarball. So this provider still builds
+// from the task's Dockerfile with the Docker CLI and exports the result
+// with `docker export`, then throws the image itself away - only the
+// exported tarball is imported into WSL. That still needs some docker
+// binary on PATH during the build step, but not Docker Desktop: the Docker
+// Engine alone (e.g. running rootless, or inside a build-only WSL distro)
+// is enough, and the distro the trial actually runs in never touches
+// Docker at all.
+package wsl
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spachava753/rollout/internal/environment"
+)
+
+// ProviderConfig holds wsl-specific configuration.
+type ProviderConfig struct {
+	// DistroPrefix names each imported distro "<prefix><id>". Defaults to
+	// "rollout-" when empty.
+	DistroPrefix string
+	// InstallDir is the base directory each trial's distro is imported
+	// into (wsl --import's install-location argument). Defaults to
+	// "<TEMP>\rollout-wsl" when empty.
+	InstallDir string
+	// User is the Linux user Exec runs commands as inside the distro.
+	// Defaults to "root" when empty.
+	User string
+}
+
+// ParseProviderConfig extracts wsl-specific config from the generic config map.
+func ParseProviderConfig(config map[string]any) ProviderConfig {
+	var pc ProviderConfig
+	if config == nil {
+		return pc
+	}
+	if v, ok := config["distro_prefix"].(string); ok {
+		pc.DistroPrefix = v
+	}
+	if v, ok := config["install_dir"].(string); ok {
+		pc.InstallDir = v
+	}
+	if v, ok := config["user"].(string); ok {
+		pc.User = v
+	}
+	return pc
+}
+
+// Provider implements the wsl environment provider.
+type Provider struct {
+	config ProviderConfig
+}
+
+// NewProvider creates a new wsl provider.
+func NewProvider(config ProviderConfig) *Provider {
+	return &Provider{config: config}
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "wsl"
+}
+
+func (p *Provider) installDir() string {
+	if p.config.InstallDir != "" {
+		return p.config.InstallDir
+	}
+	return filepath.Join(os.TempDir(), "rollout-wsl")
+}
+
+func (p *Provider) distroPrefix() string {
+	if p.config.DistroPrefix != "" {
+		return p.config.DistroPrefix
+	}
+	return "rollout-"
+}
+
+func (p *Provider) user() string {
+	if p.config.User != "" {
+		return p.config.User
+	}
+	return "root"
+}
+
+// BuildImage builds the task's Dockerfile with the Docker CLI, exports the
+// result to a rootfs tarball, and returns the tarball's path as the "image
+// reference" - CreateEnvironment imports it directly with `wsl --import`.
+func (p *Provider) BuildImage(ctx context.Context, opts environment.BuildImageOptions) (string, error) {
+	args := []string{"build", "-t", opts.Tag}
+	for _, k := range sortedKeys(opts.BuildArgs) {
+		args = append(args, "--build-arg", fmt.Sprintf("%s=%s", k, opts.BuildArgs[k]))
+	}
+	if opts.Target != "" {
+		args = append(args, "--target", opts.Target)
+	}
+	args = append(args, opts.ContextDir)
+	if err := runDocker(ctx, args...); err != nil {
+		return "", fmt.Errorf("building docker image: %w", err)
+	}
+
+	// Drop any export cached under this tag, so the image just built is
+	// exported rather than a tarball left over from an earlier build.
+	hash := sha256.Sum256([]byte(opts.Tag))
+	os.Remove(filepath.Join(tarballCacheDir(), hex.EncodeToString(hash[:])+".tar"))
+
+	tarPath, err := exportImageToTarball(ctx, opts.Tag)
+	if err != nil {
+		return "", fmt.Errorf("exporting image to rootfs tarball: %w", err)
+	}
+
+	slog.Debug("built wsl rootfs tarball", "tag", opts.Tag, "tarball", tarPath)
+	return tarPath, nil
+}
+
+// PullImage resolves a registry image reference into a cached rootfs
+// tarball ahead of CreateEnvironment, the same way other providers
+// pre-fetch into their own local store. A no-op if imageRef already is a
+// tarball path (e.g. one BuildImage returned).
+func (p *Provider) PullImage(ctx context.Context, imageRef string) error {
+	if isTarball(imageRef) {
+		slog.Debug("wsl image already a rootfs tarball, nothing to pull", "path", imageRef)
+		return nil
+	}
+
+	slog.Debug("pulling docker image for wsl rootfs export", "image", imageRef)
+	if err := runDocker(ctx, "pull", imageRef); err != nil {
+		return fmt.Errorf("pulling docker image: %w", err)
+	}
+	_, err := exportImageToTarball(ctx, imageRef)
+	return err
+}
+
+// tarballCacheDir holds rootfs tarballs exported from registry images, keyed
+// by image reference so repeated trials against the same image reuse one
+// export instead of paying docker export's cost every time.
+func tarballCacheDir() string {
+	return filepath.Join(os.TempDir(), "rollout-wsl-cache")
+}
+
+// isTarball reports whether ref already points at a rootfs tarball on disk
+// rather than a registry image reference.
+func isTarball(ref string) bool {
+	info, err := os.Stat(ref)
+	return err == nil && !info.IsDir()
+}
+
+// exportImageToTarball creates a throwaway container from imageRef, exports
+// its filesystem to a cached tarball, and removes the container, returning
+// the tarball's path.
+func exportImageToTarball(ctx context.Context, imageRef string) (string, error) {
+	if err := os.MkdirAll(tarballCacheDir(), 0755); err != nil {
+		return "", fmt.Errorf("creating tarball cache dir: %w", err)
+	}
+
+	hash := sha256.Sum256([]byte(imageRef))
+	tarPath := filepath.Join(tarballCacheDir(), hex.EncodeToString(hash[:])+".tar")
+	if isTarball(tarPath) {
+		return tarPath, nil
+	}
+
+	containerID := fmt.Sprintf("rollout-wsl-export-%d", time.Now().UnixNano())
+	if err := runDocker(ctx, "create", "--name", containerID, imageRef); err != nil {
+		return "", fmt.Errorf("creating export container: %w", err)
+	}
+	defer func() {
+		_ = exec.Command("docker", "rm", "-f", containerID).Run()
+	}()
+
+	if err := runDocker(ctx, "export", "-o", tarPath, containerID); err != nil {
+		return "", fmt.Errorf("exporting container filesystem: %w", err)
+	}
+	return tarPath, nil
+}
+
+// sortedKeys returns m's keys in sorted order, so --build-arg flags are
+// passed to docker build in a deterministic order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// runDocker runs a docker CLI command, returning its stderr on failure.
+func runDocker(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// CreateEnvironment imports a rootfs tarball as a new throwaway WSL2
+// distro.
+func (p *Provider) CreateEnvironment(ctx context.Context, opts environment.CreateEnvironmentOptions) (environment.Environment, error) {
+	if !isTarball(opts.ImageRef) {
+		return nil, fmt.Errorf("wsl provider needs a rootfs tarball, got %q: call BuildImage/PullImage first", opts.ImageRef)
+	}
+
+	distroName := opts.Name
+	if distroName == "" {
+		distroName = fmt.Sprintf("rollout-%d", time.Now().UnixNano())
+	}
+	distroName = p.distroPrefix() + distroName
+	installLocation := filepath.Join(p.installDir(), distroName)
+
+	if err := os.MkdirAll(installLocation, 0755); err != nil {
+		return nil, fmt.Errorf("creating wsl install location: %w", err)
+	}
+
+	slog.Debug("importing wsl distro", "distro", distroName, "tarball", opts.ImageRef)
+
+	// CPUs and MemoryMB can't be honored per distro: WSL2 only supports a
+	// single global memory/processor cap in %UserProfile%\.wslconfig,
+	// shared by every distro, not one rollout can scope to a single trial.
+	cmd := exec.CommandContext(ctx, "wsl", "--import", distroName, installLocation, opts.ImageRef, "--version", "2")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("importing wsl distro: %w: %s", err, stderr.String())
+	}
+
+	env := &Environment{provider: p, distroName: distroName, installLocation: installLocation}
+
+	for k, v := range opts.Env {
+		if _, _, err := env.execRaw(ctx, fmt.Sprintf("echo %s=%s >> /etc/environment", shellQuote(k), shellQuote(v))); err != nil {
+			return nil, fmt.Errorf("seeding environment variable %s: %w", k, err)
+		}
+	}
+
+	return env, nil
+}
+
+// Environment represents a running WSL2 distro.
+type Environment struct {
+	provider        *Provider
+	distroName      string
+	installLocation string
+}
+
+// ID returns the distro name.
+func (e *Environment) ID() string {
+	return e.distroName
+}
+
+// rootPath returns the UNC path Windows exposes a running WSL2 distro's
+// filesystem at, which CopyTo/CopyFrom read and write through directly
+// rather than shelling a copy command into the distro itself.
+func (e *Environment) rootPath() string {
+	return fmt.Sprintf(`\\wsl$\%s`, e.distroName)
+}
+
+// CopyTo copies a local file or directory into the distro via its \\wsl$
+// UNC mount.
+func (e *Environment) CopyTo(ctx context.Context, src, dst string) error {
+	slog.Debug("copying into wsl distro", "distro", e.distroName, "src", src, "dst", dst)
+
+	target := e.rootPath() + strings.ReplaceAll(dst, "/", `\`)
+	cmd := exec.CommandContext(ctx, "powershell", "-NoProfile", "-Command",
+		fmt.Sprintf("New-Item -ItemType Directory -Force -Path (Split-Path %q) | Out-Null; Copy-Item -Recurse -Force %q %q", target, src, target))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("copying into wsl distro: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// CopyFrom copies a file or directory from the distro to a local path via
+// its \\wsl$ UNC mount.
+func (e *Environment) CopyFrom(ctx context.Context, src, dst string) error {
+	slog.Debug("copying from wsl distro", "distro", e.distroName, "src", src, "dst", dst)
+
+	source := e.rootPath() + strings.ReplaceAll(src, "/", `\`)
+	cmd := exec.CommandContext(ctx, "powershell", "-NoProfile", "-Command",
+		fmt.Sprintf("New-Item -ItemType Directory -Force -Path %q | Out-Null; Copy-Item -Recurse -Force %q %q", filepath.Dir(dst), source, dst))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("copying from wsl distro: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// shellQuote wraps s in single quotes for inclusion in a bash -c string,
+// escaping any single quotes it contains.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// execRaw runs cmd inside the distro as the provider's configured user,
+// without the opts.Timeout/opts.Env/opts.WorkDir handling Exec adds.
+func (e *Environment) execRaw(ctx context.Context, cmd string) (int, string, error) {
+	execCmd := exec.CommandContext(ctx, "wsl", "--distribution", e.distroName, "--user", e.provider.user(), "--", "bash", "-c", cmd)
+	var stdout, stderr bytes.Buffer
+	execCmd.Stdout = &stdout
+	execCmd.Stderr = &stderr
+	err := execCmd.Run()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode(), stderr.String(), nil
+		}
+		return -1, stderr.String(), err
+	}
+	return 0, stderr.String(), nil
+}
+
+// Exec executes a command inside the distro.
+func (e *Environment) Exec(ctx context.Context, cmd string, stdout, stderr io.Writer, opts environment.ExecOptions) (int, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	var sb strings.Builder
+	for k, v := range opts.Env {
+		fmt.Fprintf(&sb, "export %s=%s; ", k, shellQuote(v))
+	}
+	if opts.WorkDir != "" {
+		fmt.Fprintf(&sb, "cd %s; ", shellQuote(opts.WorkDir))
+	}
+	sb.WriteString(cmd)
+
+	execCmd := exec.CommandContext(ctx, "wsl", "--distribution", e.distroName, "--user", e.provider.user(), "--", "bash", "-c", sb.String())
+	execCmd.Stdout = stdout
+	execCmd.Stderr = stderr
+
+	err := execCmd.Run()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode(), nil
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return -1, fmt.Errorf("command timed out")
+		}
+		return -1, fmt.Errorf("executing command: %w", err)
+	}
+	return 0, nil
+}
+
+// Stop terminates the distro's WSL2 VM instance without unregistering it,
+// mirroring docker stop (keeps the distro, just not running).
+func (e *Environment) Stop(ctx context.Context) error {
+	slog.Debug("terminating wsl distro", "distro", e.distroName)
+
+	cmd := exec.CommandContext(ctx, "wsl", "--terminate", e.distroName)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("terminating wsl distro: %w", err)
+	}
+	return nil
+}
+
+// Destroy unregisters the distro, deleting its virtual disk, and removes
+// its install location directory.
+func (e *Environment) Destroy(ctx context.Context) error {
+	slog.Debug("unregistering wsl distro", "distro", e.distroName)
+
+	cmd := exec.CommandContext(ctx, "wsl", "--unregister", e.distroName)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("unregistering wsl distro: %w", err)
+	}
+	if err := os.RemoveAll(e.installLocation); err != nil {
+		slog.Warn("failed to remove wsl install location", "path", e.installLocation, "error", err)
+	}
+	return nil
+}
+
+// Cost always returns 0: the wsl provider runs against a host the user
+// already owns and operates, with no metered billing rollout can observe.
+func (e *Environment) Cost() float64 {
+	return 0
+}
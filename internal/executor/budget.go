@@ -0,0 +1,59 @@
+package executor
+
+import (
+	"sync"
+
+	"github.com/spachava753/rollout/internal/models"
+)
+
+// budgetTracker enforces models.BudgetsConfig across trials sharing a task
+// or agent, on top of the per-trial timeouts the executor already applies.
+// It is read and updated concurrently by every worker in runConcurrent, so
+// all access goes through mu.
+type budgetTracker struct {
+	cfg models.BudgetsConfig
+
+	mu        sync.Mutex
+	taskSec   map[string]float64 // keyed by dataset + "/" + task name
+	agentCost map[string]float64 // keyed by agent name
+}
+
+func newBudgetTracker(cfg models.BudgetsConfig) *budgetTracker {
+	return &budgetTracker{
+		cfg:       cfg,
+		taskSec:   make(map[string]float64),
+		agentCost: make(map[string]float64),
+	}
+}
+
+func taskKey(trial models.Trial) string {
+	return trial.Dataset + "/" + trial.Task.Name
+}
+
+// allow reports whether trial may be started, and the budget that would be
+// exceeded if it were denied (for logging/result purposes).
+func (b *budgetTracker) allow(trial models.Trial) (bool, string) {
+	if b.cfg.PerTaskSec <= 0 && b.cfg.PerAgentCost <= 0 {
+		return true, ""
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.cfg.PerTaskSec > 0 && b.taskSec[taskKey(trial)] >= b.cfg.PerTaskSec {
+		return false, "per_task_sec"
+	}
+	if b.cfg.PerAgentCost > 0 && b.agentCost[trial.Agent.Name] >= b.cfg.PerAgentCost {
+		return false, "per_agent_cost"
+	}
+	return true, ""
+}
+
+// record adds a completed trial's consumption to the running totals.
+func (b *budgetTracker) record(trial models.Trial, result *models.TrialResult) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.taskSec[taskKey(trial)] += result.Durations.TotalSec
+	b.agentCost[trial.Agent.Name] += result.Cost
+}
@@ -0,0 +1,58 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/spachava753/rollout/internal/models"
+)
+
+func TestBudgetTrackerPerTaskSec(t *testing.T) {
+	b := newBudgetTracker(models.BudgetsConfig{PerTaskSec: 60})
+	trial := models.Trial{Dataset: "ds", Task: models.Task{Name: "task-a"}, Agent: models.Agent{Name: "agent-a"}}
+
+	if ok, _ := b.allow(trial); !ok {
+		t.Fatalf("expected first attempt to be allowed")
+	}
+
+	b.record(trial, &models.TrialResult{Durations: models.Durations{TotalSec: 61}})
+
+	ok, reason := b.allow(trial)
+	if ok {
+		t.Fatalf("expected task budget to be exhausted")
+	}
+	if reason != "per_task_sec" {
+		t.Errorf("reason = %q, want per_task_sec", reason)
+	}
+
+	otherTask := models.Trial{Dataset: "ds", Task: models.Task{Name: "task-b"}, Agent: models.Agent{Name: "agent-a"}}
+	if ok, _ := b.allow(otherTask); !ok {
+		t.Errorf("other task's budget should be unaffected")
+	}
+}
+
+func TestBudgetTrackerPerAgentCost(t *testing.T) {
+	b := newBudgetTracker(models.BudgetsConfig{PerAgentCost: 5})
+	trial := models.Trial{Dataset: "ds", Task: models.Task{Name: "task-a"}, Agent: models.Agent{Name: "agent-a"}}
+
+	b.record(trial, &models.TrialResult{Cost: 6})
+
+	if ok, reason := b.allow(trial); ok || reason != "per_agent_cost" {
+		t.Errorf("allow() = (%v, %q), want (false, per_agent_cost)", ok, reason)
+	}
+
+	otherAgent := models.Trial{Dataset: "ds", Task: models.Task{Name: "task-a"}, Agent: models.Agent{Name: "agent-b"}}
+	if ok, _ := b.allow(otherAgent); !ok {
+		t.Errorf("other agent's budget should be unaffected")
+	}
+}
+
+func TestBudgetTrackerDisabled(t *testing.T) {
+	b := newBudgetTracker(models.BudgetsConfig{})
+	trial := models.Trial{Dataset: "ds", Task: models.Task{Name: "task-a"}, Agent: models.Agent{Name: "agent-a"}}
+
+	b.record(trial, &models.TrialResult{Durations: models.Durations{TotalSec: 1_000_000}, Cost: 1_000_000})
+
+	if ok, _ := b.allow(trial); !ok {
+		t.Errorf("zero-value budgets should never deny a trial")
+	}
+}
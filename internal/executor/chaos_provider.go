@@ -0,0 +1,94 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+
+	"github.com/spachava753/rollout/internal/environment"
+	"github.com/spachava753/rollout/internal/models"
+)
+
+// chaosProvider wraps a Provider, randomly injecting CreateEnvironment
+// failures per models.ChaosConfig, so a job's retry/resume/aggregation
+// behavior can be validated against realistic infrastructure flakiness
+// before it's run for real (and before spending real money on a real
+// provider). BuildImage is left alone: an injected build failure would just
+// fail the whole job rather than a single trial, since built images are
+// reused across trials.
+type chaosProvider struct {
+	environment.Provider
+	cfg models.ChaosConfig
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// newChaosProvider wraps provider with cfg's failure injection. Returns
+// provider unwrapped if cfg.Enabled is false.
+func newChaosProvider(provider environment.Provider, cfg models.ChaosConfig) environment.Provider {
+	if !cfg.Enabled {
+		return provider
+	}
+	return &chaosProvider{Provider: provider, cfg: cfg, rng: rand.New(rand.NewSource(cfg.Seed))}
+}
+
+// chance draws the next deterministic random float in [0, 1) from p's
+// seeded RNG. Guarded by mu since multiple trials call into p concurrently,
+// and rand.Rand isn't goroutine-safe.
+func (p *chaosProvider) chance() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.rng.Float64()
+}
+
+// CreateEnvironment fails with a simulated provisioning error for
+// cfg.CreateFailureRate of calls; otherwise delegates to the wrapped
+// provider and wraps the resulting Environment so Exec/CopyTo/CopyFrom are
+// also subject to injection.
+func (p *chaosProvider) CreateEnvironment(ctx context.Context, opts environment.CreateEnvironmentOptions) (environment.Environment, error) {
+	if p.chance() < p.cfg.CreateFailureRate {
+		return nil, fmt.Errorf("chaos: injected environment-creation failure")
+	}
+	env, err := p.Provider.CreateEnvironment(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &chaosEnvironment{Environment: env, provider: p}, nil
+}
+
+// chaosEnvironment wraps an Environment, injecting exec timeouts and copy
+// errors per the owning chaosProvider's models.ChaosConfig.
+type chaosEnvironment struct {
+	environment.Environment
+	provider *chaosProvider
+}
+
+// Exec fails as if it had timed out for cfg.ExecTimeoutRate of calls;
+// otherwise delegates to the wrapped Environment.
+func (e *chaosEnvironment) Exec(ctx context.Context, cmd string, stdout, stderr io.Writer, opts environment.ExecOptions) (int, error) {
+	if e.provider.chance() < e.provider.cfg.ExecTimeoutRate {
+		return -1, fmt.Errorf("chaos: injected exec timeout: %w", context.DeadlineExceeded)
+	}
+	return e.Environment.Exec(ctx, cmd, stdout, stderr, opts)
+}
+
+// CopyTo fails with a simulated transport error for cfg.CopyErrorRate of
+// calls; otherwise delegates to the wrapped Environment.
+func (e *chaosEnvironment) CopyTo(ctx context.Context, src, dst string) error {
+	if e.provider.chance() < e.provider.cfg.CopyErrorRate {
+		return fmt.Errorf("chaos: injected copy error copying %s to environment", src)
+	}
+	return e.Environment.CopyTo(ctx, src, dst)
+}
+
+// CopyFrom fails with a simulated transport error for cfg.CopyErrorRate of
+// calls; otherwise delegates to the wrapped Environment.
+func (e *chaosEnvironment) CopyFrom(ctx context.Context, src, dst string) error {
+	if e.provider.chance() < e.provider.cfg.CopyErrorRate {
+		return fmt.Errorf("chaos: injected copy error copying %s from environment", src)
+	}
+	return e.Environment.CopyFrom(ctx, src, dst)
+}
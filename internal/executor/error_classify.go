@@ -0,0 +1,61 @@
+package executor
+
+import (
+	"strings"
+
+	"github.com/spachava753/rollout/internal/models"
+)
+
+// providerErrorPatterns maps a substring found in a provider's error
+// message to the models.ErrorType it indicates, checked in order so a more
+// specific pattern can be listed before a more general one that would
+// otherwise also match. Covers the common failure modes reported across
+// providers (docker, modal, and the rest), which each phrase the same
+// underlying failure differently.
+var providerErrorPatterns = []struct {
+	substring string
+	errType   models.ErrorType
+}{
+	{"no such image", models.ErrEnvironmentImageNotFound},
+	{"manifest unknown", models.ErrEnvironmentImageNotFound},
+
+	// Docker's own pull-access-denied message doesn't distinguish a
+	// missing image from an auth failure ("repository does not exist or
+	// may require 'docker login'"), so it's classified as an auth failure
+	// since that's the more common real-world cause and the more
+	// actionable of the two.
+	{"pull access denied", models.ErrEnvironmentAuthFailed},
+	{"unauthorized", models.ErrEnvironmentAuthFailed},
+	{"authentication required", models.ErrEnvironmentAuthFailed},
+	{"permission denied", models.ErrEnvironmentAuthFailed},
+
+	{"quota exceeded", models.ErrEnvironmentQuotaExceeded},
+	{"quota has been reached", models.ErrEnvironmentQuotaExceeded},
+	{"resource_exhausted", models.ErrEnvironmentQuotaExceeded},
+
+	{"no space left on device", models.ErrEnvironmentNoSpace},
+
+	{"network is unreachable", models.ErrEnvironmentNetworkUnreachable},
+	{"no route to host", models.ErrEnvironmentNetworkUnreachable},
+	{"could not resolve host", models.ErrEnvironmentNetworkUnreachable},
+	{"name resolution", models.ErrEnvironmentNetworkUnreachable},
+	{"connection refused", models.ErrEnvironmentNetworkUnreachable},
+}
+
+// classifyProviderError maps err to a specific models.ErrorType based on
+// known substrings in common provider error messages, so aggregate
+// failure-type counts in JobResult are actionable instead of a pile of
+// internal_error/environment_build_failed strings. Returns fallback when
+// err matches no known pattern.
+func classifyProviderError(err error, fallback models.ErrorType) models.ErrorType {
+	if err == nil {
+		return fallback
+	}
+	msg := strings.ToLower(err.Error())
+	for _, p := range providerErrorPatterns {
+		if strings.Contains(msg, p.substring) {
+			return p.errType
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,36 @@
+package executor
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spachava753/rollout/internal/models"
+)
+
+func TestClassifyProviderError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want models.ErrorType
+	}{
+		{"image not found", errors.New("Error response from daemon: No such image: foo:latest"), models.ErrEnvironmentImageNotFound},
+		{"auth failure", errors.New("pull access denied for private/repo, repository does not exist or may require 'docker login'"), models.ErrEnvironmentAuthFailed},
+		{"quota exceeded", errors.New("creating environment: quota exceeded for resource sandboxes"), models.ErrEnvironmentQuotaExceeded},
+		{"no space", errors.New("write /var/lib/docker/layer: no space left on device"), models.ErrEnvironmentNoSpace},
+		{"network unreachable", errors.New("dial tcp: lookup registry-1.docker.io: no route to host"), models.ErrEnvironmentNetworkUnreachable},
+		{"unrecognized falls back", errors.New("something else entirely went wrong"), models.ErrEnvironmentBuildFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyProviderError(tt.err, models.ErrEnvironmentBuildFailed); got != tt.want {
+				t.Errorf("classifyProviderError(%q) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyProviderErrorNilErr(t *testing.T) {
+	if got := classifyProviderError(nil, models.ErrEnvironmentBuildFailed); got != models.ErrEnvironmentBuildFailed {
+		t.Errorf("expected fallback for nil error, got %v", got)
+	}
+}
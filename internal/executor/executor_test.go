@@ -2,7 +2,10 @@ package executor
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
+	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -127,6 +130,61 @@ func slowMockExecutorFunc(delay time.Duration, counter *int32) NewTrialExecutorF
 	}
 }
 
+// flakyMockTrialExecutor fails odd attempts and passes even attempts, to
+// exercise how failed trials are counted in pass rate and mean reward.
+type flakyMockTrialExecutor struct{}
+
+func (m *flakyMockTrialExecutor) Execute(ctx context.Context, trial models.Trial, provider environment.Provider) (*models.TrialResult, error) {
+	if trial.Attempt%2 == 1 {
+		return &models.TrialResult{
+			TaskName:    trial.Task.Name,
+			DatasetName: trial.Dataset,
+			AgentName:   trial.Agent.Name,
+			Attempt:     trial.Attempt,
+			Error: &models.TrialError{
+				Type:    models.ErrEnvironmentStartFailed,
+				Message: "simulated environment flake",
+			},
+		}, nil
+	}
+
+	reward := 1.0
+	return &models.TrialResult{
+		TaskName:    trial.Task.Name,
+		DatasetName: trial.Dataset,
+		AgentName:   trial.Agent.Name,
+		Attempt:     trial.Attempt,
+		Reward:      &reward,
+	}, nil
+}
+
+func flakyMockExecutorFunc(cfg models.JobConfig) TrialExecutor {
+	return &flakyMockTrialExecutor{}
+}
+
+// nondeterministicRewardMockTrialExecutor alternates reward 1.0 and 0.0
+// across attempts for the same task, without ever erroring, to exercise
+// flakiness detection.
+type nondeterministicRewardMockTrialExecutor struct{}
+
+func (m *nondeterministicRewardMockTrialExecutor) Execute(ctx context.Context, trial models.Trial, provider environment.Provider) (*models.TrialResult, error) {
+	reward := 0.0
+	if trial.Attempt%2 == 1 {
+		reward = 1.0
+	}
+	return &models.TrialResult{
+		TaskName:    trial.Task.Name,
+		DatasetName: trial.Dataset,
+		AgentName:   trial.Agent.Name,
+		Attempt:     trial.Attempt,
+		Reward:      &reward,
+	}, nil
+}
+
+func nondeterministicRewardMockExecutorFunc(cfg models.JobConfig) TrialExecutor {
+	return &nondeterministicRewardMockTrialExecutor{}
+}
+
 // --- Integration Tests (require Docker) ---
 
 func TestOracleAgentHelloWorld(t *testing.T) {
@@ -295,6 +353,131 @@ func TestOrchestratorResultAggregation(t *testing.T) {
 	})
 }
 
+func TestScoringCountFailuresAsZero(t *testing.T) {
+	cfg, _ := loadTestConfig(t)
+	cfg.NAttempts = 4
+	cfg.NConcurrentTrials = 1
+	cfg.JobsDir = t.TempDir()
+	cfg.Name = ptr("test-scoring-excludes-failures")
+
+	synctest.Test(t, func(t *testing.T) {
+		orchestrator, err := NewJobOrchestrator(cfg, flakyMockExecutorFunc)
+		if err != nil {
+			t.Fatalf("creating orchestrator: %v", err)
+		}
+
+		result, err := orchestrator.Run(t.Context())
+		if err != nil {
+			t.Fatalf("running job: %v", err)
+		}
+
+		// Excluding failures (the default), only the 2 successful trials count.
+		if result.PassRate != 1.0 {
+			t.Errorf("expected pass rate 1.0, got %f", result.PassRate)
+		}
+		if result.MeanReward != 1.0 {
+			t.Errorf("expected mean reward 1.0, got %f", result.MeanReward)
+		}
+	})
+
+	cfg.JobsDir = t.TempDir()
+	cfg.Name = ptr("test-scoring-counts-failures")
+	cfg.Scoring.CountFailuresAsZero = true
+
+	synctest.Test(t, func(t *testing.T) {
+		orchestrator, err := NewJobOrchestrator(cfg, flakyMockExecutorFunc)
+		if err != nil {
+			t.Fatalf("creating orchestrator: %v", err)
+		}
+
+		result, err := orchestrator.Run(t.Context())
+		if err != nil {
+			t.Fatalf("running job: %v", err)
+		}
+
+		// Counting failures as reward 0, only 2 of 4 trials pass.
+		if result.PassRate != 0.5 {
+			t.Errorf("expected pass rate 0.5, got %f", result.PassRate)
+		}
+		if result.MeanReward != 0.5 {
+			t.Errorf("expected mean reward 0.5, got %f", result.MeanReward)
+		}
+	})
+}
+
+func TestFlakinessDetection(t *testing.T) {
+	cfg, _ := loadTestConfig(t)
+	cfg.NAttempts = 4
+	cfg.NConcurrentTrials = 1
+	cfg.JobsDir = t.TempDir()
+	cfg.Name = ptr("test-flakiness")
+
+	synctest.Test(t, func(t *testing.T) {
+		orchestrator, err := NewJobOrchestrator(cfg, nondeterministicRewardMockExecutorFunc)
+		if err != nil {
+			t.Fatalf("creating orchestrator: %v", err)
+		}
+
+		result, err := orchestrator.Run(t.Context())
+		if err != nil {
+			t.Fatalf("running job: %v", err)
+		}
+
+		if len(result.Flakiness) != 1 {
+			t.Fatalf("expected 1 flaky task, got %d: %+v", len(result.Flakiness), result.Flakiness)
+		}
+		f := result.Flakiness[0]
+		if f.AgentName != "oracle" || f.TaskName != "hello-world" {
+			t.Errorf("unexpected flakiness entry: %+v", f)
+		}
+		if f.Attempts != 4 || f.Passes != 2 {
+			t.Errorf("expected 2/4 passes, got %d/%d", f.Passes, f.Attempts)
+		}
+	})
+}
+
+func TestOfflineModeRejectsRegistryDataset(t *testing.T) {
+	cfg, _ := loadTestConfig(t)
+	cfg.JobsDir = t.TempDir()
+	cfg.Name = ptr("test-offline-registry")
+	cfg.Datasets = append(cfg.Datasets, models.DatasetRef{
+		Name:     "remote-dataset",
+		Registry: &models.RegistryRef{URL: ptr("https://example.com/registry.json")},
+	})
+
+	orchestrator, err := NewJobOrchestrator(cfg, mockExecutorFunc)
+	if err != nil {
+		t.Fatalf("creating orchestrator: %v", err)
+	}
+	orchestrator.Offline = true
+
+	_, err = orchestrator.Run(t.Context())
+	if err == nil {
+		t.Fatal("expected offline mode to reject a registry dataset")
+	}
+	if !strings.Contains(err.Error(), "offline mode") {
+		t.Errorf("expected an offline-mode error, got: %s", err)
+	}
+}
+
+func TestOfflineModeAllowsLocalDataset(t *testing.T) {
+	cfg, _ := loadTestConfig(t)
+	cfg.JobsDir = t.TempDir()
+	cfg.Name = ptr("test-offline-local")
+
+	orchestrator, err := NewJobOrchestrator(cfg, mockExecutorFunc)
+	if err != nil {
+		t.Fatalf("creating orchestrator: %v", err)
+	}
+	orchestrator.Offline = true
+
+	// The test dataset is loaded from a local path and sets no docker_image,
+	// so offline mode has nothing to reject.
+	if _, err := orchestrator.Run(t.Context()); err != nil {
+		t.Fatalf("running job: %v", err)
+	}
+}
+
 func TestCancellationStopsExecution(t *testing.T) {
 	cfg, _ := loadTestConfig(t)
 	cfg.NAttempts = 10
@@ -327,10 +510,51 @@ func TestCancellationStopsExecution(t *testing.T) {
 			t.Log("Note: all trials completed before cancellation took effect")
 		} else {
 			t.Logf("Cancellation worked: completed=%d, skipped=%d", result.CompletedTrials, result.SkippedTrials)
+
+			skippedFound := 0
+			jobDir := filepath.Join(cfg.JobsDir, *cfg.Name)
+			filepath.WalkDir(jobDir, func(path string, d fs.DirEntry, err error) error {
+				if err != nil || d.IsDir() || d.Name() != "result.json" {
+					return nil
+				}
+				data, err := os.ReadFile(path)
+				if err != nil {
+					return nil
+				}
+				var r models.TrialResult
+				if err := json.Unmarshal(data, &r); err != nil {
+					return nil
+				}
+				if r.Status == models.StatusSkipped || r.Status == models.StatusCancelled {
+					skippedFound++
+				}
+				return nil
+			})
+			if skippedFound != result.SkippedTrials {
+				t.Errorf("expected %d skipped result.json files, found %d", result.SkippedTrials, skippedFound)
+			}
 		}
 	})
 }
 
+func TestScanImageRecordsErrorWhenScannerUnavailable(t *testing.T) {
+	e := &DefaultTrialExecutor{EnvOverrides: models.JobEnvironmentConfig{
+		Scan: models.ScanConfig{Enabled: true, Scanner: "trivy"},
+	}}
+	trial := models.Trial{Task: models.Task{Name: "hello-world"}}
+
+	report := e.scanImage(t.Context(), trial, "rollout-test:does-not-exist", slog.Default())
+
+	if report == nil {
+		t.Fatal("expected a non-nil report even on failure")
+	}
+	if report.ImageRef != "rollout-test:does-not-exist" || report.TaskName != "hello-world" {
+		t.Errorf("unexpected report identity: %+v", report)
+	}
+	if report.Error == "" {
+		t.Error("expected Error to be set since docker/trivy are not available in this environment")
+	}
+}
 
 func TestComputeVerifierTimeout(t *testing.T) {
 	tests := []struct {
@@ -429,7 +653,10 @@ func TestComputeVerifierTimeout(t *testing.T) {
 					OverrideTimeoutSec: tt.overrideSec,
 					MaxTimeoutSec:      tt.maxSec,
 				},
-				models.JobEnvironmentConfig{}, // Added missing argument
+				models.JobEnvironmentConfig{},
+				models.DiffConfig{},
+				nil,
+				nil,
 			)
 
 			got := exec.computeVerifierTimeout(tt.taskTimeoutSec)
@@ -441,6 +668,84 @@ func TestComputeVerifierTimeout(t *testing.T) {
 	}
 }
 
+func TestPassThresholdDefaultsWhenUnset(t *testing.T) {
+	reward := 0.9
+
+	tests := []struct {
+		name string
+		r    *models.TrialResult
+		want float64
+	}{
+		{
+			name: "unset defaults to 1.0",
+			r:    &models.TrialResult{Reward: &reward},
+			want: 1.0,
+		},
+		{
+			name: "explicit threshold is respected",
+			r:    &models.TrialResult{Reward: &reward, PassThreshold: ptr(0.8)},
+			want: 0.8,
+		},
+		{
+			name: "explicit zero threshold is respected, not treated as unset",
+			r:    &models.TrialResult{Reward: &reward, PassThreshold: ptr(0.0)},
+			want: 0.0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := passThreshold(tt.r); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputePassThreshold(t *testing.T) {
+	tests := []struct {
+		name          string
+		taskThreshold float64
+		override      *float64
+		want          float64
+	}{
+		{
+			name:          "task threshold used by default",
+			taskThreshold: 1.0,
+			want:          1.0,
+		},
+		{
+			name:          "continuous reward threshold",
+			taskThreshold: 0.8,
+			want:          0.8,
+		},
+		{
+			name:          "job override takes precedence",
+			taskThreshold: 1.0,
+			override:      ptr(0.5),
+			want:          0.5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := NewTrialExecutor(
+				"/tmp/instruction.md",
+				1.0,
+				models.JobVerifierConfig{OverridePassThreshold: tt.override},
+				models.JobEnvironmentConfig{},
+				models.DiffConfig{},
+				nil,
+				nil,
+			)
+
+			if got := exec.computePassThreshold(tt.taskThreshold); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestModalOracleAgentHelloWorld(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test in short mode")
@@ -454,7 +759,7 @@ func TestModalOracleAgentHelloWorld(t *testing.T) {
 	cfg, _ := loadTestConfig(t)
 	cfg.JobsDir = getJobsDir(t)
 	cfg.Name = ptr("test-modal-oracle-hello-world")
-	cfg.Environment.Type = "modal"
+	cfg.Environment.Type = models.ProviderTypes{"modal"}
 
 	orchestrator, err := NewJobOrchestrator(cfg, DefaultTrialExecutorFunc)
 	if err != nil {
@@ -494,7 +799,7 @@ func TestModalAppCleanup(t *testing.T) {
 	cfg, _ := loadTestConfig(t)
 	cfg.JobsDir = getJobsDir(t)
 	cfg.Name = ptr("test-modal-cleanup")
-	cfg.Environment.Type = "modal"
+	cfg.Environment.Type = models.ProviderTypes{"modal"}
 	cfg.Environment.PreserveEnv = models.PreserveNever
 
 	orchestrator, err := NewJobOrchestrator(cfg, DefaultTrialExecutorFunc)
@@ -516,3 +821,39 @@ func TestModalAppCleanup(t *testing.T) {
 	// but the test succeeding without errors indicates cleanup worked.
 	t.Log("Modal cleanup test completed - sandbox terminated successfully")
 }
+
+// BenchmarkJobOrchestratorScheduling measures pure scheduling overhead -
+// generating, dispatching, and aggregating b.N trials through a real
+// JobOrchestrator with a no-op TrialExecutor - isolating the orchestrator's
+// own cost from any environment provider's.
+func BenchmarkJobOrchestratorScheduling(b *testing.B) {
+	projectRoot, err := filepath.Abs("../..")
+	if err != nil {
+		b.Fatal(err)
+	}
+	configPath := filepath.Join(projectRoot, "testdata", "job.yaml")
+	cfg, err := config.LoadJobConfig(configPath)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i, ds := range cfg.Datasets {
+		if ds.Path != nil && !filepath.IsAbs(*ds.Path) {
+			absPath := filepath.Join(projectRoot, *ds.Path)
+			cfg.Datasets[i].Path = &absPath
+		}
+	}
+	cfg.NAttempts = b.N
+	cfg.NConcurrentTrials = 8
+	cfg.JobsDir = b.TempDir()
+	cfg.Name = ptr("bench-scheduling")
+
+	orchestrator, err := NewJobOrchestrator(cfg, mockExecutorFunc)
+	if err != nil {
+		b.Fatalf("creating orchestrator: %v", err)
+	}
+
+	b.ResetTimer()
+	if _, err := orchestrator.Run(context.Background()); err != nil {
+		b.Fatalf("running job: %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/spachava753/rollout/internal/environment"
+)
+
+// failoverProvider wraps an ordered list of providers, one per entry in a
+// job.yaml environment.type failover list. BuildImage and CreateEnvironment
+// try each provider in order, moving to the next only when a call fails with
+// what looks like a capacity or auth error (the provider is unavailable or
+// misconfigured, not that the trial itself is bad) - any other error is
+// returned immediately, since retrying it on a different provider would
+// just mask a real task/environment bug.
+type failoverProvider struct {
+	providers []environment.Provider
+	names     []string
+}
+
+// newFailoverProvider wraps providers (already constructed and throttled,
+// one per environment.type entry) so BuildImage/CreateEnvironment fail over
+// between them in order. Returns providers[0] unwrapped if there's only one.
+func newFailoverProvider(names []string, providers []environment.Provider) environment.Provider {
+	if len(providers) == 1 {
+		return providers[0]
+	}
+	return &failoverProvider{providers: providers, names: names}
+}
+
+// Name returns the first (highest-priority) provider's name.
+func (p *failoverProvider) Name() string {
+	return p.names[0]
+}
+
+// isFailoverableError reports whether err looks like the provider itself is
+// unavailable - out of capacity, quota exceeded, or a bad/expired credential
+// - rather than a problem with the trial's own image or command. CLI-shelled
+// providers surface these as unstructured stderr text, so this is a
+// best-effort substring match, not a guarantee.
+func isFailoverableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{
+		"capacity", "quota", "resource exhausted", "insufficient",
+		"no space left", "rate limit", "too many requests",
+		"unauthorized", "unauthenticated", "authentication failed",
+		"forbidden", "permission denied", "access denied",
+		"401", "403", "429", "503",
+	} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildImage tries each provider in order, failing over on a capacity/auth
+// error and returning any other error immediately.
+func (p *failoverProvider) BuildImage(ctx context.Context, opts environment.BuildImageOptions) (string, error) {
+	var lastErr error
+	for i, provider := range p.providers {
+		tag, err := provider.BuildImage(ctx, opts)
+		if err == nil {
+			return tag, nil
+		}
+		if !isFailoverableError(err) {
+			return "", err
+		}
+		slog.Warn("provider failed to build image, failing over", "provider", p.names[i], "error", err)
+		lastErr = err
+	}
+	return "", fmt.Errorf("all providers failed to build image: %w", lastErr)
+}
+
+// PullImage tries each provider in order, failing over on a capacity/auth
+// error and returning any other error immediately.
+func (p *failoverProvider) PullImage(ctx context.Context, imageRef string) error {
+	var lastErr error
+	for i, provider := range p.providers {
+		err := provider.PullImage(ctx, imageRef)
+		if err == nil {
+			return nil
+		}
+		if !isFailoverableError(err) {
+			return err
+		}
+		slog.Warn("provider failed to pull image, failing over", "provider", p.names[i], "error", err)
+		lastErr = err
+	}
+	return fmt.Errorf("all providers failed to pull image: %w", lastErr)
+}
+
+// CreateEnvironment tries each provider in order, failing over on a
+// capacity/auth error. The returned Environment implements
+// environment.ProviderReporter, naming whichever provider actually
+// succeeded.
+func (p *failoverProvider) CreateEnvironment(ctx context.Context, opts environment.CreateEnvironmentOptions) (environment.Environment, error) {
+	var errs []error
+	for i, provider := range p.providers {
+		env, err := provider.CreateEnvironment(ctx, opts)
+		if err == nil {
+			return &reportingEnvironment{Environment: env, providerName: p.names[i]}, nil
+		}
+		if !isFailoverableError(err) {
+			return nil, err
+		}
+		slog.Warn("provider failed to create environment, failing over", "provider", p.names[i], "error", err)
+		errs = append(errs, fmt.Errorf("%s: %w", p.names[i], err))
+	}
+	return nil, fmt.Errorf("all providers failed to create environment: %w", errors.Join(errs...))
+}
+
+// reportingEnvironment wraps an Environment with the name of the provider
+// that actually created it, implementing environment.ProviderReporter.
+type reportingEnvironment struct {
+	environment.Environment
+	providerName string
+}
+
+// ProviderName implements environment.ProviderReporter.
+func (e *reportingEnvironment) ProviderName() string {
+	return e.providerName
+}
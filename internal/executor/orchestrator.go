@@ -7,18 +7,48 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/spachava753/rollout/internal/audit"
 	"github.com/spachava753/rollout/internal/config"
 	"github.com/spachava753/rollout/internal/dataset"
+	"github.com/spachava753/rollout/internal/dirlock"
+	"github.com/spachava753/rollout/internal/diskguard"
 	"github.com/spachava753/rollout/internal/environment"
+	"github.com/spachava753/rollout/internal/environment/apple"
+	"github.com/spachava753/rollout/internal/environment/cloudflare"
+	"github.com/spachava753/rollout/internal/environment/cloudrun"
+	"github.com/spachava753/rollout/internal/environment/coder"
+	"github.com/spachava753/rollout/internal/environment/containerd"
+	"github.com/spachava753/rollout/internal/environment/daytona"
 	"github.com/spachava753/rollout/internal/environment/docker"
+	"github.com/spachava753/rollout/internal/environment/fake"
+	"github.com/spachava753/rollout/internal/environment/fargate"
+	"github.com/spachava753/rollout/internal/environment/hetzner"
+	"github.com/spachava753/rollout/internal/environment/incus"
+	"github.com/spachava753/rollout/internal/environment/k8s"
+	"github.com/spachava753/rollout/internal/environment/lambdacloud"
 	"github.com/spachava753/rollout/internal/environment/modal"
+	"github.com/spachava753/rollout/internal/environment/morph"
+	"github.com/spachava753/rollout/internal/environment/qemu"
+	"github.com/spachava753/rollout/internal/environment/ssh"
+	"github.com/spachava753/rollout/internal/environment/vastai"
+	"github.com/spachava753/rollout/internal/environment/wsl"
+	"github.com/spachava753/rollout/internal/imagemirror"
+	"github.com/spachava753/rollout/internal/lockfile"
 	"github.com/spachava753/rollout/internal/models"
+	"github.com/spachava753/rollout/internal/provenance"
+	"github.com/spachava753/rollout/internal/redact"
 )
 
+// jobLockTimeout bounds how long RunFromJobConfig waits to acquire the
+// job directory lock before giving up, in case another rollout process
+// sharing the same jobs_dir is slow or has crashed while holding it.
+const jobLockTimeout = 30 * time.Second
+
 // TrialExecutor executes a single trial and returns the result.
 type TrialExecutor interface {
 	Execute(ctx context.Context, trial models.Trial, provider environment.Provider) (*models.TrialResult, error)
@@ -32,44 +62,289 @@ type JobOrchestrator struct {
 	cfg         models.JobConfig
 	provider    environment.Provider
 	newExecutor NewTrialExecutorFunc
+
+	// snapshotCache is shared across every worker's independently-constructed
+	// executor, so a snapshot taken by one worker's trial is visible to
+	// every other worker's attempts of the same (dataset, task, agent) trio.
+	snapshotCache *SnapshotCache
+
+	// datasetProviders holds a dataset-specific provider for every
+	// DatasetRef that set its own Environment block, keyed by dataset name.
+	// Datasets without an override use provider above instead.
+	datasetProviders map[string]environment.Provider
+
+	// imageMirror is the pull-through registry cache NewJobOrchestrator
+	// started when cfg.Environment.ImageMirror.Enabled, or nil otherwise.
+	// Close stops it.
+	imageMirror *imagemirror.Mirror
+
+	// ConfigPath is the job.yaml this orchestrator was loaded from, used to
+	// locate rollout.lock alongside it. Empty when constructed directly
+	// (e.g. in tests), which disables lockfile reading and writing.
+	ConfigPath string
+	// Locked, when true, requires rollout.lock to exist and pins registry
+	// dataset resolution to exactly the git commits it records instead of
+	// resolving "latest" again.
+	Locked bool
+	// Offline, when true, forbids any step that would need network access
+	// (cloning registry task repos, fetching a registry from a URL, or
+	// pulling a pre-built image), failing fast instead, for air-gapped
+	// evaluation environments.
+	Offline bool
+	// Debug, when true, drops into an interactive shell in a trial's
+	// environment if its install or execute phase fails, before the
+	// environment is torn down. Intended for single-trial debug runs.
+	Debug bool
+
+	// auditLogger records every provider operation (image builds,
+	// container creates, execs, copies, destroys) this job's trials make
+	// into jobDir/audit.jsonl. Opened by Run once jobDir exists, shared
+	// across every worker's independently-constructed executor the same
+	// way snapshotCache is.
+	auditLogger *audit.Logger
+
+	// statusTracker records every in-flight trial's current phase,
+	// environment, and recent output into jobDir/status.json, so
+	// `rollout trials` can show where a stuck job is stuck while it runs.
+	// Created by Run once jobDir exists, shared across every worker's
+	// independently-constructed executor the same way snapshotCache is.
+	statusTracker *StatusTracker
+}
+
+// buildProvider constructs the failover+throttled environment.Provider for
+// a single environment block, used both for the job's top-level
+// environment and for any DatasetRef.Environment override. mirrorAddr, if
+// set, is the address of an internal/imagemirror.Mirror the orchestrator
+// started for this job - it seeds docker.ProviderConfig.RegistryMirror when
+// the environment block doesn't already set provider_config.registry_mirror
+// itself.
+func buildProvider(envCfg models.JobEnvironmentConfig, mirrorAddr string) (environment.Provider, error) {
+	if len(envCfg.Type) == 0 {
+		return nil, fmt.Errorf("unsupported environment type: %s", envCfg.Type)
+	}
+
+	providers := make([]environment.Provider, 0, len(envCfg.Type))
+	for _, envType := range envCfg.Type {
+		p, err := newEnvironmentProvider(envType, envCfg, mirrorAddr)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, p)
+	}
+
+	provider := newFailoverProvider(envCfg.Type, providers)
+	provider = newThrottledProvider(provider, envCfg.MaxConcurrentEnvironments)
+	return newChaosProvider(provider, envCfg.Chaos), nil
+}
+
+// BuildProvider constructs the environment.Provider for a job's environment
+// block, exported for callers that drive a single trial directly (e.g.
+// cmd/rollout-trial) instead of going through NewJobOrchestrator/Run. It
+// doesn't start an image mirror the way NewJobOrchestrator does, since a
+// single trial isn't the right place to own that shared, job-lifetime
+// process - start one separately and set mirrorAddr (or
+// provider_config.registry_mirror) if needed.
+func BuildProvider(envCfg models.JobEnvironmentConfig, mirrorAddr string) (environment.Provider, error) {
+	return buildProvider(envCfg, mirrorAddr)
 }
 
 // NewJobOrchestrator creates a new job orchestrator.
 func NewJobOrchestrator(cfg models.JobConfig, executorFactory NewTrialExecutorFunc) (*JobOrchestrator, error) {
+	cfg.Agents = models.ExpandAgents(cfg.Agents)
+
+	var mirror *imagemirror.Mirror
+	if cfg.Environment.ImageMirror.Enabled {
+		m, err := imagemirror.Start(context.Background(), imagemirror.Config{
+			Port:      cfg.Environment.ImageMirror.Port,
+			RemoteURL: cfg.Environment.ImageMirror.RemoteURL,
+			CacheDir:  cfg.Environment.ImageMirror.CacheDir,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("starting image mirror: %w", err)
+		}
+		mirror = m
+	}
+	mirrorAddr := ""
+	if mirror != nil {
+		mirrorAddr = mirror.Addr()
+	}
+
+	provider, err := buildProvider(cfg.Environment, mirrorAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	datasetProviders := make(map[string]environment.Provider)
+	for _, ref := range cfg.Datasets {
+		if ref.Environment == nil {
+			continue
+		}
+		dp, err := buildProvider(*ref.Environment, mirrorAddr)
+		if err != nil {
+			return nil, fmt.Errorf("dataset %s environment: %w", ref.Name, err)
+		}
+		datasetProviders[ref.Name] = dp
+	}
+
+	return &JobOrchestrator{
+		cfg:              cfg,
+		datasetProviders: datasetProviders,
+		provider:         provider,
+		newExecutor:      executorFactory,
+		snapshotCache:    NewSnapshotCache(),
+		imageMirror:      mirror,
+	}, nil
+}
+
+// Close releases resources NewJobOrchestrator started outside the trials
+// themselves, currently just an enabled image mirror. Safe to call on an
+// orchestrator that never started one.
+func (o *JobOrchestrator) Close(ctx context.Context) error {
+	if o.imageMirror == nil {
+		return nil
+	}
+	return o.imageMirror.Stop(ctx)
+}
+
+// providerFor returns the provider a trial on the given dataset should use:
+// the dataset's own override if DatasetRef.Environment was set, otherwise
+// the job's top-level provider.
+func (o *JobOrchestrator) providerFor(datasetName string) environment.Provider {
+	if p, ok := o.datasetProviders[datasetName]; ok {
+		return p
+	}
+	return o.provider
+}
+
+// newEnvironmentProvider constructs the environment.Provider for a single
+// environment.type entry. Split out from NewJobOrchestrator so a failover
+// list (environment.type: [modal, docker]) can build one provider per entry.
+func newEnvironmentProvider(envType string, envCfg models.JobEnvironmentConfig, mirrorAddr string) (environment.Provider, error) {
 	var provider environment.Provider
-	switch cfg.Environment.Type {
+	switch envType {
 	case "docker":
-		provider = docker.NewProvider()
-		slog.Debug("initialized docker environment provider")
+		dockerCfg := docker.ParseProviderConfig(envCfg.ProviderConfig)
+		if dockerCfg.RegistryMirror == "" {
+			dockerCfg.RegistryMirror = mirrorAddr
+		}
+		var err error
+		provider, err = docker.NewProvider(dockerCfg)
+		if err != nil {
+			return nil, fmt.Errorf("creating docker provider: %w", err)
+		}
+		slog.Debug("initialized docker environment provider", "runtime", dockerCfg.Runtime, "registry_mirror", dockerCfg.RegistryMirror)
 	case "modal":
-		modalCfg := modal.ParseProviderConfig(cfg.Environment.ProviderConfig)
+		modalCfg := modal.ParseProviderConfig(envCfg.ProviderConfig)
 		var err error
 		provider, err = modal.NewProvider(modalCfg)
 		if err != nil {
 			return nil, fmt.Errorf("creating modal provider: %w", err)
 		}
 		slog.Debug("initialized modal environment provider")
+	case "k8s":
+		k8sCfg := k8s.ParseProviderConfig(envCfg.ProviderConfig)
+		provider = k8s.NewProvider(k8sCfg)
+		slog.Debug("initialized k8s environment provider", "namespace", k8sCfg.Namespace)
+	case "fargate":
+		fargateCfg := fargate.ParseProviderConfig(envCfg.ProviderConfig)
+		provider = fargate.NewProvider(fargateCfg)
+		slog.Debug("initialized fargate environment provider", "cluster", fargateCfg.Cluster)
+	case "gcp_cloudrun":
+		cloudrunCfg := cloudrun.ParseProviderConfig(envCfg.ProviderConfig)
+		provider = cloudrun.NewProvider(cloudrunCfg)
+		slog.Debug("initialized gcp_cloudrun environment provider", "project", cloudrunCfg.Project, "region", cloudrunCfg.Region)
+	case "daytona":
+		daytonaCfg := daytona.ParseProviderConfig(envCfg.ProviderConfig)
+		provider = daytona.NewProvider(daytonaCfg)
+		slog.Debug("initialized daytona environment provider", "target", daytonaCfg.Target)
+	case "ssh":
+		sshCfg := ssh.ParseProviderConfig(envCfg.ProviderConfig)
+		provider = ssh.NewProvider(sshCfg)
+		slog.Debug("initialized ssh environment provider", "host", sshCfg.Host)
+	case "containerd":
+		containerdCfg := containerd.ParseProviderConfig(envCfg.ProviderConfig)
+		provider = containerd.NewProvider(containerdCfg)
+		slog.Debug("initialized containerd environment provider", "namespace", containerdCfg.Namespace)
+	case "incus":
+		incusCfg := incus.ParseProviderConfig(envCfg.ProviderConfig)
+		provider = incus.NewProvider(incusCfg)
+		slog.Debug("initialized incus environment provider", "remote", incusCfg.Remote)
+	case "fake":
+		fakeCfg := fake.ParseProviderConfig(envCfg.ProviderConfig)
+		provider = fake.NewProvider(fakeCfg)
+		slog.Debug("initialized fake environment provider")
+	case "qemu":
+		qemuCfg := qemu.ParseProviderConfig(envCfg.ProviderConfig)
+		provider = qemu.NewProvider(qemuCfg)
+		slog.Debug("initialized qemu environment provider", "connect", qemuCfg.Connect)
+	case "cloudflare":
+		cloudflareCfg := cloudflare.ParseProviderConfig(envCfg.ProviderConfig)
+		provider = cloudflare.NewProvider(cloudflareCfg)
+		slog.Debug("initialized cloudflare environment provider", "account_id", cloudflareCfg.AccountID)
+	case "morph":
+		morphCfg := morph.ParseProviderConfig(envCfg.ProviderConfig)
+		provider = morph.NewProvider(morphCfg)
+		slog.Debug("initialized morph environment provider", "base_snapshot_id", morphCfg.BaseSnapshotID)
+	case "vastai":
+		vastaiCfg := vastai.ParseProviderConfig(envCfg.ProviderConfig)
+		provider = vastai.NewProvider(vastaiCfg)
+		slog.Debug("initialized vastai environment provider", "gpu_filter", vastaiCfg.GPUFilter, "bid_price", vastaiCfg.BidPrice)
+	case "lambda_cloud":
+		lambdaCfg := lambdacloud.ParseProviderConfig(envCfg.ProviderConfig)
+		provider = lambdacloud.NewProvider(lambdaCfg)
+		slog.Debug("initialized lambda_cloud environment provider", "instance_type", lambdaCfg.InstanceType, "region", lambdaCfg.Region)
+	case "hetzner":
+		hetznerCfg := hetzner.ParseProviderConfig(envCfg.ProviderConfig)
+		provider = hetzner.NewProvider(hetznerCfg)
+		slog.Debug("initialized hetzner environment provider", "server_type", hetznerCfg.ServerType, "location", hetznerCfg.Location)
+	case "wsl":
+		wslCfg := wsl.ParseProviderConfig(envCfg.ProviderConfig)
+		provider = wsl.NewProvider(wslCfg)
+		slog.Debug("initialized wsl environment provider", "distro_prefix", wslCfg.DistroPrefix)
+	case "coder":
+		coderCfg := coder.ParseProviderConfig(envCfg.ProviderConfig)
+		provider = coder.NewProvider(coderCfg)
+		slog.Debug("initialized coder environment provider", "url", coderCfg.URL, "org", coderCfg.Org)
+	case "apple":
+		appleCfg := apple.ParseProviderConfig(envCfg.ProviderConfig)
+		provider = apple.NewProvider(appleCfg)
+		slog.Debug("initialized apple environment provider", "root_exec", appleCfg.RootExec)
 	default:
-		return nil, fmt.Errorf("unsupported environment type: %s", cfg.Environment.Type)
+		return nil, fmt.Errorf("unsupported environment type: %s", envType)
 	}
 
-	return &JobOrchestrator{
-		cfg:         cfg,
-		provider:    provider,
-		newExecutor: executorFactory,
-	}, nil
+	return provider, nil
 }
 
 // Run executes all trials defined by the job configuration.
 func (o *JobOrchestrator) Run(ctx context.Context) (*models.JobResult, error) {
 	startTime := time.Now()
 
+	// If running --locked, rollout.lock must already exist: load it now so
+	// its git commit pins can be applied as registry datasets are resolved
+	// below, instead of re-resolving "latest".
+	lockPath := o.lockPath()
+	var lf lockfile.Lockfile
+	if o.Locked {
+		if lockPath == "" {
+			return nil, fmt.Errorf("--locked requires a job loaded from a file, not a config built in-process")
+		}
+		var err error
+		lf, err = lockfile.Load(lockPath)
+		if err != nil {
+			return nil, fmt.Errorf("loading %s (run without --locked first to generate one): %w", lockfile.FileName, err)
+		}
+	}
+
 	// Load datasets
 	slog.Info("loading datasets", "count", len(o.cfg.Datasets))
 	loader := dataset.NewLoader()
 	var datasets []models.Dataset
 
 	for _, ref := range o.cfg.Datasets {
+		if o.Offline && ref.Registry != nil {
+			return nil, fmt.Errorf("offline mode forbids registry dataset %q: resolving it would clone task repositories and possibly fetch registry metadata over the network; use a local dataset path instead", ref.Name)
+		}
 		if ref.Path != nil {
 			slog.Debug("loading dataset from path", "path", *ref.Path)
 			ds, err := loader.LoadFromPath(ctx, *ref.Path)
@@ -80,7 +355,11 @@ func (o *JobOrchestrator) Run(ctx context.Context) (*models.JobResult, error) {
 			datasets = append(datasets, *ds)
 		} else if ref.Registry != nil {
 			slog.Debug("loading dataset from registry", "name", ref.Name, "version", ref.Version)
-			ds, err := loader.LoadFromRegistry(ctx, *ref.Registry, ref.Name, ref.Version)
+			var pins map[string]string
+			if o.Locked {
+				pins = lf.CommitPins(ref.Name)
+			}
+			ds, err := loader.LoadFromRegistry(ctx, *ref.Registry, ref.Name, ref.Version, pins)
 			if err != nil {
 				return nil, fmt.Errorf("loading dataset %s from registry: %w", ref.Name, err)
 			}
@@ -89,6 +368,71 @@ func (o *JobOrchestrator) Run(ctx context.Context) (*models.JobResult, error) {
 		}
 	}
 
+	// Outside --locked, record exactly what was resolved so a later
+	// --locked run can reproduce it.
+	if !o.Locked && lockPath != "" {
+		generated, err := lockfile.Generate(datasets)
+		if err != nil {
+			slog.Warn("failed to generate rollout.lock", "error", err)
+		} else if err := lockfile.Write(lockPath, generated); err != nil {
+			slog.Warn("failed to write rollout.lock", "error", err)
+		} else {
+			slog.Debug("wrote rollout.lock", "path", lockPath)
+		}
+	}
+
+	if o.Offline {
+		for _, ds := range datasets {
+			for _, t := range ds.Tasks {
+				if t.Config.Env.DockerImage != nil {
+					return nil, fmt.Errorf("offline mode forbids task %q/%q: it sets docker_image %q, which would be pulled over the network; build and load the image locally ahead of time instead", ds.Name, t.Name, *t.Config.Env.DockerImage)
+				}
+			}
+		}
+	}
+
+	// Create job output directory. Held under a lock file for the whole
+	// check-then-create sequence (and the trial directory checks below) so
+	// two rollout processes sharing the same jobs_dir on a shared
+	// filesystem can't both observe "doesn't exist yet" and clobber each
+	// other's directories.
+	jobName := time.Now().Format("2006-01-02__15-04-05")
+	if o.cfg.Name != nil {
+		jobName = *o.cfg.Name
+	}
+	jobDir := filepath.Join(o.cfg.JobsDir, jobName)
+
+	jobLock, err := dirlock.Acquire(jobDir, jobLockTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("acquiring job directory lock: %w", err)
+	}
+
+	if _, err := os.Stat(jobDir); err == nil {
+		jobLock.Release()
+		return nil, fmt.Errorf("job directory already exists: %s (will not overwrite existing results)", jobDir)
+	}
+
+	slog.Debug("creating job output directory", "path", jobDir)
+	if err := os.MkdirAll(jobDir, 0755); err != nil {
+		jobLock.Release()
+		return nil, fmt.Errorf("creating job directory: %w", err)
+	}
+
+	if o.cfg.Mode == models.ModeValidate {
+		jobLock.Release()
+		return o.runValidation(ctx, jobDir, datasets, startTime)
+	}
+
+	// Run the oracle agent once per task before any real agent's trials,
+	// quarantining (excluding from every agent's trials) any task whose own
+	// oracle solution fails - otherwise a broken task drags down every
+	// agent's score identically, masking which failures are the agent's
+	// fault.
+	var quarantined []models.QuarantinedTask
+	if o.cfg.SanityCheck.Enabled {
+		quarantined = o.runSanityCheck(ctx, jobDir, datasets)
+	}
+
 	// Generate trials (Cartesian product of agents × tasks × attempts)
 	var trials []models.Trial
 	for _, agent := range o.cfg.Agents {
@@ -96,7 +440,7 @@ func (o *JobOrchestrator) Run(ctx context.Context) (*models.JobResult, error) {
 			for _, task := range ds.Tasks {
 				for attempt := 1; attempt <= o.cfg.NAttempts; attempt++ {
 					trialID := fmt.Sprintf("%s__%s__%s__%d", agent.Name, ds.Name, task.Name, attempt)
-					outputDir := filepath.Join(o.cfg.JobsDir, agent.Name, ds.Name, fmt.Sprintf("%s__%d", task.Name, attempt))
+					outputDir := filepath.Join(jobDir, agent.Name, ds.Name, fmt.Sprintf("%s__%d", task.Name, attempt))
 
 					trials = append(trials, models.Trial{
 						ID:        trialID,
@@ -116,38 +460,68 @@ func (o *JobOrchestrator) Run(ctx context.Context) (*models.JobResult, error) {
 		"agents", len(o.cfg.Agents),
 		"attempts_per_task", o.cfg.NAttempts)
 
-	// Create job output directory
-	jobName := time.Now().Format("2006-01-02__15-04-05")
-	if o.cfg.Name != nil {
-		jobName = *o.cfg.Name
+	// Save job config, redacting any literal secrets (e.g. inlined in
+	// agent.env) so they don't land in jobs_dir in plaintext.
+	redactor, err := redact.New(o.cfg.Redaction)
+	if err != nil {
+		slog.Warn("invalid redaction config, redaction disabled", "error", err)
 	}
-	jobDir := filepath.Join(o.cfg.JobsDir, jobName)
+	cfgJSON, _ := json.MarshalIndent(o.cfg, "", "  ")
+	os.WriteFile(filepath.Join(jobDir, "config.json"), redactor.Bytes(cfgJSON), 0644)
 
-	if _, err := os.Stat(jobDir); err == nil {
-		return nil, fmt.Errorf("job directory already exists: %s (will not overwrite existing results)", jobDir)
+	// Open the job's audit log; every worker's executor gets a reference to
+	// it below so their provider calls record into it too.
+	auditLogger, err := audit.Open(jobDir)
+	if err != nil {
+		slog.Warn("failed to open audit log, provider operations will not be recorded", "error", err)
 	}
+	o.auditLogger = auditLogger
+	defer auditLogger.Close()
+	o.statusTracker = newStatusTracker(jobDir)
 
-	slog.Debug("creating job output directory", "path", jobDir)
-	if err := os.MkdirAll(jobDir, 0755); err != nil {
-		return nil, fmt.Errorf("creating job directory: %w", err)
+	// Resolve the seed for this run, generating one if the user didn't pin
+	// one in the config, then record provenance for later reproduction.
+	seed := o.cfg.Seed
+	if seed == nil {
+		generated := time.Now().UnixNano()
+		seed = &generated
+		o.cfg.Seed = seed
 	}
+	prov := provenance.Capture(o.cfg, *seed)
+	slog.Debug("writing job provenance", "path", filepath.Join(jobDir, "provenance.json"), "seed", *seed)
+	provJSON, _ := json.MarshalIndent(prov, "", "  ")
+	os.WriteFile(filepath.Join(jobDir, "provenance.json"), redactor.Bytes(provJSON), 0644)
 
-	// Update trial output dirs to include job name
-	for i := range trials {
-		trials[i].OutputDir = filepath.Join(jobDir, trials[i].Agent.Name, trials[i].Dataset, fmt.Sprintf("%s__%d", trials[i].Task.Name, trials[i].Attempt))
+	// Check jobs_dir has enough free space for the estimated trial count
+	// before committing to a run that could fail mid-job with a cryptic
+	// write error once disk fills up.
+	if report, err := diskguard.Check(jobDir, len(trials), o.cfg.DiskGuard); err != nil {
+		slog.Warn("disk guard check failed, proceeding without it", "error", err)
+	} else if !report.OK() {
+		msg := fmt.Sprintf("jobs_dir has %dMB free but this run is estimated to need %dMB (short by %dMB)", report.FreeMB, report.RequiredMB, report.ShortfallMB)
+		if o.cfg.DiskGuard.OnShortfall == "block" {
+			jobLock.Release()
+			return nil, fmt.Errorf("%s; free up space or raise disk_guard.reserve_mb/per_trial_estimate_mb if this estimate is too conservative", msg)
+		}
+		slog.Warn(msg)
 	}
 
-	// Save job config
-	cfgJSON, _ := json.MarshalIndent(o.cfg, "", "  ")
-	os.WriteFile(filepath.Join(jobDir, "config.json"), cfgJSON, 0644)
-
-	// Check that no trial output directories already exist
+	// Check that no trial output directories already exist. Still under
+	// jobLock: trial directories are created lazily per-trial below, but
+	// reserving the whole jobDir here means a second process can't create
+	// an overlapping set of trial directories (e.g. a rerun with the same
+	// job name) between this check and the first trial actually writing.
 	for _, trial := range trials {
 		if _, err := os.Stat(trial.OutputDir); err == nil {
+			jobLock.Release()
 			return nil, fmt.Errorf("trial output directory already exists: %s (will not overwrite existing results)", trial.OutputDir)
 		}
 	}
 
+	if err := jobLock.Release(); err != nil {
+		slog.Warn("releasing job directory lock failed", "error", err)
+	}
+
 	// Execute trials concurrently
 	nWorkers := o.cfg.NConcurrentTrials
 	if nWorkers <= 0 {
@@ -161,14 +535,18 @@ func (o *JobOrchestrator) Run(ctx context.Context) (*models.JobResult, error) {
 		"workers", nWorkers,
 		"total_trials", len(trials))
 
-	results, skipped := o.runConcurrent(ctx, trials, nWorkers)
+	results, cancelledSkipped, budgetSkipped := o.runConcurrent(ctx, trials, nWorkers)
 
 	// Aggregate results
 	jobResult := o.aggregateResults(jobName, results, startTime)
-	jobResult.SkippedTrials = skipped
-	if skipped > 0 {
+	jobResult.SkippedTrials = cancelledSkipped + budgetSkipped
+	jobResult.QuarantinedTasks = quarantined
+	if cancelledSkipped > 0 {
 		jobResult.Cancelled = true
-		slog.Info("job cancelled", "completed", len(results), "skipped", skipped)
+		slog.Info("job cancelled", "completed", len(results), "skipped", cancelledSkipped)
+	}
+	if budgetSkipped > 0 {
+		slog.Info("skipped remaining attempts due to budget caps", "skipped", budgetSkipped)
 	}
 
 	// Save job result
@@ -186,22 +564,50 @@ func (o *JobOrchestrator) Run(ctx context.Context) (*models.JobResult, error) {
 }
 
 // runConcurrent executes trials using a fan-out/fan-in pattern.
-// Returns collected results and count of skipped trials.
-func (o *JobOrchestrator) runConcurrent(ctx context.Context, trials []models.Trial, nWorkers int) ([]*models.TrialResult, int) {
+// Returns collected results, the count of trials skipped due to
+// cancellation, and the count of trials skipped because a budget in
+// o.cfg.Budgets was already exhausted.
+func (o *JobOrchestrator) runConcurrent(ctx context.Context, trials []models.Trial, nWorkers int) ([]*models.TrialResult, int, int) {
 	trialChan := make(chan models.Trial) // unbuffered
 	resultChan := make(chan *models.TrialResult, len(trials))
+	budget := newBudgetTracker(o.cfg.Budgets)
 
 	var wg sync.WaitGroup
+	var budgetMu sync.Mutex
+	var budgetSkippedTrials []models.Trial
 
 	// Start workers
 	for range nWorkers {
 		wg.Go(func() {
 			executor := o.newExecutor(o.cfg)
+			if setter, ok := executor.(snapshotCacheSetter); ok {
+				setter.SetSnapshotCache(o.snapshotCache)
+			}
+			if setter, ok := executor.(debugSetter); ok {
+				setter.SetDebug(o.Debug)
+			}
+			if setter, ok := executor.(auditSetter); ok {
+				setter.SetAuditLogger(o.auditLogger)
+			}
+			if setter, ok := executor.(statusSetter); ok {
+				setter.SetStatusTracker(o.statusTracker)
+			}
 
 			for trial := range trialChan {
+				if ok, reason := budget.allow(trial); !ok {
+					slog.Info("skipping trial, budget exhausted",
+						"task", trial.Task.Name,
+						"agent", trial.Agent.Name,
+						"budget", reason)
+					budgetMu.Lock()
+					budgetSkippedTrials = append(budgetSkippedTrials, trial)
+					budgetMu.Unlock()
+					continue
+				}
+
 				os.MkdirAll(trial.OutputDir, 0755)
 
-				result, err := executor.Execute(ctx, trial, o.provider)
+				result, err, retried := o.executeWithRetry(ctx, executor, trial, o.providerFor(trial.Dataset))
 				if err != nil {
 					slog.Error("trial execution error",
 						"task", trial.Task.Name,
@@ -219,6 +625,18 @@ func (o *JobOrchestrator) runConcurrent(ctx context.Context, trials []models.Tri
 					}
 				}
 
+				switch {
+				case result.Error != nil:
+					result.Status = models.StatusFailed
+				case retried:
+					result.Status = models.StatusRetried
+				default:
+					result.Status = models.StatusCompleted
+				}
+				result.Labels = o.cfg.Labels
+
+				budget.record(trial, result)
+
 				resultJSON, _ := json.MarshalIndent(result, "", "  ")
 				os.WriteFile(filepath.Join(trial.OutputDir, "result.json"), resultJSON, 0644)
 
@@ -231,17 +649,19 @@ func (o *JobOrchestrator) runConcurrent(ctx context.Context, trials []models.Tri
 		})
 	}
 
-	// Feeder goroutine: sends trials to workers, respects context cancellation
-	fed := 0
+	// Feeder goroutine: sends trials to workers, respects context cancellation.
+	// fed tracks exactly which trials were handed to a worker, so trials left
+	// unfed on cancellation can be recorded as skipped rather than just counted.
+	fed := make([]bool, len(trials))
 	go func() {
 		defer close(trialChan)
-		for _, trial := range trials {
+		for i, trial := range trials {
 			select {
 			case <-ctx.Done():
 				slog.Debug("stopping trial feeder due to context cancellation")
 				return
 			case trialChan <- trial:
-				fed++
+				fed[i] = true
 			}
 		}
 	}()
@@ -258,9 +678,256 @@ func (o *JobOrchestrator) runConcurrent(ctx context.Context, trials []models.Tri
 		results = append(results, result)
 	}
 
-	skipped := max(len(trials)-len(results), 0)
+	var cancelledTrials []models.Trial
+	for i, trial := range trials {
+		if !fed[i] {
+			cancelledTrials = append(cancelledTrials, trial)
+		}
+	}
+	o.writeSkippedResults(cancelledTrials, models.StatusCancelled)
+	o.writeSkippedResults(budgetSkippedTrials, models.StatusSkipped)
+
+	return results, len(cancelledTrials), len(budgetSkippedTrials)
+}
+
+// runSanityCheck runs the oracle agent once against every task in datasets,
+// removing (in place) any task whose oracle run errors or falls short of its
+// own pass threshold, and returns a QuarantinedTask entry per removal for
+// the job report. Oracle output for this pass is kept separate from any
+// real "oracle" agent a job might also define, under jobDir/_sanity.
+func (o *JobOrchestrator) runSanityCheck(ctx context.Context, jobDir string, datasets []models.Dataset) []models.QuarantinedTask {
+	executor := o.newExecutor(o.cfg)
+	oracle := models.Agent{Name: "oracle"}
+
+	var quarantined []models.QuarantinedTask
+	for i := range datasets {
+		ds := &datasets[i]
+		var kept []models.Task
+		for _, task := range ds.Tasks {
+			outputDir := filepath.Join(jobDir, "_sanity", ds.Name, task.Name)
+			os.MkdirAll(outputDir, 0755)
+
+			trial := models.Trial{
+				ID:        fmt.Sprintf("_sanity__%s__%s", ds.Name, task.Name),
+				Task:      task,
+				Agent:     oracle,
+				Dataset:   ds.Name,
+				Attempt:   1,
+				OutputDir: outputDir,
+			}
+
+			result, err := executor.Execute(ctx, trial, o.providerFor(ds.Name))
+			reason := ""
+			switch {
+			case err != nil:
+				reason = err.Error()
+			case result.Error != nil:
+				reason = result.Error.Message
+			case result.Reward == nil || *result.Reward < passThreshold(result):
+				reason = fmt.Sprintf("oracle reward did not meet pass threshold: reward=%v", result.Reward)
+			}
+
+			if reason != "" {
+				slog.Warn("quarantining task, oracle sanity check failed",
+					"dataset", ds.Name, "task", task.Name, "reason", reason)
+				quarantined = append(quarantined, models.QuarantinedTask{
+					DatasetName: ds.Name,
+					TaskName:    task.Name,
+					Reason:      reason,
+				})
+				continue
+			}
+
+			kept = append(kept, task)
+		}
+		ds.Tasks = kept
+	}
+
+	return quarantined
+}
+
+// runValidation implements ModeValidate: it builds each task's environment
+// and runs only the oracle solution and verifier against it once (no real
+// agents), producing a per-task health report instead of scoring any
+// agent. Useful as a standalone maintenance job to check that every task in
+// a dataset still builds, has a working solution, and verifies correctly.
+func (o *JobOrchestrator) runValidation(ctx context.Context, jobDir string, datasets []models.Dataset, startTime time.Time) (*models.JobResult, error) {
+	executor := o.newExecutor(o.cfg)
+	oracle := models.Agent{Name: "oracle"}
+
+	var report []models.TaskValidation
+	for _, ds := range datasets {
+		for _, task := range ds.Tasks {
+			outputDir := filepath.Join(jobDir, ds.Name, task.Name)
+			os.MkdirAll(outputDir, 0755)
+
+			trial := models.Trial{
+				ID:        fmt.Sprintf("_validate__%s__%s", ds.Name, task.Name),
+				Task:      task,
+				Agent:     oracle,
+				Dataset:   ds.Name,
+				Attempt:   1,
+				OutputDir: outputDir,
+			}
+
+			result, err := executor.Execute(ctx, trial, o.providerFor(ds.Name))
+			entry := models.TaskValidation{DatasetName: ds.Name, TaskName: task.Name}
+			switch {
+			case err != nil:
+				entry.Error = err.Error()
+			case result.Error != nil:
+				entry.Error = result.Error.Message
+				entry.Cost = result.Cost
+				entry.DurationSec = result.Durations.TotalSec
+			default:
+				entry.Reward = result.Reward
+				entry.Cost = result.Cost
+				entry.DurationSec = result.Durations.TotalSec
+				entry.Passed = result.Reward != nil && *result.Reward >= passThreshold(result)
+			}
+
+			if !entry.Passed {
+				slog.Warn("task validation failed",
+					"dataset", ds.Name, "task", task.Name, "reason", entry.Error)
+			}
+			report = append(report, entry)
+		}
+	}
+
+	jr := &models.JobResult{
+		JobName:          filepath.Base(jobDir),
+		TotalTrials:      len(report),
+		StartedAt:        startTime,
+		EndedAt:          time.Now(),
+		TotalDurationSec: time.Since(startTime).Seconds(),
+		Validation:       report,
+		Labels:           o.cfg.Labels,
+	}
+	for _, e := range report {
+		jr.TotalCost += e.Cost
+		if e.Passed {
+			jr.CompletedTrials++
+		} else {
+			jr.FailedTrials++
+		}
+	}
+	if jr.TotalTrials > 0 {
+		jr.PassRate = float64(jr.CompletedTrials) / float64(jr.TotalTrials)
+	}
+
+	slog.Debug("writing job result", "path", filepath.Join(jobDir, "result.json"))
+	jobResultJSON, _ := json.MarshalIndent(jr, "", "  ")
+	os.WriteFile(filepath.Join(jobDir, "result.json"), jobResultJSON, 0644)
+
+	slog.Info("validation completed",
+		"duration", time.Since(startTime).Round(time.Second),
+		"passed", jr.CompletedTrials,
+		"failed", jr.FailedTrials)
+
+	return jr, nil
+}
+
+// executeWithRetry runs executor.Execute, retrying the trial up to
+// o.cfg.Retry.MaxAttempts times (with the configured backoff) when it fails
+// with models.ErrEnvironmentInterrupted - a preemptible/spot instance
+// reclaimed mid-trial, not a problem with the trial's own image or command,
+// so retrying it fresh is worth the cost. Any other error or a successful
+// result returns immediately. The returned bool reports whether at least
+// one retry happened, so the caller can record models.StatusRetried instead
+// of models.StatusCompleted.
+func (o *JobOrchestrator) executeWithRetry(ctx context.Context, executor TrialExecutor, trial models.Trial, provider environment.Provider) (*models.TrialResult, error, bool) {
+	maxAttempts := o.cfg.Retry.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	delay := time.Duration(o.cfg.Retry.InitialDelayMs) * time.Millisecond
+	maxDelay := time.Duration(o.cfg.Retry.MaxDelayMs) * time.Millisecond
+
+	var result *models.TrialResult
+	var err error
+	var retried bool
+	var wastedCost float64
+	var retryReasons []models.ErrorType
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		result, err = executor.Execute(ctx, trial, provider)
+		if err != nil || result.Error == nil || result.Error.Type != models.ErrEnvironmentInterrupted {
+			if result != nil {
+				result.AttemptCount = attempt
+				result.WastedCost = wastedCost
+				result.RetryReasons = retryReasons
+			}
+			return result, err, retried
+		}
+		if attempt == maxAttempts {
+			result.AttemptCount = attempt
+			result.WastedCost = wastedCost
+			result.RetryReasons = retryReasons
+			return result, err, retried
+		}
+
+		slog.Warn("trial interrupted, retrying",
+			"task", trial.Task.Name,
+			"agent", trial.Agent.Name,
+			"attempt", attempt,
+			"delay", delay)
+		select {
+		case <-ctx.Done():
+			result.AttemptCount = attempt
+			result.WastedCost = wastedCost
+			result.RetryReasons = retryReasons
+			return result, err, retried
+		case <-time.After(delay):
+		}
+		retried = true
+		// The failed attempt's cost (image build + environment time already
+		// spent before it was interrupted) is pure waste - it's discarded
+		// and re-attempted from scratch, contributing nothing to the
+		// eventual result's own Cost.
+		wastedCost += result.Cost
+		retryReasons = append(retryReasons, result.Error.Type)
+
+		if o.cfg.Retry.Multiplier > 0 {
+			delay = time.Duration(float64(delay) * o.cfg.Retry.Multiplier)
+		}
+		if maxDelay > 0 && delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+	return result, err, retried
+}
+
+// writeSkippedResults writes a placeholder result.json with the given
+// status for each trial in trials, so tooling reading the jobs directory
+// can distinguish "never attempted" from trials whose result was otherwise
+// lost.
+func (o *JobOrchestrator) writeSkippedResults(trials []models.Trial, status string) {
+	for _, trial := range trials {
+		result := &models.TrialResult{
+			TaskName:        trial.Task.Name,
+			DatasetName:     trial.Dataset,
+			AgentName:       trial.Agent.Name,
+			Attempt:         trial.Attempt,
+			TaskGitCommitID: trial.Task.GitCommitID,
+			Status:          status,
+			Labels:          o.cfg.Labels,
+		}
 
-	return results, skipped
+		os.MkdirAll(trial.OutputDir, 0755)
+		resultJSON, _ := json.MarshalIndent(result, "", "  ")
+		os.WriteFile(filepath.Join(trial.OutputDir, "result.json"), resultJSON, 0644)
+	}
+}
+
+// passThreshold returns the reward threshold a result counts as a pass
+// against. Results from before pass_threshold existed (or otherwise never
+// reaching a reward) have a nil PassThreshold and default to 1.0,
+// preserving the old reward == 1.0 behavior; this is distinct from a task
+// that explicitly sets pass_threshold = 0.0.
+func passThreshold(r *models.TrialResult) float64 {
+	if r.PassThreshold != nil {
+		return *r.PassThreshold
+	}
+	return 1.0
 }
 
 func (o *JobOrchestrator) aggregateResults(jobName string, results []*models.TrialResult, startTime time.Time) *models.JobResult {
@@ -271,6 +938,7 @@ func (o *JobOrchestrator) aggregateResults(jobName string, results []*models.Tri
 		EndedAt:     time.Now(),
 		Agents:      make(map[string]models.AgentSummary),
 		Results:     make([]models.TrialSummary, 0, len(results)),
+		Labels:      o.cfg.Labels,
 	}
 
 	jr.TotalDurationSec = jr.EndedAt.Sub(jr.StartedAt).Seconds()
@@ -279,36 +947,80 @@ func (o *JobOrchestrator) aggregateResults(jobName string, results []*models.Tri
 	var rewardCount int
 
 	agentData := make(map[string]struct {
-		total     int
-		completed int
-		failed    int
-		rewards   []float64
-		cost      float64
+		total      int
+		completed  int
+		failed     int
+		rewards    []float64
+		thresholds []float64
+		cost       float64
 	})
 
+	type taskAttempts struct {
+		agent, dataset, task string
+		attempts             int
+		passes               int
+	}
+	taskData := make(map[string]*taskAttempts)
+	seenImages := make(map[string]bool)
+
+	var passCount int
+
 	for _, r := range results {
 		ad := agentData[r.AgentName]
 		ad.total++
 		ad.cost += r.Cost
 		jr.TotalCost += r.Cost
 
-		if r.Error != nil {
+		if r.Status == models.StatusRetried {
+			jr.RetriedTrials++
+		}
+		jr.TotalWastedCost += r.WastedCost
+
+		if r.Status == models.StatusFailed {
 			jr.FailedTrials++
 			ad.failed++
+			if o.cfg.Scoring.CountFailuresAsZero {
+				ad.rewards = append(ad.rewards, 0)
+				ad.thresholds = append(ad.thresholds, passThreshold(r))
+				rewardCount++
+			}
 		} else if r.Reward != nil {
 			jr.CompletedTrials++
 			ad.completed++
 			ad.rewards = append(ad.rewards, *r.Reward)
+			ad.thresholds = append(ad.thresholds, passThreshold(r))
 			totalReward += *r.Reward
 			rewardCount++
+
+			passed := *r.Reward >= passThreshold(r)
+			if passed {
+				passCount++
+			}
+
+			tk := r.AgentName + "\x00" + r.DatasetName + "\x00" + r.TaskName
+			ta := taskData[tk]
+			if ta == nil {
+				ta = &taskAttempts{agent: r.AgentName, dataset: r.DatasetName, task: r.TaskName}
+				taskData[tk] = ta
+			}
+			ta.attempts++
+			if passed {
+				ta.passes++
+			}
 		}
 
 		agentData[r.AgentName] = ad
 
+		if r.ImageReport != nil && !seenImages[r.ImageReport.ImageRef] {
+			seenImages[r.ImageReport.ImageRef] = true
+			jr.ImageReports = append(jr.ImageReports, *r.ImageReport)
+		}
+
 		jr.Results = append(jr.Results, models.TrialSummary{
 			TaskName:    r.TaskName,
 			DatasetName: r.DatasetName,
 			AgentName:   r.AgentName,
+			Model:       r.Model,
 			Attempt:     r.Attempt,
 			Reward:      r.Reward,
 		})
@@ -316,17 +1028,33 @@ func (o *JobOrchestrator) aggregateResults(jobName string, results []*models.Tri
 
 	if rewardCount > 0 {
 		jr.MeanReward = totalReward / float64(rewardCount)
+		jr.PassRate = float64(passCount) / float64(rewardCount)
 	}
 
-	var passCount int
-	for _, r := range results {
-		if r.Reward != nil && *r.Reward == 1.0 {
-			passCount++
+	for _, ta := range taskData {
+		if ta.attempts > 1 && ta.passes > 0 && ta.passes < ta.attempts {
+			jr.Flakiness = append(jr.Flakiness, models.FlakinessEntry{
+				AgentName:   ta.agent,
+				DatasetName: ta.dataset,
+				TaskName:    ta.task,
+				Attempts:    ta.attempts,
+				Passes:      ta.passes,
+			})
 		}
 	}
-	if jr.CompletedTrials > 0 {
-		jr.PassRate = float64(passCount) / float64(jr.CompletedTrials)
-	}
+	sort.Slice(jr.Flakiness, func(i, j int) bool {
+		a, b := jr.Flakiness[i], jr.Flakiness[j]
+		if a.AgentName != b.AgentName {
+			return a.AgentName < b.AgentName
+		}
+		if a.DatasetName != b.DatasetName {
+			return a.DatasetName < b.DatasetName
+		}
+		return a.TaskName < b.TaskName
+	})
+	sort.Slice(jr.ImageReports, func(i, j int) bool {
+		return jr.ImageReports[i].ImageRef < jr.ImageReports[j].ImageRef
+	})
 
 	for agentName, ad := range agentData {
 		var meanReward float64
@@ -339,13 +1067,13 @@ func (o *JobOrchestrator) aggregateResults(jobName string, results []*models.Tri
 
 		var passRate float64
 		var passes int
-		for _, r := range ad.rewards {
-			if r == 1.0 {
+		for i, r := range ad.rewards {
+			if r >= ad.thresholds[i] {
 				passes++
 			}
 		}
-		if ad.completed > 0 {
-			passRate = float64(passes) / float64(ad.completed)
+		if len(ad.rewards) > 0 {
+			passRate = float64(passes) / float64(len(ad.rewards))
 		}
 
 		jr.Agents[agentName] = models.AgentSummary{
@@ -363,11 +1091,23 @@ func (o *JobOrchestrator) aggregateResults(jobName string, results []*models.Tri
 
 // DefaultTrialExecutorFunc creates a default trial executor.
 func DefaultTrialExecutorFunc(cfg models.JobConfig) TrialExecutor {
-	return NewTrialExecutor(cfg.InstructionPath, cfg.TimeoutMultiplier, cfg.Verifier, cfg.Environment)
+	redactor, err := redact.New(cfg.Redaction)
+	if err != nil {
+		// config.LoadJobConfig already validates patterns, so this should be
+		// unreachable in practice; fall back to no redaction rather than
+		// failing the whole job over it.
+		slog.Warn("invalid redaction config, redaction disabled", "error", err)
+	}
+	return NewTrialExecutor(cfg.InstructionPath, cfg.TimeoutMultiplier, cfg.Verifier, cfg.Environment, cfg.Diff, cfg.Seed, redactor)
 }
 
-// RunFromConfig loads a job config file and executes the job.
-func RunFromConfig(ctx context.Context, configPath string) (*models.JobResult, error) {
+// RunFromConfig loads a job config file and executes the job. When locked is
+// true, the job requires a rollout.lock next to configPath and pins registry
+// dataset resolution to exactly the git commits it records. When offline is
+// true, any step that would need network access fails fast instead. When
+// debug is true, a trial whose install or execute phase fails drops into an
+// interactive shell in its environment before teardown.
+func RunFromConfig(ctx context.Context, configPath string, locked, offline, debug bool) (*models.JobResult, error) {
 	slog.Info("loading job config", "path", configPath)
 	cfg, err := config.LoadJobConfig(configPath)
 	if err != nil {
@@ -381,10 +1121,28 @@ func RunFromConfig(ctx context.Context, configPath string) (*models.JobResult, e
 	if err != nil {
 		return nil, fmt.Errorf("creating orchestrator: %w", err)
 	}
+	orchestrator.ConfigPath = configPath
+	orchestrator.Locked = locked
+	orchestrator.Offline = offline
+	orchestrator.Debug = debug
+	defer func() {
+		if err := orchestrator.Close(ctx); err != nil {
+			slog.Warn("closing orchestrator failed", "error", err)
+		}
+	}()
 
 	return orchestrator.Run(ctx)
 }
 
+// lockPath returns the rollout.lock path alongside ConfigPath, or "" if
+// ConfigPath is unset (e.g. orchestrators built directly in tests).
+func (o *JobOrchestrator) lockPath() string {
+	if o.ConfigPath == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(o.ConfigPath), lockfile.FileName)
+}
+
 // configureLogging sets up slog based on the log level from job config.
 func configureLogging(level string) {
 	var logLevel slog.Level
@@ -0,0 +1,99 @@
+package executor
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/spachava753/rollout/internal/environment"
+	"github.com/spachava753/rollout/internal/models"
+)
+
+// resourceMonitor periodically samples an environment's resource usage on a
+// background goroutine and aggregates the samples into a
+// models.ResourceUsageSummary.
+type resourceMonitor struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	stopped bool
+	summary *models.ResourceUsageSummary
+
+	samples int
+	peakCPU float64
+	sumCPU  float64
+	peakMem float64
+	sumMem  float64
+}
+
+// startResourceMonitor begins sampling env every interval until Stop is
+// called. It returns nil if env does not implement environment.ResourceSampler
+// or interval is zero, in which case there is nothing to monitor.
+func startResourceMonitor(env environment.Environment, interval time.Duration, logger *slog.Logger) *resourceMonitor {
+	sampler, ok := env.(environment.ResourceSampler)
+	if !ok || interval <= 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &resourceMonitor{cancel: cancel, done: make(chan struct{})}
+
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				usage, err := sampler.Stats(ctx)
+				if err != nil {
+					logger.Debug("resource sample failed", "error", err)
+					continue
+				}
+				m.record(usage)
+			}
+		}
+	}()
+
+	return m
+}
+
+// record is only ever called from the sampling goroutine, so it needs no
+// synchronization of its own; Stop waits on m.done before reading the
+// accumulated fields.
+func (m *resourceMonitor) record(usage environment.ResourceUsage) {
+	m.samples++
+	m.sumCPU += usage.CPUPercent
+	m.sumMem += usage.MemoryMB
+	if usage.CPUPercent > m.peakCPU {
+		m.peakCPU = usage.CPUPercent
+	}
+	if usage.MemoryMB > m.peakMem {
+		m.peakMem = usage.MemoryMB
+	}
+}
+
+// Stop stops sampling and returns the aggregated summary, or nil if no
+// samples were collected. Safe to call more than once; later calls return
+// the same summary without re-stopping anything.
+func (m *resourceMonitor) Stop() *models.ResourceUsageSummary {
+	if m.stopped {
+		return m.summary
+	}
+	m.stopped = true
+	m.cancel()
+	<-m.done
+
+	if m.samples > 0 {
+		m.summary = &models.ResourceUsageSummary{
+			SampleCount:    m.samples,
+			PeakCPUPercent: m.peakCPU,
+			MeanCPUPercent: m.sumCPU / float64(m.samples),
+			PeakMemoryMB:   m.peakMem,
+			MeanMemoryMB:   m.sumMem / float64(m.samples),
+		}
+	}
+	return m.summary
+}
@@ -0,0 +1,47 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/spachava753/rollout/internal/environment"
+)
+
+func TestResourceMonitorAggregation(t *testing.T) {
+	m := &resourceMonitor{done: make(chan struct{})}
+	close(m.done)
+	m.cancel = func() {}
+
+	m.record(environment.ResourceUsage{CPUPercent: 10, MemoryMB: 100})
+	m.record(environment.ResourceUsage{CPUPercent: 30, MemoryMB: 50})
+
+	summary := m.Stop()
+	if summary == nil {
+		t.Fatal("expected a summary after recording samples")
+	}
+	if summary.SampleCount != 2 {
+		t.Errorf("expected 2 samples, got %d", summary.SampleCount)
+	}
+	if summary.PeakCPUPercent != 30 {
+		t.Errorf("expected peak cpu 30, got %v", summary.PeakCPUPercent)
+	}
+	if summary.MeanCPUPercent != 20 {
+		t.Errorf("expected mean cpu 20, got %v", summary.MeanCPUPercent)
+	}
+	if summary.PeakMemoryMB != 100 {
+		t.Errorf("expected peak memory 100, got %v", summary.PeakMemoryMB)
+	}
+	if summary.MeanMemoryMB != 75 {
+		t.Errorf("expected mean memory 75, got %v", summary.MeanMemoryMB)
+	}
+
+	// Stop is idempotent.
+	if again := m.Stop(); again != summary {
+		t.Errorf("expected second Stop() to return the same summary")
+	}
+}
+
+func TestStartResourceMonitorNoSampler(t *testing.T) {
+	if m := startResourceMonitor(nil, 0, nil); m != nil {
+		t.Errorf("expected nil monitor when interval is 0")
+	}
+}
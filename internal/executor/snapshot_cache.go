@@ -0,0 +1,75 @@
+package executor
+
+import (
+	"sync"
+
+	"github.com/spachava753/rollout/internal/audit"
+)
+
+// SnapshotCache remembers, per (dataset, task, agent) trio, the image
+// reference of the first successful post-install environment.Snapshotter
+// snapshot taken for it, so later attempts of the same task/agent pair can
+// start straight from the snapshot instead of rebuilding an image and
+// reinstalling the agent from scratch. Safe for concurrent use by the
+// multiple worker goroutines a job runs trials on.
+type SnapshotCache struct {
+	mu   sync.Mutex
+	refs map[string]string
+}
+
+// NewSnapshotCache creates an empty SnapshotCache.
+func NewSnapshotCache() *SnapshotCache {
+	return &SnapshotCache{refs: make(map[string]string)}
+}
+
+func snapshotCacheKey(dataset, task, agent string) string {
+	return dataset + "/" + task + "/" + agent
+}
+
+// Get returns the cached snapshot ref for (dataset, task, agent), if any.
+func (c *SnapshotCache) Get(dataset, task, agent string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ref, ok := c.refs[snapshotCacheKey(dataset, task, agent)]
+	return ref, ok
+}
+
+// Store records ref as the snapshot for (dataset, task, agent). If a ref is
+// already stored for that trio, it's kept as-is: the first successful
+// attempt to finish installing wins, so concurrent attempts of the same
+// task/agent racing to snapshot don't clobber each other.
+func (c *SnapshotCache) Store(dataset, task, agent, ref string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := snapshotCacheKey(dataset, task, agent)
+	if _, exists := c.refs[key]; exists {
+		return
+	}
+	c.refs[key] = ref
+}
+
+// snapshotCacheSetter is implemented by TrialExecutors that can reuse a
+// job-wide SnapshotCache across the independently-constructed executor
+// instance each worker goroutine gets from NewTrialExecutorFunc. Executors
+// that don't implement it (e.g. test mocks) simply never have one wired in,
+// which just means they never benefit from snapshot reuse.
+type snapshotCacheSetter interface {
+	SetSnapshotCache(c *SnapshotCache)
+}
+
+// debugSetter is implemented by TrialExecutors that can drop into an
+// interactive debug shell on a failed phase. Executors that don't
+// implement it (e.g. test mocks) simply never have --debug wired in.
+type debugSetter interface {
+	SetDebug(debug bool)
+}
+
+// auditSetter is implemented by TrialExecutors that can record provider
+// operations into a job-wide audit.Logger shared across the
+// independently-constructed executor instance each worker goroutine gets
+// from NewTrialExecutorFunc. Executors that don't implement it (e.g. test
+// mocks) simply never have one wired in, which just means their providers
+// never get an audit.Logger to record into.
+type auditSetter interface {
+	SetAuditLogger(l *audit.Logger)
+}
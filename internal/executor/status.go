@@ -0,0 +1,221 @@
+package executor
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/spachava753/rollout/internal/models"
+	"github.com/spachava753/rollout/internal/redact"
+)
+
+// StatusFileName is the live trial snapshot's filename within a job
+// directory. Unlike audit.jsonl, it's overwritten in place on every update
+// rather than appended to, so `rollout trials` always reads
+// the current state of a running job.
+const StatusFileName = "status.json"
+
+// statusOutputLines is how many of a trial's most recent install/execute
+// output lines StatusTracker keeps around per trial, enough for an operator
+// to see what a stuck trial was last doing without holding its whole
+// transcript in memory.
+const statusOutputLines = 20
+
+// TrialStatus is one trial's in-flight state, as reported by a running
+// job's StatusTracker and persisted to jobDir/status.json.
+type TrialStatus struct {
+	TrialID     string `json:"trial_id"`
+	TaskName    string `json:"task_name"`
+	AgentName   string `json:"agent_name"`
+	DatasetName string `json:"dataset_name"`
+	Attempt     int    `json:"attempt"`
+
+	Phase          string    `json:"phase"`
+	PhaseStartedAt time.Time `json:"phase_started_at"`
+
+	EnvironmentID       string `json:"environment_id,omitempty"`
+	EnvironmentProvider string `json:"environment_provider,omitempty"`
+
+	// RecentOutput holds the last statusOutputLines lines written to the
+	// current phase's stdout/stderr, oldest first.
+	RecentOutput []string `json:"recent_output,omitempty"`
+}
+
+// StatusTracker tracks every trial currently in flight for a job, so an
+// operator can see where a stuck job is stuck via `rollout trials` while it
+// runs. Safe for concurrent use by the multiple worker goroutines a job
+// runs trials on. A nil *StatusTracker is valid and a no-op, so callers can
+// hold one unconditionally without checking whether it's wired in.
+type StatusTracker struct {
+	mu     sync.Mutex
+	trials map[string]*TrialStatus
+
+	// path is jobDir/status.json. Empty disables persistence to disk, which
+	// just means Snapshot is only observable in-process (e.g. in tests).
+	path string
+}
+
+// newStatusTracker creates a StatusTracker that persists to jobDir/status.json
+// on every update.
+func newStatusTracker(jobDir string) *StatusTracker {
+	return &StatusTracker{
+		trials: make(map[string]*TrialStatus),
+		path:   filepath.Join(jobDir, StatusFileName),
+	}
+}
+
+// start records trial as in flight, with no phase yet.
+func (t *StatusTracker) start(trial models.Trial) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.trials[trial.ID] = &TrialStatus{
+		TrialID:     trial.ID,
+		TaskName:    trial.Task.Name,
+		AgentName:   trial.Agent.Name,
+		DatasetName: trial.Dataset,
+		Attempt:     trial.Attempt,
+	}
+	t.mu.Unlock()
+	t.persist()
+}
+
+// setPhase records trialID as having entered phase, resetting the elapsed
+// time and recent-output tracking for the new phase.
+func (t *StatusTracker) setPhase(trialID, phase string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	if s, ok := t.trials[trialID]; ok {
+		s.Phase = phase
+		s.PhaseStartedAt = time.Now()
+		s.RecentOutput = nil
+	}
+	t.mu.Unlock()
+	t.persist()
+}
+
+// setEnvironment records the environment a trial is running in, once its
+// setup phase has created one.
+func (t *StatusTracker) setEnvironment(trialID, envID, provider string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	if s, ok := t.trials[trialID]; ok {
+		s.EnvironmentID = envID
+		s.EnvironmentProvider = provider
+	}
+	t.mu.Unlock()
+	t.persist()
+}
+
+// appendOutput records a chunk of output written during trialID's current
+// phase, keeping only the last statusOutputLines lines.
+func (t *StatusTracker) appendOutput(trialID string, chunk []byte) {
+	if t == nil || len(chunk) == 0 {
+		return
+	}
+	lines := splitLines(chunk)
+	t.mu.Lock()
+	if s, ok := t.trials[trialID]; ok {
+		s.RecentOutput = append(s.RecentOutput, lines...)
+		if len(s.RecentOutput) > statusOutputLines {
+			s.RecentOutput = s.RecentOutput[len(s.RecentOutput)-statusOutputLines:]
+		}
+	}
+	t.mu.Unlock()
+	t.persist()
+}
+
+// finish removes trialID from the in-flight set: it has either completed or
+// failed and has a result.json of its own now.
+func (t *StatusTracker) finish(trialID string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	delete(t.trials, trialID)
+	t.mu.Unlock()
+	t.persist()
+}
+
+// Snapshot returns the current in-flight trials, in no particular order.
+func (t *StatusTracker) Snapshot() []TrialStatus {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]TrialStatus, 0, len(t.trials))
+	for _, s := range t.trials {
+		out = append(out, *s)
+	}
+	return out
+}
+
+// persist writes the current snapshot to jobDir/status.json, best-effort:
+// a running job's correctness never depends on this file, so a write
+// failure is only logged.
+func (t *StatusTracker) persist() {
+	if t == nil || t.path == "" {
+		return
+	}
+	data, err := json.MarshalIndent(t.Snapshot(), "", "  ")
+	if err != nil {
+		slog.Debug("marshaling trial status snapshot failed", "error", err)
+		return
+	}
+	if err := os.WriteFile(t.path, data, 0644); err != nil {
+		slog.Debug("writing trial status snapshot failed", "error", err)
+	}
+}
+
+// statusWriter is an io.Writer that feeds every Write into a StatusTracker's
+// recent-output ring buffer for trialID, used to tee an in-flight phase's
+// stdout/stderr without changing how it's otherwise captured. Writes are
+// redacted the same way the buffered stdout/stderr written to
+// command/stdout.txt are, since RecentOutput is itself persisted to
+// jobDir/status.json and echoed verbatim by `rollout trials`.
+type statusWriter struct {
+	tracker  *StatusTracker
+	trialID  string
+	redactor *redact.Redactor
+}
+
+func (w statusWriter) Write(p []byte) (int, error) {
+	w.tracker.appendOutput(w.trialID, w.redactor.Bytes(p))
+	return len(p), nil
+}
+
+// splitLines splits chunk on newlines, dropping a trailing empty line left
+// by a final "\n", so appendOutput doesn't record a spurious blank entry.
+func splitLines(chunk []byte) []string {
+	lines := make([]string, 0, 4)
+	start := 0
+	for i, b := range chunk {
+		if b == '\n' {
+			lines = append(lines, string(chunk[start:i]))
+			start = i + 1
+		}
+	}
+	if start < len(chunk) {
+		lines = append(lines, string(chunk[start:]))
+	}
+	return lines
+}
+
+// statusSetter is implemented by TrialExecutors that can report their
+// trials' live progress into a job-wide StatusTracker shared across the
+// independently-constructed executor instance each worker goroutine gets
+// from NewTrialExecutorFunc. Executors that don't implement it (e.g. test
+// mocks) simply never have one wired in, which just means their trials
+// never show up in `rollout trials`.
+type statusSetter interface {
+	SetStatusTracker(t *StatusTracker)
+}
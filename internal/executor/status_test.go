@@ -0,0 +1,34 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spachava753/rollout/internal/models"
+	"github.com/spachava753/rollout/internal/redact"
+)
+
+func TestStatusWriterRedactsBeforeRecording(t *testing.T) {
+	redactor, err := redact.New(models.RedactionConfig{Enabled: true, Secrets: []string{"sk-abc123"}})
+	if err != nil {
+		t.Fatalf("redact.New: %v", err)
+	}
+
+	tracker := newStatusTracker(t.TempDir())
+	tracker.start(models.Trial{ID: "trial-1"})
+	w := statusWriter{tracker: tracker, trialID: "trial-1", redactor: redactor}
+
+	if _, err := w.Write([]byte("api key is sk-abc123\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	snap := tracker.Snapshot()
+	if len(snap) != 1 {
+		t.Fatalf("expected 1 tracked trial, got %d", len(snap))
+	}
+	for _, line := range snap[0].RecentOutput {
+		if strings.Contains(line, "sk-abc123") {
+			t.Errorf("expected secret to be redacted from RecentOutput, got %q", line)
+		}
+	}
+}
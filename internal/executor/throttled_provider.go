@@ -0,0 +1,62 @@
+package executor
+
+import (
+	"context"
+
+	"github.com/spachava753/rollout/internal/environment"
+)
+
+// throttledProvider wraps a Provider, capping how many BuildImage and
+// CreateEnvironment calls run concurrently, independent of how many trial
+// workers are running. PullImage and the returned Environment's own methods
+// are left ungated: the cap is specifically for the part of environment
+// setup providers like docker (CPU/disk contention from concurrent builds)
+// or local-VM providers can't scale past, even when the job runs many
+// trials in parallel.
+type throttledProvider struct {
+	environment.Provider
+	sem chan struct{}
+}
+
+// newThrottledProvider wraps provider so at most maxConcurrent BuildImage or
+// CreateEnvironment calls run at once. maxConcurrent <= 0 disables
+// throttling, returning provider unwrapped.
+func newThrottledProvider(provider environment.Provider, maxConcurrent int) environment.Provider {
+	if maxConcurrent <= 0 {
+		return provider
+	}
+	return &throttledProvider{Provider: provider, sem: make(chan struct{}, maxConcurrent)}
+}
+
+func (p *throttledProvider) acquire(ctx context.Context) error {
+	select {
+	case p.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *throttledProvider) release() {
+	<-p.sem
+}
+
+// BuildImage queues behind maxConcurrent other in-flight BuildImage/
+// CreateEnvironment calls before delegating to the wrapped provider.
+func (p *throttledProvider) BuildImage(ctx context.Context, opts environment.BuildImageOptions) (string, error) {
+	if err := p.acquire(ctx); err != nil {
+		return "", err
+	}
+	defer p.release()
+	return p.Provider.BuildImage(ctx, opts)
+}
+
+// CreateEnvironment queues behind maxConcurrent other in-flight BuildImage/
+// CreateEnvironment calls before delegating to the wrapped provider.
+func (p *throttledProvider) CreateEnvironment(ctx context.Context, opts environment.CreateEnvironmentOptions) (environment.Environment, error) {
+	if err := p.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer p.release()
+	return p.Provider.CreateEnvironment(ctx, opts)
+}
@@ -0,0 +1,59 @@
+package executor
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spachava753/rollout/internal/environment"
+)
+
+// trackingProvider counts how many BuildImage calls are in flight at once,
+// recording the highest concurrency it observed.
+type trackingProvider struct {
+	environment.Provider
+	inFlight int32
+	maxSeen  int32
+}
+
+func (p *trackingProvider) BuildImage(ctx context.Context, opts environment.BuildImageOptions) (string, error) {
+	n := atomic.AddInt32(&p.inFlight, 1)
+	for {
+		maxSeen := atomic.LoadInt32(&p.maxSeen)
+		if n <= maxSeen || atomic.CompareAndSwapInt32(&p.maxSeen, maxSeen, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	atomic.AddInt32(&p.inFlight, -1)
+	return opts.Tag, nil
+}
+
+func TestThrottledProviderCapsConcurrentBuildImage(t *testing.T) {
+	inner := &trackingProvider{}
+	provider := newThrottledProvider(inner, 2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			provider.BuildImage(context.Background(), environment.BuildImageOptions{Tag: "t"})
+		}()
+	}
+	wg.Wait()
+
+	if inner.maxSeen > 2 {
+		t.Errorf("maxSeen concurrent BuildImage calls = %d, want <= 2", inner.maxSeen)
+	}
+}
+
+func TestNewThrottledProviderDisabledWhenZero(t *testing.T) {
+	inner := &trackingProvider{}
+	provider := newThrottledProvider(inner, 0)
+	if provider != inner {
+		t.Error("expected maxConcurrent <= 0 to return the provider unwrapped")
+	}
+}
@@ -3,38 +3,339 @@ package executor
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"io"
 	"io/fs"
 	"log/slog"
 	"maps"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/spachava753/rollout/internal/audit"
 	"github.com/spachava753/rollout/internal/environment"
 	"github.com/spachava753/rollout/internal/models"
+	"github.com/spachava753/rollout/internal/redact"
+	"github.com/spachava753/rollout/internal/scanner"
+	"github.com/spachava753/rollout/internal/util"
 )
 
+// errImageDigestMismatch marks an error from setupEnvironment as a digest
+// mismatch rather than a generic pull/build failure, so its caller can
+// classify it as models.ErrEnvironmentImageDigestMismatch.
+var errImageDigestMismatch = errors.New("pulled image does not match pinned digest")
+
+// Phase names recorded in models.TrialResult.Phases, in execution order.
+const (
+	phaseEnvironmentSetup = "environment_setup"
+	phaseAgentInstall     = "agent_install"
+	phaseAgentExecution   = "agent_execution"
+	phaseVerifier         = "verifier"
+	phaseTeardown         = "teardown"
+)
+
+// logCollectionRetries is how many times in total collectLogs tries
+// CopyFrom for a single /logs entry, the first try included, before giving
+// up on it.
+const logCollectionRetries = 3
+
+// logCollectionRetryDelay is the fixed delay between collectLogs retries.
+const logCollectionRetryDelay = 2 * time.Second
+
+// logCollectionConcurrency bounds how many /logs entries collectLogs copies
+// at once, so a trial whose verifier leaves behind thousands of small
+// artifacts doesn't spawn thousands of concurrent CopyFrom calls against
+// the same environment.
+const logCollectionConcurrency = 8
+
+// collectLogs copies every top-level entry under /logs in env into logsDir,
+// through a bounded pool of concurrent CopyFrom calls so a trial with a
+// large log tree (per-step agent logs, verifier artifacts) doesn't
+// serialize on the slowest entry without also overwhelming the environment
+// with unbounded concurrent copies. Each entry is tried up to
+// logCollectionRetries times before being recorded as a failure; a failure
+// on one entry doesn't stop the others from being collected. Returns a
+// human-readable message per entry that never succeeded, empty if
+// everything was collected.
+func collectLogs(ctx context.Context, env environment.Environment, logsDir string, logger *slog.Logger) []string {
+	var lsOut bytes.Buffer
+	if _, err := env.Exec(ctx, "ls -A1 /logs", &lsOut, &lsOut, environment.ExecOptions{}); err != nil {
+		return []string{fmt.Sprintf("listing /logs: %s", err)}
+	}
+
+	var entries []string
+	for _, line := range strings.Split(lsOut.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			entries = append(entries, line)
+		}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	logger.Debug("collecting logs", "entries", len(entries), "concurrency", logCollectionConcurrency)
+
+	var (
+		mu        sync.Mutex
+		failures  []string
+		completed int
+		wg        sync.WaitGroup
+		sem       = make(chan struct{}, logCollectionConcurrency)
+	)
+	for _, entry := range entries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(entry string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			src := "/logs/" + entry
+			dst := filepath.Join(logsDir, entry)
+
+			var lastErr error
+			for attempt := 1; attempt <= logCollectionRetries; attempt++ {
+				lastErr = env.CopyFrom(ctx, src, dst)
+				if lastErr == nil {
+					break
+				}
+				logger.Debug("retrying log collection", "entry", entry, "attempt", attempt, "error", lastErr)
+				if attempt < logCollectionRetries {
+					time.Sleep(logCollectionRetryDelay)
+				}
+			}
+
+			mu.Lock()
+			completed++
+			if lastErr != nil {
+				failures = append(failures, fmt.Sprintf("%s: %s", entry, lastErr))
+			}
+			logger.Debug("log collection progress", "completed", completed, "total", len(entries))
+			mu.Unlock()
+		}(entry)
+	}
+	wg.Wait()
+
+	sort.Strings(failures)
+	return failures
+}
+
+// newPhaseResult builds a models.PhaseResult for a phase that ran an exec
+// step. exitCode of -1 means the phase was skipped (no exit code to report);
+// any other value is recorded. Status is derived from phaseErr rather than
+// passed explicitly so callers can't drift it out of sync with result.Error.
+func newPhaseResult(name string, exitCode int, stdoutPath, stderrPath string, phaseErr *models.TrialError) models.PhaseResult {
+	status := "completed"
+	if phaseErr != nil {
+		status = "failed"
+	}
+	pr := models.PhaseResult{
+		Name:       name,
+		Status:     status,
+		StdoutPath: stdoutPath,
+		StderrPath: stderrPath,
+	}
+	if exitCode >= 0 {
+		pr.ExitCode = &exitCode
+	}
+	return pr
+}
+
+// hashDir returns a sha256 hex digest over every regular file under path,
+// keyed by its path relative to path so a renamed-but-identical file still
+// changes the digest. Order-independent: file paths are sorted before
+// hashing so walk order doesn't affect the result.
+func hashDir(path string) (string, error) {
+	var files []string
+	if err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, p)
+		}
+		return nil
+	}); err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+
+	h := sha256.New()
+	for _, f := range files {
+		rel, err := filepath.Rel(path, f)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s\x00", rel)
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return "", err
+		}
+		h.Write(data)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
 // DefaultTrialExecutor runs a single trial through all phases.
 type DefaultTrialExecutor struct {
 	InstructionPath   string
 	TimeoutMultiplier float64
 	VerifierConfig    models.JobVerifierConfig
 	EnvOverrides      models.JobEnvironmentConfig
+	DiffConfig        models.DiffConfig
+	// Seed is the job's resolved reproducibility seed, exposed to the
+	// agent's execute step as ROLLOUT_SEED. Nil if the job has none.
+	Seed *int64
+
+	// copiedHashes remembers, per environment ID and destination path, the
+	// content hash last copied there via copyIfChanged. Every trial today
+	// creates a fresh environment, so this is always a miss in practice;
+	// it exists so a pooled or snapshot-reused environment (one whose ID
+	// this executor sees again across trials) skips re-copying an unchanged
+	// instruction.md or tests/ tree instead of paying the copy cost again.
+	copiedHashes map[string]map[string]string
+
+	// snapshotCache, if set, lets setupEnvironment start a trial straight
+	// from an earlier trial's post-install environment.Snapshotter snapshot
+	// instead of building an image and reinstalling the agent from scratch.
+	// Wired in by JobOrchestrator via SetSnapshotCache; nil (the default)
+	// disables snapshot reuse entirely.
+	snapshotCache *SnapshotCache
+
+	// debug, when true, drops into an interactive shell in a trial's
+	// environment on a failed install or execute phase, so a task author
+	// can poke around before the deferred teardown destroys it. Wired in
+	// by JobOrchestrator via SetDebug from the --debug CLI flag; only
+	// makes sense with a single trial in flight at a time, since every
+	// worker goroutine would otherwise try to use the terminal at once.
+	debug bool
+
+	// Redactor scrubs captured install/execute/verifier stdout and stderr
+	// before they're written under trial.OutputDir. Nil (the default)
+	// disables redaction entirely.
+	Redactor *redact.Redactor
+
+	// auditLogger, if set, is passed to every provider call this executor's
+	// trials make, so image builds, container creates, execs, copies, and
+	// destroys are recorded into the job's audit.jsonl. Wired in by
+	// JobOrchestrator via SetAuditLogger; nil disables auditing entirely.
+	auditLogger *audit.Logger
+
+	// statusTracker, if set, is updated with every trial's current phase,
+	// environment, and recent output as it runs, so `rollout trials` can
+	// show where a stuck job is stuck. Wired in by JobOrchestrator via
+	// SetStatusTracker; nil disables live status reporting entirely.
+	statusTracker *StatusTracker
+}
+
+// SetDebug wires in the --debug flag's value. Satisfies debugSetter.
+func (e *DefaultTrialExecutor) SetDebug(debug bool) {
+	e.debug = debug
+}
+
+// SetAuditLogger wires in the job's shared audit.Logger. Satisfies
+// auditSetter.
+func (e *DefaultTrialExecutor) SetAuditLogger(l *audit.Logger) {
+	e.auditLogger = l
+}
+
+// debugDrop, when e.debug is set and env supports interactive exec, prints
+// reason and starts an interactive shell in env wired to the process's own
+// terminal, blocking until the user exits it. A no-op otherwise.
+func (e *DefaultTrialExecutor) debugDrop(ctx context.Context, env environment.Environment, reason string, logger *slog.Logger) {
+	if !e.debug || env == nil {
+		return
+	}
+	interactive, ok := env.(environment.InteractiveExecutor)
+	if !ok {
+		logger.Warn("--debug set but environment provider has no interactive exec support, skipping debug shell")
+		return
+	}
+
+	fmt.Printf("\n--debug: %s\ndropping into a shell in environment %s (exit to continue and tear it down)\n", reason, env.ID())
+	if err := interactive.ExecInteractive(ctx, "bash", os.Stdin, os.Stdout, os.Stderr); err != nil {
+		logger.Warn("interactive debug shell failed", "error", err)
+	}
+}
+
+// SetSnapshotCache wires in the job-wide cache of post-install environment
+// snapshots shared across every worker's independently-constructed
+// executor. Satisfies snapshotCacheSetter.
+func (e *DefaultTrialExecutor) SetSnapshotCache(c *SnapshotCache) {
+	e.snapshotCache = c
+}
+
+// SetStatusTracker wires in the job-wide live trial status tracker shared
+// across every worker's independently-constructed executor. Satisfies
+// statusSetter.
+func (e *DefaultTrialExecutor) SetStatusTracker(t *StatusTracker) {
+	e.statusTracker = t
 }
 
 // NewTrialExecutor creates a new trial executor.
-func NewTrialExecutor(instructionPath string, timeoutMult float64, verifierCfg models.JobVerifierConfig, envOverrides models.JobEnvironmentConfig) *DefaultTrialExecutor {
+func NewTrialExecutor(instructionPath string, timeoutMult float64, verifierCfg models.JobVerifierConfig, envOverrides models.JobEnvironmentConfig, diffCfg models.DiffConfig, seed *int64, redactor *redact.Redactor) *DefaultTrialExecutor {
 	return &DefaultTrialExecutor{
 		InstructionPath:   instructionPath,
 		TimeoutMultiplier: timeoutMult,
 		VerifierConfig:    verifierCfg,
 		EnvOverrides:      envOverrides,
+		DiffConfig:        diffCfg,
+		Seed:              seed,
+		Redactor:          redactor,
 	}
 }
 
+// libfaketimePreloadPath is libfaketime's standard install location on
+// Debian/Ubuntu glibc-amd64 images, set as LD_PRELOAD when a task configures
+// environment.fake_time. Images installing libfaketime somewhere else (or
+// targeting a different libc/arch) need to set LD_PRELOAD themselves.
+const libfaketimePreloadPath = "/usr/lib/x86_64-linux-gnu/faketime/libfaketime.so.1"
+
+// trialSeed derives a per-trial seed from the job's resolved seed and this
+// trial's ID, so every trial a stochastic agent or verifier sees gets a
+// distinct value instead of every attempt rolling the identical dice, while
+// staying reproducible: the same job seed and trial ID always derive the
+// same per-trial seed.
+func trialSeed(jobSeed int64, trialID string) int64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d:%s", jobSeed, trialID)
+	return int64(h.Sum64())
+}
+
+// copyIfChanged copies localPath (a file or directory) to dst in env,
+// skipping the copy if contentHash already matches what was last copied to
+// that (env ID, dst) pair by this executor - letting a pooled or
+// snapshot-reused environment skip re-copying unchanged instruction.md or
+// tests/ content across trials instead of paying CopyTo's cost every time.
+func (e *DefaultTrialExecutor) copyIfChanged(ctx context.Context, env environment.Environment, localPath, dst, contentHash string) error {
+	envID := env.ID()
+	if e.copiedHashes == nil {
+		e.copiedHashes = make(map[string]map[string]string)
+	}
+	dests, ok := e.copiedHashes[envID]
+	if !ok {
+		dests = make(map[string]string)
+		e.copiedHashes[envID] = dests
+	}
+	if dests[dst] == contentHash {
+		return nil
+	}
+
+	if err := env.CopyTo(ctx, localPath, dst); err != nil {
+		return err
+	}
+	dests[dst] = contentHash
+	return nil
+}
+
 // Execute runs the trial and returns the result.
 func (e *DefaultTrialExecutor) Execute(ctx context.Context, trial models.Trial, provider environment.Provider) (*models.TrialResult, error) {
 	logger := slog.With(
@@ -45,25 +346,32 @@ func (e *DefaultTrialExecutor) Execute(ctx context.Context, trial models.Trial,
 	)
 
 	logger.Info("starting trial")
-	
+
 	result := &models.TrialResult{
 		TaskName:        trial.Task.Name,
 		DatasetName:     trial.Dataset,
 		AgentName:       trial.Agent.Name,
+		Model:           trial.Agent.Model,
 		Attempt:         trial.Attempt,
 		TaskGitCommitID: trial.Task.GitCommitID,
 		Timestamps: models.Timestamps{
 			StartedAt: time.Now(),
 		},
 	}
+	if e.Seed != nil {
+		result.Seed = trialSeed(*e.Seed, trial.ID)
+	}
 
 	var env environment.Environment
 	var err error
 
+	e.statusTracker.start(trial)
+	defer e.statusTracker.finish(trial.ID)
+
 	defer func() {
 		result.Timestamps.EndedAt = time.Now()
 		result.Durations.TotalSec = result.Timestamps.EndedAt.Sub(result.Timestamps.StartedAt).Seconds()
-		
+
 		if result.Error != nil {
 			logger.Error("trial failed",
 				"error_type", result.Error.Type,
@@ -78,32 +386,68 @@ func (e *DefaultTrialExecutor) Execute(ctx context.Context, trial models.Trial,
 
 	// Phase 1: Environment Setup
 	logger.Debug("phase 1: setting up environment")
+	e.statusTracker.setPhase(trial.ID, phaseEnvironmentSetup)
 	result.Timestamps.EnvironmentSetupStartedAt = time.Now()
-	env, err = e.setupEnvironment(ctx, trial, provider, logger)
+	var imageRef string
+	env, imageRef, err = e.setupEnvironment(ctx, trial, provider, logger)
+	result.ImageRef = imageRef
+	if reporter, ok := env.(environment.ProviderReporter); ok {
+		result.EnvironmentProvider = reporter.ProviderName()
+	} else if env != nil {
+		result.EnvironmentProvider = provider.Name()
+	}
+	if env != nil {
+		e.statusTracker.setEnvironment(trial.ID, env.ID(), result.EnvironmentProvider)
+	}
 	result.Timestamps.EnvironmentSetupEndedAt = time.Now()
 	setupDur := result.Timestamps.EnvironmentSetupEndedAt.Sub(result.Timestamps.EnvironmentSetupStartedAt).Seconds()
 	result.Durations.EnvironmentSetupSec = &setupDur
 
 	if err != nil {
+		errType := classifyProviderError(err, models.ErrEnvironmentBuildFailed)
+		if errors.Is(err, errImageDigestMismatch) {
+			errType = models.ErrEnvironmentImageDigestMismatch
+		}
 		result.Error = &models.TrialError{
-			Type:    models.ErrEnvironmentBuildFailed,
+			Type:    errType,
 			Message: err.Error(),
 		}
+		result.Phases = append(result.Phases, models.PhaseResult{Name: phaseEnvironmentSetup, Status: "failed"})
 		return result, nil
 	}
+	setupPhase := models.PhaseResult{Name: phaseEnvironmentSetup, Status: "completed"}
+	if noter, ok := provider.(environment.RuntimeNoter); ok {
+		setupPhase.Notes = noter.RuntimeNote()
+	}
+	result.Phases = append(result.Phases, setupPhase)
+
+	if e.EnvOverrides.Scan.Enabled {
+		result.ImageReport = e.scanImage(ctx, trial, imageRef, logger)
+	}
 
 	// Phase 6: Teardown (deferred)
 	defer func() {
 		if env != nil {
 			logger.Debug("phase 6: tearing down environment", "env_id", env.ID())
+			e.statusTracker.setPhase(trial.ID, phaseTeardown)
+			status := "completed"
 			if err := env.Destroy(context.Background()); err != nil {
 				logger.Error("failed to destroy environment", "error", err)
+				status = "failed"
 			} else {
 				logger.Debug("environment destroyed", "env_id", env.ID())
 			}
+			result.Phases = append(result.Phases, models.PhaseResult{Name: phaseTeardown, Status: status})
 		}
 	}()
 
+	monitor := startResourceMonitor(env, e.resourceMonitorInterval(), logger)
+	if monitor != nil {
+		defer func() {
+			result.ResourceUsage = monitor.Stop()
+		}()
+	}
+
 	// Copy instruction.md
 	logger.Debug("copying instruction.md to container", "dest", e.InstructionPath)
 	instrFile, err := trial.Task.Instruction()
@@ -138,7 +482,8 @@ func (e *DefaultTrialExecutor) Execute(ctx context.Context, trial models.Trial,
 	tmpInstr.Close()
 	defer os.Remove(tmpInstr.Name())
 
-	if err := env.CopyTo(ctx, tmpInstr.Name(), e.InstructionPath); err != nil {
+	instrHash := sha256.Sum256(instrContent)
+	if err := e.copyIfChanged(ctx, env, tmpInstr.Name(), e.InstructionPath, hex.EncodeToString(instrHash[:])); err != nil {
 		result.Error = &models.TrialError{
 			Type:    models.ErrEnvironmentStartFailed,
 			Message: fmt.Sprintf("copying instruction: %s", err),
@@ -160,34 +505,73 @@ func (e *DefaultTrialExecutor) Execute(ctx context.Context, trial models.Trial,
 
 	// Phase 2: Agent Install
 	logger.Debug("phase 2: installing agent")
+	e.statusTracker.setPhase(trial.ID, phaseAgentInstall)
 	result.Timestamps.AgentSetupStartedAt = time.Now()
-	err = e.installAgent(ctx, trial, env, result, logger)
+	installExit, installStdoutPath, installStderrPath, _ := e.installAgent(ctx, trial, env, result, logger)
 	result.Timestamps.AgentSetupEndedAt = time.Now()
 	installDur := result.Timestamps.AgentSetupEndedAt.Sub(result.Timestamps.AgentSetupStartedAt).Seconds()
 	result.Durations.AgentSetupSec = &installDur
+	result.Phases = append(result.Phases, newPhaseResult(phaseAgentInstall, installExit, installStdoutPath, installStderrPath, result.Error))
 
 	if result.Error != nil {
+		e.debugDrop(ctx, env, "agent install failed", logger)
 		return result, nil
 	}
 	logger.Debug("agent install completed", "duration", fmt.Sprintf("%.2fs", installDur))
 
+	// Snapshot the environment for reuse by later attempts of this same
+	// (dataset, task, agent) trio, if nobody's beaten us to it and the
+	// provider supports it. The oracle agent has nothing worth caching -
+	// its "install" step is just copying /oracle, cheaper than a snapshot.
+	if e.snapshotCache != nil && !trial.Agent.IsOracle() {
+		if _, exists := e.snapshotCache.Get(trial.Dataset, trial.Task.Name, trial.Agent.Name); !exists {
+			if snapper, ok := env.(environment.Snapshotter); ok {
+				if ref, err := snapper.Snapshot(ctx); err != nil {
+					logger.Debug("snapshotting environment failed, continuing without caching", "error", err)
+				} else {
+					e.snapshotCache.Store(trial.Dataset, trial.Task.Name, trial.Agent.Name, ref)
+					logger.Debug("cached environment snapshot for reuse", "image_ref", ref)
+				}
+			}
+		}
+	}
+
 	// Phase 3: Agent Execute
 	logger.Debug("phase 3: executing agent")
+	e.statusTracker.setPhase(trial.ID, phaseAgentExecution)
 	result.Timestamps.AgentExecutionStartedAt = time.Now()
-	err = e.executeAgent(ctx, trial, env, result, logger)
+	execExit, execStdoutPath, execStderrPath, _ := e.executeAgent(ctx, trial, env, result, logger)
 	result.Timestamps.AgentExecutionEndedAt = time.Now()
 	execDur := result.Timestamps.AgentExecutionEndedAt.Sub(result.Timestamps.AgentExecutionStartedAt).Seconds()
 	result.Durations.AgentExecutionSec = &execDur
+	classifyOOM(ctx, env, execExit, result, logger)
+	classifyInterruption(ctx, env, result, logger)
+	result.Phases = append(result.Phases, newPhaseResult(phaseAgentExecution, execExit, execStdoutPath, execStderrPath, result.Error))
 
 	if result.Error != nil {
+		e.debugDrop(ctx, env, "agent execution failed", logger)
 		return result, nil
 	}
 	logger.Debug("agent execution completed", "duration", fmt.Sprintf("%.2fs", execDur))
 
+	if monitor != nil {
+		result.ResourceUsage = monitor.Stop()
+	}
+
+	e.captureWorkspaceDiff(ctx, trial, env, logger)
+
 	// Copy tests/ directory (after agent execution, before verification)
 	logger.Debug("copying tests directory to container", "dest", "/tests")
 	testsDir := filepath.Join(trial.Task.Path, "tests")
-	if err := env.CopyTo(ctx, testsDir, "/tests"); err != nil {
+	testsHash, err := hashDir(testsDir)
+	if err != nil {
+		result.Error = &models.TrialError{
+			Type:    models.ErrVerifierFailed,
+			Message: fmt.Sprintf("hashing tests: %s", err),
+		}
+		return result, nil
+	}
+	if err := e.copyIfChanged(ctx, env, testsDir, "/tests", testsHash); err != nil {
 		result.Error = &models.TrialError{
 			Type:    models.ErrVerifierFailed,
 			Message: fmt.Sprintf("copying tests: %s", err),
@@ -197,21 +581,34 @@ func (e *DefaultTrialExecutor) Execute(ctx context.Context, trial models.Trial,
 
 	// Phase 4: Verification
 	logger.Debug("phase 4: running verifier")
+	e.statusTracker.setPhase(trial.ID, phaseVerifier)
 	now := time.Now()
 	result.Timestamps.VerifierStartedAt = &now
-	err = e.runVerifier(ctx, trial, env, result, logger)
+	verifierExit, _ := e.runVerifier(ctx, trial, env, result, logger)
+	classifyOOM(ctx, env, verifierExit, result, logger)
+	classifyInterruption(ctx, env, result, logger)
 	endNow := time.Now()
 	result.Timestamps.VerifierEndedAt = &endNow
 	verifierDur := endNow.Sub(now).Seconds()
 	result.Durations.VerifierSec = &verifierDur
 
+	var verifierStdoutPath, verifierStderrPath string
+	if trial.OutputDir != "" {
+		verifierStdoutPath = filepath.Join(trial.OutputDir, "logs", "verifier", "stdout.txt")
+		verifierStderrPath = filepath.Join(trial.OutputDir, "logs", "verifier", "stderr.txt")
+	}
+	result.Phases = append(result.Phases, newPhaseResult(phaseVerifier, verifierExit, verifierStdoutPath, verifierStderrPath, result.Error))
+
 	// Phase 5: Collect results (copy /logs)
 	logger.Debug("phase 5: collecting results")
 	if trial.OutputDir != "" {
 		logsDir := filepath.Join(trial.OutputDir, "logs")
 		os.MkdirAll(logsDir, 0755)
 		logger.Debug("copying logs from container", "src", "/logs", "dest", logsDir)
-		env.CopyFrom(ctx, "/logs/.", logsDir)
+		result.LogCollectionErrors = collectLogs(ctx, env, logsDir, logger)
+		if len(result.LogCollectionErrors) > 0 {
+			logger.Warn("some log entries failed to collect", "errors", result.LogCollectionErrors)
+		}
 
 		// Write verifier stdout/stderr directly to output dir
 		verifierLogsDir := filepath.Join(logsDir, "verifier")
@@ -224,40 +621,231 @@ func (e *DefaultTrialExecutor) Execute(ctx context.Context, trial models.Trial,
 	return result, nil
 }
 
-func (e *DefaultTrialExecutor) setupEnvironment(ctx context.Context, trial models.Trial, provider environment.Provider, logger *slog.Logger) (environment.Environment, error) {
+// buildImageTag computes the tag used for a task's built image. When
+// image caching is disabled (the default), each build gets a fresh
+// timestamped tag. When models.ImageCacheConfig.Keep is set, the tag is
+// derived deterministically from the environment context so that unchanged
+// tasks reuse the same image across jobs instead of rebuilding.
+func (e *DefaultTrialExecutor) buildImageTag(trial models.Trial) (string, error) {
+	if !e.EnvOverrides.ImageCache.Keep {
+		return fmt.Sprintf("rollout-%s-%s:%d", trial.Task.Name, trial.Agent.Name, time.Now().UnixNano()), nil
+	}
+
+	prefix := e.EnvOverrides.ImageCache.Prefix
+	if prefix == "" {
+		prefix = "rollout-cache"
+	}
+
+	envFS, err := trial.Task.Environment()
+	if err != nil {
+		return "", fmt.Errorf("opening environment dir: %w", err)
+	}
+	hash, err := util.HashFS(envFS)
+	if err != nil {
+		return "", fmt.Errorf("hashing environment dir: %w", err)
+	}
+
+	return fmt.Sprintf("%s-%s:%s", prefix, trial.Task.Name, hash), nil
+}
+
+// classifyOOM reclassifies result.Error as models.ErrEnvironmentOOM when a
+// failed phase's exit code looks like an OOM kill (the conventional
+// 128+SIGKILL = 137) and the environment confirms it via
+// environment.OOMDetector. Providers that don't implement OOMDetector leave
+// the original error untouched.
+func classifyOOM(ctx context.Context, env environment.Environment, exitCode int, result *models.TrialResult, logger *slog.Logger) {
+	if result.Error == nil || exitCode != 137 {
+		return
+	}
+	detector, ok := env.(environment.OOMDetector)
+	if !ok {
+		return
+	}
+	killed, err := detector.WasOOMKilled(ctx)
+	if err != nil {
+		logger.Debug("oom detection failed", "error", err)
+		return
+	}
+	if killed {
+		logger.Error("process was killed by the OOM killer", "exit_code", exitCode)
+		result.Error = &models.TrialError{
+			Type:    models.ErrEnvironmentOOM,
+			Message: fmt.Sprintf("process killed by OOM killer (exit code %d)", exitCode),
+		}
+	}
+}
+
+// classifyInterruption reclassifies result.Error as
+// models.ErrEnvironmentInterrupted when a failed phase's environment
+// confirms its underlying instance was reclaimed via
+// environment.InterruptionDetector. Providers that don't implement
+// InterruptionDetector leave the original error untouched.
+func classifyInterruption(ctx context.Context, env environment.Environment, result *models.TrialResult, logger *slog.Logger) {
+	if result.Error == nil {
+		return
+	}
+	detector, ok := env.(environment.InterruptionDetector)
+	if !ok {
+		return
+	}
+	interrupted, err := detector.WasInterrupted(ctx)
+	if err != nil {
+		logger.Debug("interruption detection failed", "error", err)
+		return
+	}
+	if interrupted {
+		logger.Error("environment instance was interrupted")
+		result.Error = &models.TrialError{
+			Type:    models.ErrEnvironmentInterrupted,
+			Message: "environment instance was reclaimed before the phase completed",
+		}
+	}
+}
+
+// resourceMonitorInterval returns how often to sample resource usage, or 0
+// to disable sampling, based on models.ResourceMonitorConfig.
+func (e *DefaultTrialExecutor) resourceMonitorInterval() time.Duration {
+	if !e.EnvOverrides.ResourceMonitor.Enabled {
+		return 0
+	}
+	sec := e.EnvOverrides.ResourceMonitor.IntervalSec
+	if sec <= 0 {
+		sec = 5
+	}
+	return time.Duration(sec * float64(time.Second))
+}
+
+// scanImage summarizes imageRef's size and vulnerabilities for the job
+// report. Scan failures (missing scanner binary, remote-only provider with
+// no local image, etc.) are logged and recorded on the report rather than
+// failing the trial.
+func (e *DefaultTrialExecutor) scanImage(ctx context.Context, trial models.Trial, imageRef string, logger *slog.Logger) *models.ImageReport {
+	report := &models.ImageReport{ImageRef: imageRef, TaskName: trial.Task.Name}
+
+	result, err := scanner.Scan(ctx, e.EnvOverrides.Scan.Scanner, imageRef)
+	if err != nil {
+		logger.Warn("image scan failed", "image", imageRef, "error", err)
+		report.Error = err.Error()
+		return report
+	}
+
+	report.SizeBytes = result.SizeBytes
+	report.CriticalCVEs = result.Critical
+	report.HighCVEs = result.High
+	report.MediumCVEs = result.Medium
+	report.LowCVEs = result.Low
+	return report
+}
+
+// verifyImageDigest checks that imageRef's pulled content digest matches
+// wantDigest (e.g. "sha256:abcd..."), shelling out to `docker image
+// inspect` the same way the scanner package inspects image size. This only
+// works for providers that pull into the local docker image store (the
+// docker provider against a local daemon); providers that pull elsewhere
+// (ssh's remote daemon, containerd's own store, remote-only providers) fail
+// the check since the local docker CLI cannot see their pulled bits.
+func verifyImageDigest(ctx context.Context, imageRef, wantDigest string) error {
+	out, err := exec.CommandContext(ctx, "docker", "image", "inspect", "--format", "{{range .RepoDigests}}{{.}} {{end}}", imageRef).Output()
+	if err != nil {
+		return fmt.Errorf("inspecting pulled image: %w", err)
+	}
+	for _, digestRef := range strings.Fields(string(out)) {
+		if strings.HasSuffix(digestRef, "@"+wantDigest) {
+			return nil
+		}
+	}
+	return fmt.Errorf("image %s has digest(s) %q, want %q", imageRef, strings.TrimSpace(string(out)), wantDigest)
+}
+
+// pushImageToRegistry retags localTag under registry and pushes it there
+// with the docker CLI, returning the fully-qualified pushed reference. Used
+// by models.ImageCacheConfig.Registry so a remote provider's PullImage can
+// fetch the exact image rollout built locally instead of building (or
+// re-parsing a Dockerfile for) it itself.
+func pushImageToRegistry(ctx context.Context, localTag, registry string) (string, error) {
+	pushedRef := registry + "/" + localTag
+	if out, err := exec.CommandContext(ctx, "docker", "tag", localTag, pushedRef).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("tagging %s as %s: %w: %s", localTag, pushedRef, err, out)
+	}
+	if out, err := exec.CommandContext(ctx, "docker", "push", pushedRef).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("pushing %s: %w: %s", pushedRef, err, out)
+	}
+	return pushedRef, nil
+}
+
+func (e *DefaultTrialExecutor) setupEnvironment(ctx context.Context, trial models.Trial, provider environment.Provider, logger *slog.Logger) (environment.Environment, string, error) {
 	var imageRef string
 	var err error
 
-	// Check if a pre-built docker image is specified and force_build is not set
-	if trial.Task.Config.Env.DockerImage != nil && !e.EnvOverrides.ForceBuild {
-		imageRef = *trial.Task.Config.Env.DockerImage
-		logger.Debug("using pre-built image", "image", imageRef)
-		if err := provider.PullImage(ctx, imageRef); err != nil {
-			logger.Error("image pull failed", "error", err)
-			return nil, fmt.Errorf("pulling image: %w", err)
+	if e.snapshotCache != nil {
+		if ref, ok := e.snapshotCache.Get(trial.Dataset, trial.Task.Name, trial.Agent.Name); ok {
+			logger.Debug("reusing environment snapshot, skipping build/install", "image_ref", ref)
+			imageRef = ref
 		}
-		logger.Debug("image ready", "image_ref", imageRef)
-	} else {
-		// Build image from Dockerfile
-		envDir := filepath.Join(trial.Task.Path, "environment")
-		tag := fmt.Sprintf("rollout-%s-%s:%d", trial.Task.Name, trial.Agent.Name, time.Now().UnixNano())
-
-		timeout := time.Duration(trial.Task.Config.Env.BuildTimeoutSec*e.TimeoutMultiplier) * time.Second
-		logger.Debug("building image",
-			"context_dir", envDir,
-			"tag", tag,
-			"timeout", timeout)
-
-		imageRef, err = provider.BuildImage(ctx, environment.BuildImageOptions{
-			ContextDir: envDir,
-			Tag:        tag,
-			Timeout:    timeout,
-		})
-		if err != nil {
-			logger.Error("image build failed", "error", err)
-			return nil, fmt.Errorf("building image: %w", err)
+	}
+
+	if imageRef == "" {
+		// Check if a pre-built docker image is specified and force_build is not set
+		if trial.Task.Config.Env.DockerImage != nil && !e.EnvOverrides.ForceBuild {
+			imageRef = *trial.Task.Config.Env.DockerImage
+			logger.Debug("using pre-built image", "image", imageRef)
+			if err := provider.PullImage(ctx, imageRef); err != nil {
+				logger.Error("image pull failed", "error", err)
+				return nil, "", fmt.Errorf("pulling image: %w", err)
+			}
+			logger.Debug("image ready", "image_ref", imageRef)
+
+			if wantDigest := trial.Task.Config.Env.DockerImageDigest; wantDigest != nil {
+				if err := verifyImageDigest(ctx, imageRef, *wantDigest); err != nil {
+					return nil, "", fmt.Errorf("%w: %w", errImageDigestMismatch, err)
+				}
+				logger.Debug("image digest verified", "image_ref", imageRef, "digest", *wantDigest)
+			}
+		} else {
+			// Build image from Dockerfile
+			envDir := filepath.Join(trial.Task.Path, "environment")
+			tag, err := e.buildImageTag(trial)
+			if err != nil {
+				return nil, "", fmt.Errorf("computing image tag: %w", err)
+			}
+
+			timeout := time.Duration(trial.Task.Config.Env.BuildTimeoutSec*e.TimeoutMultiplier) * time.Second
+			logger.Debug("building image",
+				"context_dir", envDir,
+				"tag", tag,
+				"timeout", timeout,
+				"reuse", e.EnvOverrides.ImageCache.Keep)
+
+			imageRef, err = provider.BuildImage(ctx, environment.BuildImageOptions{
+				ContextDir:    envDir,
+				Tag:           tag,
+				Timeout:       timeout,
+				ReuseExisting: e.EnvOverrides.ImageCache.Keep,
+				BuildArgs:     trial.Task.Config.Env.BuildArgs,
+				Target:        trial.Task.Config.Env.Target,
+				AuditLogger:   e.auditLogger,
+			})
+			if err != nil {
+				logger.Error("image build failed", "error", err)
+				return nil, "", fmt.Errorf("building image: %w", err)
+			}
+			logger.Debug("image built successfully", "image_ref", imageRef)
+
+			if registry := e.EnvOverrides.ImageCache.Registry; registry != "" {
+				if !e.EnvOverrides.ImageCache.Keep {
+					logger.Warn("image_cache.registry is set without keep - pushing a fresh timestamped tag every trial instead of reusing one across jobs")
+				}
+				pushedRef, err := pushImageToRegistry(ctx, imageRef, registry)
+				if err != nil {
+					return nil, "", fmt.Errorf("pushing built image to registry: %w", err)
+				}
+				logger.Debug("pushed image to registry", "local_tag", imageRef, "pushed_ref", pushedRef)
+				if err := provider.PullImage(ctx, pushedRef); err != nil {
+					return nil, "", fmt.Errorf("pulling pushed image: %w", err)
+				}
+				imageRef = pushedRef
+			}
 		}
-		logger.Debug("image built successfully", "image_ref", imageRef)
 	}
 
 	// Determine Memory and Storage
@@ -265,44 +853,177 @@ func (e *DefaultTrialExecutor) setupEnvironment(ctx context.Context, trial model
 	if e.EnvOverrides.OverrideMemoryMB != nil {
 		memoryMB = *e.EnvOverrides.OverrideMemoryMB
 	}
-	
+
 	storageMB := trial.Task.Config.Env.StorageMB
 	if e.EnvOverrides.OverrideStorageMB != nil {
 		storageMB = *e.EnvOverrides.OverrideStorageMB
 	}
-	
+
 	// Determine CPUs
 	cpus := trial.Task.Config.Env.CPUs
 	if e.EnvOverrides.OverrideCPUs != nil {
 		cpus = *e.EnvOverrides.OverrideCPUs
 	}
 
+	// Determine GPU count and type
+	gpuCount := trial.Task.Config.Env.GPUCount
+	if e.EnvOverrides.OverrideGPUCount != nil {
+		gpuCount = *e.EnvOverrides.OverrideGPUCount
+	}
+
+	gpuType := trial.Task.Config.Env.GPUType
+	if e.EnvOverrides.OverrideGPUType != nil {
+		gpuType = *e.EnvOverrides.OverrideGPUType
+	}
+
+	network := trial.Task.Config.Env.Network
+	if e.EnvOverrides.OverrideNetwork != nil {
+		network = *e.EnvOverrides.OverrideNetwork
+	}
+
+	fakeTime := trial.Task.Config.Env.FakeTime
+	if e.EnvOverrides.OverrideFakeTime != nil {
+		fakeTime = *e.EnvOverrides.OverrideFakeTime
+	}
+
+	shaping := trial.Task.Config.Env.NetworkShaping
+	if e.EnvOverrides.OverrideNetworkShaping != nil {
+		shaping = *e.EnvOverrides.OverrideNetworkShaping
+	}
+
+	security := trial.Task.Config.Env.Security
+	if e.EnvOverrides.OverrideSecurity != nil {
+		security = *e.EnvOverrides.OverrideSecurity
+	}
+	if security.SeccompProfile != "" && security.SeccompProfile != "unconfined" && security.SeccompProfile != "default" && !filepath.IsAbs(security.SeccompProfile) {
+		security.SeccompProfile = filepath.Join(trial.Task.Path, security.SeccompProfile)
+	}
+
+	pidsLimit := trial.Task.Config.Env.PidsLimit
+	if e.EnvOverrides.OverridePidsLimit != nil {
+		pidsLimit = *e.EnvOverrides.OverridePidsLimit
+	}
+
+	ulimits := make([]environment.Ulimit, 0, len(trial.Task.Config.Env.Ulimits))
+	for _, u := range trial.Task.Config.Env.Ulimits {
+		hard := u.Hard
+		if hard == 0 {
+			hard = u.Soft
+		}
+		ulimits = append(ulimits, environment.Ulimit{Name: u.Name, Soft: u.Soft, Hard: hard})
+	}
+
+	mounts := make([]environment.Mount, 0, len(trial.Task.Config.Env.Mounts))
+	for _, m := range trial.Task.Config.Env.Mounts {
+		hostPath := m.HostPath
+		if !filepath.IsAbs(hostPath) {
+			hostPath = filepath.Join(trial.Task.Path, hostPath)
+		}
+		mounts = append(mounts, environment.Mount{
+			HostPath: hostPath,
+			Path:     m.Path,
+			ReadOnly: m.ReadOnly,
+		})
+	}
+
+	caches := make([]environment.Cache, 0, len(e.EnvOverrides.Caches))
+	for _, c := range e.EnvOverrides.Caches {
+		caches = append(caches, environment.Cache{Name: c.Name, Path: c.Path})
+	}
+
+	services := make([]environment.Service, 0, len(trial.Task.Config.Env.Services))
+	for _, svc := range trial.Task.Config.Env.Services {
+		healthcheckTimeout := time.Duration(svc.HealthcheckTimeoutSec * float64(time.Second))
+		if svc.Healthcheck != "" && healthcheckTimeout == 0 {
+			healthcheckTimeout = 60 * time.Second
+		}
+		services = append(services, environment.Service{
+			Name:               svc.Name,
+			Image:              svc.Image,
+			Env:                svc.Env,
+			Ports:              svc.Ports,
+			Healthcheck:        svc.Healthcheck,
+			HealthcheckTimeout: healthcheckTimeout,
+		})
+	}
+
+	// envVars holds only container-wide, non-secret vars (currently just
+	// libfaketime's). The agent's own Env/InstallEnv/ExecuteEnv are passed
+	// per-exec instead (see installAgent/executeAgent), not baked into the
+	// container here - docker exec otherwise inherits a container's
+	// creation-time env by default, which would leak them into the
+	// verifier phase too.
+	var envVars map[string]string
+	if fakeTime != "" {
+		envVars = map[string]string{
+			"FAKETIME":   fakeTime,
+			"LD_PRELOAD": libfaketimePreloadPath,
+		}
+	}
+
 	// Create environment with meaningful name for debugging
 	envName := formatEnvironmentName(trial.Dataset, trial.Task.Name, trial.Agent.Name, trial.Attempt)
 	logger.Debug("creating environment",
 		"name", envName,
 		"cpus", cpus,
 		"memory_mb", memoryMB,
-		"storage_mb", storageMB)
-	
+		"storage_mb", storageMB,
+		"gpu_count", gpuCount,
+		"gpu_type", gpuType,
+		"network", network,
+		"fake_time", fakeTime,
+		"network_shaping", shaping)
+
 	env, err := provider.CreateEnvironment(ctx, environment.CreateEnvironmentOptions{
 		Name:      envName,
 		ImageRef:  imageRef,
 		CPUs:      cpus,
 		MemoryMB:  memoryMB,
 		StorageMB: storageMB,
-		Env:       trial.Agent.Env,
+		GPUCount:  gpuCount,
+		GPUType:   gpuType,
+		Network:   network,
+		NetworkShaping: environment.NetworkShaping{
+			LatencyMs:     shaping.LatencyMs,
+			BandwidthKbps: shaping.BandwidthKbps,
+		},
+		Services:    services,
+		Env:         envVars,
+		Config:      trial.Task.Config.Env.ProviderHints,
+		Timeout:     e.computeSandboxTimeout(trial),
+		AuditLogger: e.auditLogger,
+		MaxLogMB:    trial.Task.Config.Env.MaxLogMB,
+		Caches:      caches,
+		Constraints: environment.Constraints{
+			Region:         trial.Task.Config.Env.Constraints.Region,
+			Arch:           trial.Task.Config.Env.Constraints.Arch,
+			MaxPriceHourly: trial.Task.Config.Env.Constraints.MaxPrice,
+		},
+		Mounts: mounts,
+		Security: environment.Security{
+			SeccompProfile:  security.SeccompProfile,
+			AppArmorProfile: security.AppArmorProfile,
+			CapDrop:         security.CapDrop,
+			CapAdd:          security.CapAdd,
+			NoNewPrivileges: security.NoNewPrivileges,
+		},
+		PidsLimit: pidsLimit,
+		Ulimits:   ulimits,
 	})
 	if err != nil {
 		logger.Error("environment creation failed", "error", err)
-		return nil, fmt.Errorf("creating environment: %w", err)
+		return nil, "", fmt.Errorf("creating environment: %w", err)
 	}
 
 	logger.Debug("environment created", "env_id", env.ID())
-	return env, nil
+	return env, imageRef, nil
 }
 
-func (e *DefaultTrialExecutor) installAgent(ctx context.Context, trial models.Trial, env environment.Environment, result *models.TrialResult, logger *slog.Logger) error {
+// installAgent runs the agent's install step. It returns the exit code of
+// the install script (-1 if the step was skipped, e.g. oracle agents or
+// agents with no install script) along with the paths the install logs were
+// saved to (empty if nothing was saved).
+func (e *DefaultTrialExecutor) installAgent(ctx context.Context, trial models.Trial, env environment.Environment, result *models.TrialResult, logger *slog.Logger) (int, string, string, error) {
 	if trial.Agent.IsOracle() {
 		// Oracle agent: copy solution
 		solDir := filepath.Join(trial.Task.Path, "solution")
@@ -312,31 +1033,36 @@ func (e *DefaultTrialExecutor) installAgent(ctx context.Context, trial models.Tr
 				Type:    models.ErrAgentInstallFailed,
 				Message: fmt.Sprintf("copying solution: %s", err),
 			}
-			return err
+			return -1, "", "", err
 		}
-		return nil
+		return -1, "", "", nil
 	}
 
 	if trial.Agent.Install == "" {
 		logger.Debug("no install script, skipping agent install")
-		return nil
+		return -1, "", "", nil
 	}
 
 	timeout := time.Duration(trial.Task.Config.Agent.InstallTimeoutSec*e.TimeoutMultiplier) * time.Second
 	logger.Debug("executing agent install script", "timeout", timeout)
 	var stdout, stderr bytes.Buffer
+	live := statusWriter{tracker: e.statusTracker, trialID: trial.ID, redactor: e.Redactor}
 
-	exitCode, err := env.Exec(ctx, trial.Agent.Install, &stdout, &stderr, environment.ExecOptions{
-		Env:     trial.Agent.Env,
+	exitCode, err := env.Exec(ctx, trial.Agent.Install, io.MultiWriter(&stdout, live), io.MultiWriter(&stderr, live), environment.ExecOptions{
+		Env:     trial.Agent.InstallEnvVars(),
 		Timeout: timeout,
+		User:    trial.Agent.User,
 	})
 
 	// Save install logs
+	var stdoutPath, stderrPath string
 	if trial.OutputDir != "" {
 		setupDir := filepath.Join(trial.OutputDir, "setup")
 		os.MkdirAll(setupDir, 0755)
-		os.WriteFile(filepath.Join(setupDir, "stdout.txt"), stdout.Bytes(), 0644)
-		os.WriteFile(filepath.Join(setupDir, "stderr.txt"), stderr.Bytes(), 0644)
+		stdoutPath = filepath.Join(setupDir, "stdout.txt")
+		stderrPath = filepath.Join(setupDir, "stderr.txt")
+		os.WriteFile(stdoutPath, e.Redactor.Bytes(stdout.Bytes()), 0644)
+		os.WriteFile(stderrPath, e.Redactor.Bytes(stderr.Bytes()), 0644)
 	}
 
 	if err != nil {
@@ -353,7 +1079,7 @@ func (e *DefaultTrialExecutor) installAgent(ctx context.Context, trial models.Tr
 				Message: err.Error(),
 			}
 		}
-		return err
+		return exitCode, stdoutPath, stderrPath, err
 	}
 
 	if exitCode != 0 {
@@ -362,13 +1088,17 @@ func (e *DefaultTrialExecutor) installAgent(ctx context.Context, trial models.Tr
 			Type:    models.ErrAgentInstallFailed,
 			Message: fmt.Sprintf("install script exited with code %d", exitCode),
 		}
-		return fmt.Errorf("install failed with exit code %d", exitCode)
+		return exitCode, stdoutPath, stderrPath, fmt.Errorf("install failed with exit code %d", exitCode)
 	}
 
-	return nil
+	return exitCode, stdoutPath, stderrPath, nil
 }
 
-func (e *DefaultTrialExecutor) executeAgent(ctx context.Context, trial models.Trial, env environment.Environment, result *models.TrialResult, logger *slog.Logger) error {
+// executeAgent runs the agent's execute step. It returns the agent's exit
+// code (-1 if the step was skipped, e.g. an agent with no execute script)
+// along with the paths the execution logs were saved to (empty if nothing
+// was saved).
+func (e *DefaultTrialExecutor) executeAgent(ctx context.Context, trial models.Trial, env environment.Environment, result *models.TrialResult, logger *slog.Logger) (int, string, string, error) {
 	var cmd string
 	if trial.Agent.IsOracle() {
 		cmd = "bash /oracle/solve.sh"
@@ -378,28 +1108,36 @@ func (e *DefaultTrialExecutor) executeAgent(ctx context.Context, trial models.Tr
 
 	if cmd == "" {
 		logger.Debug("no execute script, skipping agent execution")
-		return nil
+		return -1, "", "", nil
 	}
 
 	timeout := time.Duration(trial.Task.Config.Agent.TimeoutSec*e.TimeoutMultiplier) * time.Second
 	logger.Debug("executing agent command", "timeout", timeout)
 	var stdout, stderr bytes.Buffer
+	live := statusWriter{tracker: e.statusTracker, trialID: trial.ID, redactor: e.Redactor}
 
 	execEnv := make(map[string]string)
-	maps.Copy(execEnv, trial.Agent.Env)
+	maps.Copy(execEnv, trial.Agent.ExecuteEnvVars())
 	execEnv["ROLLOUT_TASK_INSTRUCTION"] = e.InstructionPath
+	if e.Seed != nil {
+		execEnv["ROLLOUT_SEED"] = strconv.FormatInt(result.Seed, 10)
+	}
 
-	exitCode, err := env.Exec(ctx, cmd, &stdout, &stderr, environment.ExecOptions{
+	exitCode, err := env.Exec(ctx, cmd, io.MultiWriter(&stdout, live), io.MultiWriter(&stderr, live), environment.ExecOptions{
 		Env:     execEnv,
 		Timeout: timeout,
+		User:    trial.Agent.User,
 	})
 
 	// Save execution logs
+	var stdoutPath, stderrPath string
 	if trial.OutputDir != "" {
 		cmdDir := filepath.Join(trial.OutputDir, "command")
 		os.MkdirAll(cmdDir, 0755)
-		os.WriteFile(filepath.Join(cmdDir, "stdout.txt"), stdout.Bytes(), 0644)
-		os.WriteFile(filepath.Join(cmdDir, "stderr.txt"), stderr.Bytes(), 0644)
+		stdoutPath = filepath.Join(cmdDir, "stdout.txt")
+		stderrPath = filepath.Join(cmdDir, "stderr.txt")
+		os.WriteFile(stdoutPath, e.Redactor.Bytes(stdout.Bytes()), 0644)
+		os.WriteFile(stderrPath, e.Redactor.Bytes(stderr.Bytes()), 0644)
 	}
 
 	if err != nil {
@@ -416,7 +1154,7 @@ func (e *DefaultTrialExecutor) executeAgent(ctx context.Context, trial models.Tr
 				Message: err.Error(),
 			}
 		}
-		return err
+		return exitCode, stdoutPath, stderrPath, err
 	}
 
 	if exitCode != 0 {
@@ -425,12 +1163,61 @@ func (e *DefaultTrialExecutor) executeAgent(ctx context.Context, trial models.Tr
 			Type:    models.ErrAgentExecutionFailed,
 			Message: fmt.Sprintf("agent exited with code %d", exitCode),
 		}
-		return fmt.Errorf("agent failed with exit code %d", exitCode)
+		return exitCode, stdoutPath, stderrPath, fmt.Errorf("agent failed with exit code %d", exitCode)
 	}
 
-	return nil
+	return exitCode, stdoutPath, stderrPath, nil
 }
 
+// captureWorkspaceDiff runs the configured diff command inside the
+// environment and saves its stdout as the trial's workspace patch, so
+// analyses and `rollout replay` have the agent's actual code changes rather
+// than just logs. Failures are logged and otherwise ignored: a missing diff
+// should never fail an otherwise-successful trial.
+func (e *DefaultTrialExecutor) captureWorkspaceDiff(ctx context.Context, trial models.Trial, env environment.Environment, logger *slog.Logger) {
+	if !e.DiffConfig.Enabled || trial.OutputDir == "" {
+		return
+	}
+
+	cmd := e.DiffConfig.Command
+	if cmd == "" {
+		cmd = "git diff"
+	}
+
+	var stdout, stderr bytes.Buffer
+	exitCode, err := env.Exec(ctx, cmd, &stdout, &stderr, environment.ExecOptions{})
+	if err != nil {
+		logger.Warn("workspace diff capture failed", "error", err)
+		return
+	}
+	if exitCode != 0 {
+		logger.Warn("workspace diff command exited non-zero", "exit_code", exitCode, "stderr", stderr.String())
+		return
+	}
+
+	patchPath := filepath.Join(trial.OutputDir, "workspace.patch")
+	if err := os.WriteFile(patchPath, stdout.Bytes(), 0644); err != nil {
+		logger.Warn("failed to write workspace patch", "error", err)
+	}
+}
+
+// sandboxTimeoutMargin is added on top of the summed phase timeouts so the
+// environment outlives the slowest-case trial by enough to cover teardown
+// and scheduling jitter, without granting it an unbounded lifetime.
+const sandboxTimeoutMargin = 15 * time.Minute
+
+// computeSandboxTimeout sums the install, agent execution, and verifier
+// timeouts that will run inside trial's environment (applying overrides and
+// TimeoutMultiplier the same way each phase does) and adds
+// sandboxTimeoutMargin, giving providers whose environments enforce their
+// own hard lifetime (e.g. Modal sandboxes) a bound derived from the trial
+// instead of one hard-coded far beyond what any trial should need.
+func (e *DefaultTrialExecutor) computeSandboxTimeout(trial models.Trial) time.Duration {
+	install := time.Duration(trial.Task.Config.Agent.InstallTimeoutSec*e.TimeoutMultiplier) * time.Second
+	agent := time.Duration(trial.Task.Config.Agent.TimeoutSec*e.TimeoutMultiplier) * time.Second
+	verifier := e.computeVerifierTimeout(trial.Task.Config.Verifier.TimeoutSec)
+	return install + agent + verifier + sandboxTimeoutMargin
+}
 
 // computeVerifierTimeout calculates the effective timeout for the verifier,
 // applying override, max ceiling, and multiplier logic.
@@ -456,18 +1243,33 @@ func (e *DefaultTrialExecutor) computeVerifierTimeout(taskTimeoutSec float64) ti
 	return time.Duration(timeoutSec) * time.Second
 }
 
-func (e *DefaultTrialExecutor) runVerifier(ctx context.Context, trial models.Trial, env environment.Environment, result *models.TrialResult, logger *slog.Logger) error {
+// computePassThreshold resolves the effective pass threshold for a trial,
+// applying the job-level override over the task's own threshold.
+func (e *DefaultTrialExecutor) computePassThreshold(taskThreshold float64) float64 {
+	if e.VerifierConfig.OverridePassThreshold != nil {
+		return *e.VerifierConfig.OverridePassThreshold
+	}
+	return taskThreshold
+}
+
+// runVerifier runs /tests/test.sh and reads back the reward it produces. It
+// returns the verifier script's own exit code; reward-file handling does not
+// affect the returned code since a missing/invalid reward is a distinct
+// failure mode from the verifier script itself failing.
+func (e *DefaultTrialExecutor) runVerifier(ctx context.Context, trial models.Trial, env environment.Environment, result *models.TrialResult, logger *slog.Logger) (int, error) {
 	timeout := e.computeVerifierTimeout(trial.Task.Config.Verifier.TimeoutSec)
 	logger.Debug("executing verifier", "timeout", timeout)
 	var stdout, stderr bytes.Buffer
 
 	exitCode, err := env.Exec(ctx, "bash /tests/test.sh", &stdout, &stderr, environment.ExecOptions{
+		Env:     trial.Task.Config.Verifier.Env,
 		Timeout: timeout,
+		User:    trial.Task.Config.Verifier.User,
 	})
 
 	// Store verifier output directly in result
-	result.VerifierStdout = stdout.String()
-	result.VerifierStderr = stderr.String()
+	result.VerifierStdout = e.Redactor.String(stdout.String())
+	result.VerifierStderr = e.Redactor.String(stderr.String())
 
 	if err != nil {
 		if strings.Contains(err.Error(), "timed out") {
@@ -483,7 +1285,7 @@ func (e *DefaultTrialExecutor) runVerifier(ctx context.Context, trial models.Tri
 				Message: err.Error(),
 			}
 		}
-		return err
+		return exitCode, err
 	}
 
 	if exitCode != 0 {
@@ -492,20 +1294,20 @@ func (e *DefaultTrialExecutor) runVerifier(ctx context.Context, trial models.Tri
 			Type:    models.ErrVerifierFailed,
 			Message: fmt.Sprintf("verifier exited with code %d", exitCode),
 		}
-		return fmt.Errorf("verifier failed with exit code %d", exitCode)
+		return exitCode, fmt.Errorf("verifier failed with exit code %d", exitCode)
 	}
 
 	// Read reward file
 	logger.Debug("reading reward file")
 	var rewardBuf bytes.Buffer
-	exitCode, err = env.Exec(ctx, "cat /logs/verifier/reward.txt", &rewardBuf, nil, environment.ExecOptions{})
-	if err != nil || exitCode != 0 {
+	rewardExitCode, rewardErr := env.Exec(ctx, "cat /logs/verifier/reward.txt", &rewardBuf, nil, environment.ExecOptions{})
+	if rewardErr != nil || rewardExitCode != 0 {
 		logger.Error("reward file missing")
 		result.Error = &models.TrialError{
 			Type:    models.ErrVerifierRewardMissing,
 			Message: "reward.txt not found",
 		}
-		return fmt.Errorf("reward file missing")
+		return exitCode, fmt.Errorf("reward file missing")
 	}
 
 	rewardStr := strings.TrimSpace(rewardBuf.String())
@@ -516,15 +1318,16 @@ func (e *DefaultTrialExecutor) runVerifier(ctx context.Context, trial models.Tri
 			Type:    models.ErrVerifierRewardInvalid,
 			Message: fmt.Sprintf("invalid reward value: %s", rewardStr),
 		}
-		return fmt.Errorf("invalid reward: %w", err)
+		return exitCode, fmt.Errorf("invalid reward: %w", err)
 	}
 
 	logger.Debug("reward parsed", "reward", reward)
 	result.Reward = &reward
-	return nil
+	threshold := e.computePassThreshold(trial.Task.Config.Verifier.PassThreshold)
+	result.PassThreshold = &threshold
+	return exitCode, nil
 }
 
-
 // formatEnvironmentName creates a human-readable environment name from trial context.
 // Format: {dataset}-{task}-{agent}-{attempt}-{timestamp}
 // Names are sanitized to be valid across providers (lowercase, alphanumeric + hyphens).
@@ -556,7 +1359,7 @@ func sanitizeEnvName(name string) string {
 	}
 	// Trim leading/trailing hyphens
 	sanitized := strings.Trim(result.String(), "-")
-	
+
 	// Truncate to max length, avoiding trailing hyphen
 	if len(sanitized) > maxAppNameLength {
 		sanitized = sanitized[:maxAppNameLength]
@@ -1,6 +1,41 @@
 package executor
 
-import "testing"
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/spachava753/rollout/internal/environment"
+	"github.com/spachava753/rollout/internal/models"
+)
+
+// fakeOOMEnvironment is a minimal environment.Environment that also
+// implements environment.OOMDetector, for testing classifyOOM without a
+// real container runtime.
+type fakeOOMEnvironment struct {
+	oomKilled bool
+}
+
+func (f *fakeOOMEnvironment) ID() string                                        { return "fake" }
+func (f *fakeOOMEnvironment) CopyTo(ctx context.Context, src, dst string) error { return nil }
+func (f *fakeOOMEnvironment) CopyFrom(ctx context.Context, src, dst string) error {
+	return nil
+}
+func (f *fakeOOMEnvironment) Exec(ctx context.Context, cmd string, stdout, stderr io.Writer, opts environment.ExecOptions) (int, error) {
+	return 0, nil
+}
+func (f *fakeOOMEnvironment) Stop(ctx context.Context) error    { return nil }
+func (f *fakeOOMEnvironment) Destroy(ctx context.Context) error { return nil }
+func (f *fakeOOMEnvironment) Cost() float64                     { return 0 }
+func (f *fakeOOMEnvironment) WasOOMKilled(ctx context.Context) (bool, error) {
+	return f.oomKilled, nil
+}
 
 func TestSanitizeEnvName(t *testing.T) {
 	tests := []struct {
@@ -57,3 +92,182 @@ func TestSanitizeEnvName(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildImageTag(t *testing.T) {
+	fsys := fstest.MapFS{
+		"environment/Dockerfile": &fstest.MapFile{Data: []byte("FROM alpine\n")},
+	}
+	task := models.Task{Name: "hello-world", FS: fsys}
+	trial := models.Trial{Task: task, Agent: models.Agent{Name: "oracle"}}
+
+	e := &DefaultTrialExecutor{}
+	tag, err := e.buildImageTag(trial)
+	if err != nil {
+		t.Fatalf("buildImageTag failed: %v", err)
+	}
+	if tag == "" {
+		t.Errorf("expected non-empty tag when image caching is disabled")
+	}
+
+	e.EnvOverrides.ImageCache = models.ImageCacheConfig{Keep: true}
+	tagA, err := e.buildImageTag(trial)
+	if err != nil {
+		t.Fatalf("buildImageTag failed: %v", err)
+	}
+	tagB, err := e.buildImageTag(trial)
+	if err != nil {
+		t.Fatalf("buildImageTag failed: %v", err)
+	}
+	if tagA != tagB {
+		t.Errorf("expected deterministic tag with image caching enabled, got %q and %q", tagA, tagB)
+	}
+	if got, want := tagA[:len("rollout-cache-")], "rollout-cache-"; got != want {
+		t.Errorf("expected default prefix %q, got tag %q", want, tagA)
+	}
+
+	e.EnvOverrides.ImageCache.Prefix = "custom-prefix"
+	tagC, err := e.buildImageTag(trial)
+	if err != nil {
+		t.Fatalf("buildImageTag failed: %v", err)
+	}
+	if got, want := tagC[:len("custom-prefix-")], "custom-prefix-"; got != want {
+		t.Errorf("expected custom prefix %q, got tag %q", want, tagC)
+	}
+}
+
+func TestClassifyOOM(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reclassifies confirmed OOM kill", func(t *testing.T) {
+		result := &models.TrialResult{Error: &models.TrialError{Type: models.ErrAgentExecutionFailed}}
+		classifyOOM(ctx, &fakeOOMEnvironment{oomKilled: true}, 137, result, slog.Default())
+		if result.Error.Type != models.ErrEnvironmentOOM {
+			t.Errorf("expected error type %q, got %q", models.ErrEnvironmentOOM, result.Error.Type)
+		}
+	})
+
+	t.Run("leaves error alone when not OOM killed", func(t *testing.T) {
+		original := &models.TrialError{Type: models.ErrAgentExecutionFailed}
+		result := &models.TrialResult{Error: original}
+		classifyOOM(ctx, &fakeOOMEnvironment{oomKilled: false}, 137, result, slog.Default())
+		if result.Error != original {
+			t.Errorf("expected error to be left untouched")
+		}
+	})
+
+	t.Run("ignores non-137 exit codes", func(t *testing.T) {
+		original := &models.TrialError{Type: models.ErrAgentExecutionFailed}
+		result := &models.TrialResult{Error: original}
+		classifyOOM(ctx, &fakeOOMEnvironment{oomKilled: true}, 1, result, slog.Default())
+		if result.Error != original {
+			t.Errorf("expected error to be left untouched for non-137 exit code")
+		}
+	})
+}
+
+// fakeLogsEnvironment is a minimal environment.Environment for testing
+// collectLogs: Exec answers `ls -A1 /logs` with a fixed entry list, and
+// CopyFrom tracks how many calls are in flight concurrently so tests can
+// assert collectLogs never exceeds logCollectionConcurrency.
+type fakeLogsEnvironment struct {
+	entries []string
+
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	copied      []string
+	failEntries map[string]bool
+}
+
+func (f *fakeLogsEnvironment) ID() string                                        { return "fake" }
+func (f *fakeLogsEnvironment) CopyTo(ctx context.Context, src, dst string) error { return nil }
+
+func (f *fakeLogsEnvironment) CopyFrom(ctx context.Context, src, dst string) error {
+	f.mu.Lock()
+	f.inFlight++
+	if f.inFlight > f.maxInFlight {
+		f.maxInFlight = f.inFlight
+	}
+	f.mu.Unlock()
+
+	time.Sleep(time.Millisecond)
+
+	f.mu.Lock()
+	f.inFlight--
+	f.copied = append(f.copied, src)
+	fail := f.failEntries[strings.TrimPrefix(src, "/logs/")]
+	f.mu.Unlock()
+
+	if fail {
+		return fmt.Errorf("simulated failure for %s", src)
+	}
+	return nil
+}
+
+func (f *fakeLogsEnvironment) Exec(ctx context.Context, cmd string, stdout, stderr io.Writer, opts environment.ExecOptions) (int, error) {
+	fmt.Fprint(stdout, strings.Join(f.entries, "\n"))
+	return 0, nil
+}
+func (f *fakeLogsEnvironment) Stop(ctx context.Context) error    { return nil }
+func (f *fakeLogsEnvironment) Destroy(ctx context.Context) error { return nil }
+func (f *fakeLogsEnvironment) Cost() float64                     { return 0 }
+
+func TestCollectLogsBoundsConcurrency(t *testing.T) {
+	var entries []string
+	for i := 0; i < logCollectionConcurrency*3; i++ {
+		entries = append(entries, fmt.Sprintf("entry-%d", i))
+	}
+	env := &fakeLogsEnvironment{entries: entries}
+	logsDir := t.TempDir()
+
+	failures := collectLogs(context.Background(), env, logsDir, slog.Default())
+	if len(failures) != 0 {
+		t.Errorf("expected no failures, got %v", failures)
+	}
+	if len(env.copied) != len(entries) {
+		t.Errorf("expected %d entries copied, got %d", len(entries), len(env.copied))
+	}
+	if env.maxInFlight > logCollectionConcurrency {
+		t.Errorf("expected at most %d concurrent copies, saw %d", logCollectionConcurrency, env.maxInFlight)
+	}
+}
+
+func TestCollectLogsReportsFailures(t *testing.T) {
+	env := &fakeLogsEnvironment{
+		entries:     []string{"good", "bad"},
+		failEntries: map[string]bool{"bad": true},
+	}
+	logsDir := t.TempDir()
+
+	failures := collectLogs(context.Background(), env, logsDir, slog.Default())
+	if len(failures) != 1 || !strings.Contains(failures[0], "bad") {
+		t.Errorf("expected one failure for entry %q, got %v", "bad", failures)
+	}
+}
+
+func TestVerifyImageDigestFailsWhenDockerUnavailable(t *testing.T) {
+	err := verifyImageDigest(context.Background(), "rollout-test:does-not-exist", "sha256:deadbeef")
+	if err == nil {
+		t.Fatal("expected an error since docker is not available in this environment")
+	}
+}
+
+func TestNewPhaseResult(t *testing.T) {
+	pr := newPhaseResult(phaseAgentInstall, 0, "setup/stdout.txt", "setup/stderr.txt", nil)
+	if pr.Status != "completed" {
+		t.Errorf("expected status completed, got %q", pr.Status)
+	}
+	if pr.ExitCode == nil || *pr.ExitCode != 0 {
+		t.Errorf("expected exit code 0, got %v", pr.ExitCode)
+	}
+
+	pr = newPhaseResult(phaseAgentInstall, -1, "", "", nil)
+	if pr.ExitCode != nil {
+		t.Errorf("expected nil exit code for skipped phase, got %v", *pr.ExitCode)
+	}
+
+	pr = newPhaseResult(phaseVerifier, 1, "", "", &models.TrialError{Type: models.ErrVerifierFailed})
+	if pr.Status != "failed" {
+		t.Errorf("expected status failed, got %q", pr.Status)
+	}
+}
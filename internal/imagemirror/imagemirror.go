@@ -0,0 +1,129 @@
+// Package imagemirror manages an optional local pull-through registry
+// cache (a registry:2 container in proxy mode), so hundreds of trials
+// pulling the same base images hit a local cache instead of Docker Hub's
+// rate limits.
+package imagemirror
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// defaultPort is the host port a Mirror listens on when Config.Port is zero.
+const defaultPort = 5000
+
+// defaultRemoteURL is the upstream registry a Mirror proxies and caches
+// pulls from when Config.RemoteURL is empty.
+const defaultRemoteURL = "https://registry-1.docker.io"
+
+// readyTimeout bounds how long Start waits for the mirror container to
+// start accepting connections before giving up.
+const readyTimeout = 30 * time.Second
+
+// Config configures a Mirror.
+type Config struct {
+	// Port is the host port the pull-through cache listens on. Zero uses
+	// defaultPort.
+	Port int
+	// RemoteURL is the upstream registry to proxy and cache pulls from.
+	// Empty uses defaultRemoteURL (Docker Hub).
+	RemoteURL string
+	// CacheDir, if set, bind-mounts this host directory as the registry's
+	// blob storage, so the cache survives across jobs instead of starting
+	// empty every run.
+	CacheDir string
+}
+
+// Mirror is a running registry:2 pull-through cache container.
+type Mirror struct {
+	containerID string
+	addr        string
+}
+
+// Addr returns the mirror's host:port (e.g. "localhost:5000"), the form
+// docker.ProviderConfig.RegistryMirror expects.
+func (m *Mirror) Addr() string {
+	return m.addr
+}
+
+// Start brings up a registry:2 container configured as a pull-through
+// cache for cfg.RemoteURL and waits for it to accept connections.
+func Start(ctx context.Context, cfg Config) (*Mirror, error) {
+	port := cfg.Port
+	if port == 0 {
+		port = defaultPort
+	}
+	remoteURL := cfg.RemoteURL
+	if remoteURL == "" {
+		remoteURL = defaultRemoteURL
+	}
+
+	containerID := fmt.Sprintf("rollout-image-mirror-%d", time.Now().UnixNano())
+	args := []string{
+		"run", "-d",
+		"--name", containerID,
+		"-p", fmt.Sprintf("%d:5000", port),
+		"-e", "REGISTRY_PROXY_REMOTEURL=" + remoteURL,
+	}
+	if cfg.CacheDir != "" {
+		args = append(args, "-v", cfg.CacheDir+":/var/lib/registry")
+	}
+	args = append(args, "registry:2")
+
+	slog.Debug("starting image mirror", "port", port, "remote_url", remoteURL, "cache_dir", cfg.CacheDir)
+
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("starting image mirror container: %w: %s", err, stderr.String())
+	}
+
+	addr := "localhost:" + strconv.Itoa(port)
+	if err := waitForReady(ctx, addr); err != nil {
+		if rmErr := exec.CommandContext(ctx, "docker", "rm", "-f", containerID).Run(); rmErr != nil {
+			slog.Warn("removing image mirror container after failed startup also failed", "error", rmErr)
+		}
+		return nil, fmt.Errorf("waiting for image mirror to become ready: %w", err)
+	}
+
+	slog.Debug("image mirror ready", "addr", addr)
+	return &Mirror{containerID: containerID, addr: addr}, nil
+}
+
+// waitForReady polls the registry's /v2/ endpoint, its standard liveness
+// check, until it responds or readyTimeout elapses.
+func waitForReady(ctx context.Context, addr string) error {
+	ctx, cancel := context.WithTimeout(ctx, readyTimeout)
+	defer cancel()
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	for {
+		resp, err := client.Get("http://" + addr + "/v2/")
+		if err == nil {
+			resp.Body.Close()
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out after %s", readyTimeout)
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// Stop removes the mirror container.
+func (m *Mirror) Stop(ctx context.Context) error {
+	slog.Debug("stopping image mirror", "container_id", m.containerID)
+	if err := exec.CommandContext(ctx, "docker", "rm", "-f", m.containerID).Run(); err != nil {
+		return fmt.Errorf("removing image mirror container: %w", err)
+	}
+	return nil
+}
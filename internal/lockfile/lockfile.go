@@ -0,0 +1,122 @@
+// Package lockfile generates and reads rollout.lock, which pins the exact
+// registry dataset versions, git commits, and task environment content
+// hashes a job resolved against, so a later `--locked` run can reproduce
+// precisely the same inputs instead of re-resolving "latest".
+package lockfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spachava753/rollout/internal/models"
+	"github.com/spachava753/rollout/internal/util"
+)
+
+// FileName is the conventional name for a lockfile, written alongside the
+// job.yaml it was generated from (mirroring package-lock.json, Cargo.lock,
+// go.sum).
+const FileName = "rollout.lock"
+
+// Lockfile pins the resolved inputs of a job run.
+type Lockfile struct {
+	Datasets []DatasetEntry `json:"datasets"`
+}
+
+// DatasetEntry pins one job.yaml dataset entry.
+type DatasetEntry struct {
+	Name    string      `json:"name"`
+	Version string      `json:"version,omitempty"`
+	Tasks   []TaskEntry `json:"tasks"`
+}
+
+// TaskEntry pins a single task within a dataset.
+type TaskEntry struct {
+	Name string `json:"name"`
+	// GitURL and GitCommitID pin the registry source the task was loaded
+	// from. Empty for tasks loaded from a local path rather than a registry.
+	GitURL      string `json:"git_url,omitempty"`
+	GitCommitID string `json:"git_commit_id,omitempty"`
+	// EnvironmentHash is a content hash of the task's environment
+	// directory (the same hash used for image_cache.keep tags), pinning
+	// the exact build inputs even for tasks with no git history.
+	EnvironmentHash string `json:"environment_hash,omitempty"`
+}
+
+// Generate builds a Lockfile from the resolved datasets of a job run.
+func Generate(datasets []models.Dataset) (Lockfile, error) {
+	lf := Lockfile{Datasets: make([]DatasetEntry, 0, len(datasets))}
+
+	for _, ds := range datasets {
+		entry := DatasetEntry{
+			Name:    ds.Name,
+			Version: ds.Version,
+			Tasks:   make([]TaskEntry, 0, len(ds.Tasks)),
+		}
+
+		for _, t := range ds.Tasks {
+			te := TaskEntry{Name: t.Name, GitURL: t.GitURL}
+			if t.GitCommitID != nil {
+				te.GitCommitID = *t.GitCommitID
+			}
+
+			if t.FS != nil {
+				if envFS, err := t.Environment(); err == nil {
+					if hash, err := util.HashFS(envFS); err == nil {
+						te.EnvironmentHash = hash
+					}
+				}
+			}
+
+			entry.Tasks = append(entry.Tasks, te)
+		}
+
+		lf.Datasets = append(lf.Datasets, entry)
+	}
+
+	return lf, nil
+}
+
+// Write saves lf as JSON to path, creating it or overwriting it.
+func Write(path string, lf Lockfile) error {
+	data, err := json.MarshalIndent(lf, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling lockfile: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("writing lockfile %s: %w", path, err)
+	}
+	return nil
+}
+
+// Load reads and parses a lockfile from path.
+func Load(path string) (Lockfile, error) {
+	var lf Lockfile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return lf, fmt.Errorf("reading lockfile %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &lf); err != nil {
+		return lf, fmt.Errorf("parsing lockfile %s: %w", path, err)
+	}
+	return lf, nil
+}
+
+// CommitPins returns the git commit pins for a dataset's tasks, keyed by
+// task name, for use with registry.Resolver.Resolve. Returns nil if the
+// dataset isn't present in the lockfile.
+func (lf Lockfile) CommitPins(datasetName string) map[string]string {
+	for _, ds := range lf.Datasets {
+		if ds.Name != datasetName {
+			continue
+		}
+		pins := make(map[string]string, len(ds.Tasks))
+		for _, t := range ds.Tasks {
+			if t.GitCommitID != "" {
+				pins[t.Name] = t.GitCommitID
+			}
+		}
+		return pins
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package lockfile
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spachava753/rollout/internal/models"
+)
+
+func TestGenerateAndWriteLoadRoundTrip(t *testing.T) {
+	commit := "abc123"
+	datasets := []models.Dataset{
+		{
+			Name:    "swe-bench",
+			Version: "1.0",
+			Tasks: []models.Task{
+				{Name: "task-a", GitCommitID: &commit},
+				{Name: "task-b"},
+			},
+		},
+	}
+
+	lf, err := Generate(datasets)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if len(lf.Datasets) != 1 || len(lf.Datasets[0].Tasks) != 2 {
+		t.Fatalf("unexpected lockfile shape: %+v", lf)
+	}
+	if lf.Datasets[0].Tasks[0].GitCommitID != commit {
+		t.Errorf("task-a GitCommitID = %q, want %q", lf.Datasets[0].Tasks[0].GitCommitID, commit)
+	}
+
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := Write(path, lf); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(loaded.Datasets) != 1 || loaded.Datasets[0].Name != "swe-bench" {
+		t.Errorf("round-tripped lockfile mismatch: %+v", loaded)
+	}
+}
+
+func TestCommitPins(t *testing.T) {
+	commitA := "abc123"
+	lf := Lockfile{
+		Datasets: []DatasetEntry{
+			{
+				Name: "swe-bench",
+				Tasks: []TaskEntry{
+					{Name: "task-a", GitCommitID: commitA},
+					{Name: "task-b"},
+				},
+			},
+		},
+	}
+
+	pins := lf.CommitPins("swe-bench")
+	if pins["task-a"] != commitA {
+		t.Errorf("task-a pin = %q, want %q", pins["task-a"], commitA)
+	}
+	if _, ok := pins["task-b"]; ok {
+		t.Errorf("task-b should have no pin (no git commit recorded)")
+	}
+
+	if pins := lf.CommitPins("unknown-dataset"); pins != nil {
+		t.Errorf("CommitPins(unknown) = %v, want nil", pins)
+	}
+}
@@ -0,0 +1,115 @@
+// Package mockllm implements a minimal OpenAI-chat-completions-compatible
+// HTTP server that serves recorded or stubbed responses instead of calling a
+// real LLM API. Pointing an agent at it (e.g. via an OPENAI_BASE_URL entry
+// in job.yaml's agent.env) gives fully offline, deterministic integration
+// tests of agents, and of rollout itself.
+package mockllm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// defaultStub is served when neither a fixture nor Config.Stub applies, so
+// the server always answers something even with zero configuration.
+const defaultStub = `{"id":"mock-llm","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"mock response"},"finish_reason":"stop"}]}`
+
+// Config configures a Server.
+type Config struct {
+	// Addr is the address to listen on (e.g. "127.0.0.1:8089"). Empty, or a
+	// port of 0, picks a random free port.
+	Addr string
+	// FixturesDir, if set, serves recorded responses from files named
+	// "<sha256-of-request-body-hex>.json", one JSON response body per file.
+	// A request with no matching fixture falls back to Stub.
+	FixturesDir string
+	// Stub is the response body served when FixturesDir is empty or has no
+	// fixture matching a request. Empty uses defaultStub.
+	Stub string
+}
+
+// Server is a mockllm HTTP server. Create one with NewServer and call Start
+// to begin listening.
+type Server struct {
+	cfg     Config
+	httpSrv *http.Server
+}
+
+// NewServer creates a Server from cfg. It does not start listening until
+// Start is called.
+func NewServer(cfg Config) *Server {
+	return &Server{cfg: cfg}
+}
+
+// Start begins listening and serving in the background, returning the base
+// URL agents should point an OpenAI-compatible client at (e.g.
+// "http://127.0.0.1:8089/v1"). Call Stop to shut it down.
+func (s *Server) Start() (string, error) {
+	ln, err := net.Listen("tcp", s.cfg.Addr)
+	if err != nil {
+		return "", fmt.Errorf("listening on %s: %w", s.cfg.Addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/chat/completions", s.handleCompletion)
+	mux.HandleFunc("/v1/completions", s.handleCompletion)
+	s.httpSrv = &http.Server{Handler: mux}
+
+	go func() {
+		if err := s.httpSrv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			slog.Error("mock llm server stopped unexpectedly", "error", err)
+		}
+	}()
+
+	return "http://" + ln.Addr().String() + "/v1", nil
+}
+
+// Stop shuts down the server, closing its listener.
+func (s *Server) Stop() error {
+	if s.httpSrv == nil {
+		return nil
+	}
+	return s.httpSrv.Close()
+}
+
+func (s *Server) handleCompletion(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := s.fixtureFor(body)
+	if resp == "" {
+		resp = s.cfg.Stub
+	}
+	if resp == "" {
+		resp = defaultStub
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(resp))
+}
+
+// fixtureFor returns the recorded response body for a request, keyed by the
+// SHA-256 hash of its exact body, or "" if FixturesDir is unset or has no
+// matching file.
+func (s *Server) fixtureFor(body []byte) string {
+	if s.cfg.FixturesDir == "" {
+		return ""
+	}
+	sum := sha256.Sum256(body)
+	path := filepath.Join(s.cfg.FixturesDir, hex.EncodeToString(sum[:])+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,91 @@
+package mockllm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServerDefaultStub(t *testing.T) {
+	srv := NewServer(Config{Addr: "127.0.0.1:0"})
+	baseURL, err := srv.Start()
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer srv.Stop()
+
+	resp, err := http.Post(baseURL+"/chat/completions", "application/json", strings.NewReader(`{"messages":[]}`))
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "mock response") {
+		t.Errorf("expected default stub in response, got %q", body)
+	}
+}
+
+func TestServerConfiguredStub(t *testing.T) {
+	srv := NewServer(Config{Addr: "127.0.0.1:0", Stub: `{"content":"configured stub"}`})
+	baseURL, err := srv.Start()
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer srv.Stop()
+
+	resp, err := http.Post(baseURL+"/chat/completions", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != `{"content":"configured stub"}` {
+		t.Errorf("got %q, want configured stub", body)
+	}
+}
+
+func TestServerFixtureMatch(t *testing.T) {
+	fixturesDir := t.TempDir()
+	reqBody := `{"messages":[{"role":"user","content":"hello"}]}`
+	sum := sha256.Sum256([]byte(reqBody))
+	fixturePath := filepath.Join(fixturesDir, hex.EncodeToString(sum[:])+".json")
+	if err := os.WriteFile(fixturePath, []byte(`{"content":"recorded answer"}`), 0644); err != nil {
+		t.Fatalf("writing fixture: %v", err)
+	}
+
+	srv := NewServer(Config{Addr: "127.0.0.1:0", FixturesDir: fixturesDir, Stub: `{"content":"fallback"}`})
+	baseURL, err := srv.Start()
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer srv.Stop()
+
+	// Matching request body gets the fixture.
+	resp, err := http.Post(baseURL+"/chat/completions", "application/json", strings.NewReader(reqBody))
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != `{"content":"recorded answer"}` {
+		t.Errorf("got %q, want recorded fixture", body)
+	}
+
+	// Non-matching request body falls back to Stub.
+	resp, err = http.Post(baseURL+"/chat/completions", "application/json", strings.NewReader(`{"messages":[]}`))
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != `{"content":"fallback"}` {
+		t.Errorf("got %q, want fallback stub", body)
+	}
+}
@@ -1,15 +1,110 @@
 package models
 
+import "fmt"
+
 // Agent represents an agent definition from job.yaml.
 type Agent struct {
-	Name        string            `yaml:"name" json:"name"`
-	Description string            `yaml:"description,omitempty" json:"description,omitempty"`
-	Install     string            `yaml:"install,omitempty" json:"install,omitempty"`
-	Execute     string            `yaml:"execute,omitempty" json:"execute,omitempty"`
-	Env         map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Install     string `yaml:"install,omitempty" json:"install,omitempty"`
+	Execute     string `yaml:"execute,omitempty" json:"execute,omitempty"`
+	// Env sets environment variables for both the install and execute
+	// phases, unless InstallEnv or ExecuteEnv overrides it for that phase
+	// specifically. Never passed to the verifier phase, which has no
+	// default visibility into agent secrets; see VerifierConfig.Env for
+	// verifier-specific vars.
+	Env map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+	// InstallEnv, if set, replaces Env for the install phase only (e.g. a
+	// package registry token the execute phase doesn't need).
+	InstallEnv map[string]string `yaml:"install_env,omitempty" json:"install_env,omitempty"`
+	// ExecuteEnv, if set, replaces Env for the execute phase only (e.g. an
+	// LLM API key the install phase doesn't need).
+	ExecuteEnv map[string]string `yaml:"execute_env,omitempty" json:"execute_env,omitempty"`
+	// User runs Install and Execute as this user/UID instead of the
+	// image's default, for images that run as a non-root user by default
+	// but whose install step needs root (or vice versa). Empty uses the
+	// image's own default. Only honored by providers whose Exec supports
+	// ExecOptions.User (currently docker, apple, and modal).
+	User string `yaml:"user,omitempty" json:"user,omitempty"`
+	// Models, if set, expands this agent into one variant per entry via
+	// ExpandAgents instead of running it as written: each variant is named
+	// "<name>-<model>", has MODEL set in Env (and InstallEnv/ExecuteEnv, if
+	// those override Env), and records model in Model. This is the
+	// built-in version of the name/model/env agent block users otherwise
+	// hand-write once per model.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+	// Model records which entry of Models a variant produced by
+	// ExpandAgents resolved to. Left empty on an agent that doesn't set
+	// Models.
+	Model string `yaml:"-" json:"model,omitempty"`
 }
 
 // IsOracle returns true if this is the special oracle agent.
 func (a Agent) IsOracle() bool {
 	return a.Name == "oracle"
 }
+
+// InstallEnvVars returns the environment variables to use for the install
+// phase: InstallEnv if set, otherwise Env.
+func (a Agent) InstallEnvVars() map[string]string {
+	if a.InstallEnv != nil {
+		return a.InstallEnv
+	}
+	return a.Env
+}
+
+// ExecuteEnvVars returns the environment variables to use for the execute
+// phase: ExecuteEnv if set, otherwise Env.
+func (a Agent) ExecuteEnvVars() map[string]string {
+	if a.ExecuteEnv != nil {
+		return a.ExecuteEnv
+	}
+	return a.Env
+}
+
+// ExpandAgents expands every agent with a non-empty Models into one
+// variant per model (see Agent.Models), leaving agents without Models
+// untouched. Callers should run it once over a JobConfig's Agents before
+// generating trials, so the rest of the pipeline never needs to know
+// Models exists.
+func ExpandAgents(agents []Agent) []Agent {
+	expanded := make([]Agent, 0, len(agents))
+	for _, agent := range agents {
+		if len(agent.Models) == 0 {
+			expanded = append(expanded, agent)
+			continue
+		}
+		for _, model := range agent.Models {
+			expanded = append(expanded, agent.withModel(model))
+		}
+	}
+	return expanded
+}
+
+// withModel returns a's variant for model: renamed "<a.Name>-<model>",
+// with Models cleared, Model set, and MODEL injected into Env (and
+// InstallEnv/ExecuteEnv, if those override Env).
+func (a Agent) withModel(model string) Agent {
+	v := a
+	v.Name = fmt.Sprintf("%s-%s", a.Name, model)
+	v.Model = model
+	v.Models = nil
+	v.Env = envWithModel(a.Env, model)
+	if a.InstallEnv != nil {
+		v.InstallEnv = envWithModel(a.InstallEnv, model)
+	}
+	if a.ExecuteEnv != nil {
+		v.ExecuteEnv = envWithModel(a.ExecuteEnv, model)
+	}
+	return v
+}
+
+// envWithModel returns a copy of env with MODEL set to model.
+func envWithModel(env map[string]string, model string) map[string]string {
+	out := make(map[string]string, len(env)+1)
+	for k, v := range env {
+		out[k] = v
+	}
+	out["MODEL"] = model
+	return out
+}
@@ -5,17 +5,37 @@ type ErrorType string
 
 const (
 	// Environment build phase
-	ErrEnvironmentBuildFailed              ErrorType = "environment_build_failed"
-	ErrEnvironmentBuildTimeout             ErrorType = "environment_build_timeout"
-	ErrEnvironmentImagePullFailed          ErrorType = "environment_image_pull_failed"
+	ErrEnvironmentBuildFailed         ErrorType = "environment_build_failed"
+	ErrEnvironmentBuildTimeout        ErrorType = "environment_build_timeout"
+	ErrEnvironmentImagePullFailed     ErrorType = "environment_image_pull_failed"
+	ErrEnvironmentImageDigestMismatch ErrorType = "environment_image_digest_mismatch"
+	// ErrEnvironmentImageNotFound marks a build/pull failure where the
+	// image itself doesn't exist (a typo'd tag, a deleted image), as
+	// opposed to a pull failing for some other reason.
+	ErrEnvironmentImageNotFound ErrorType = "environment_image_not_found"
+	// ErrEnvironmentAuthFailed marks a build/pull failure caused by missing
+	// or rejected registry credentials, distinct from the image simply not
+	// existing.
+	ErrEnvironmentAuthFailed ErrorType = "environment_auth_failed"
+	// ErrEnvironmentQuotaExceeded marks a failure where the provider
+	// rejected the request because an account or resource quota was
+	// exhausted, not because of anything wrong with the task itself.
+	ErrEnvironmentQuotaExceeded ErrorType = "environment_quota_exceeded"
+	// ErrEnvironmentNoSpace marks a failure caused by the host or provider
+	// running out of disk space, distinct from a generic build failure.
+	ErrEnvironmentNoSpace ErrorType = "environment_no_space"
+	// ErrEnvironmentNetworkUnreachable marks a failure caused by the
+	// provider being unable to reach a registry or API endpoint over the
+	// network, as opposed to that endpoint rejecting the request.
+	ErrEnvironmentNetworkUnreachable ErrorType = "environment_network_unreachable"
 
 	// Environment start phase
 	ErrEnvironmentStartFailed              ErrorType = "environment_start_failed"
 	ErrEnvironmentResourceAllocationFailed ErrorType = "environment_resource_allocation_failed"
 
 	// Agent install phase
-	ErrAgentInstallFailed   ErrorType = "agent_install_failed"
-	ErrAgentInstallTimeout  ErrorType = "agent_install_timeout"
+	ErrAgentInstallFailed  ErrorType = "agent_install_failed"
+	ErrAgentInstallTimeout ErrorType = "agent_install_timeout"
 
 	// Agent execution phase
 	ErrAgentExecutionFailed  ErrorType = "agent_execution_failed"
@@ -30,6 +50,13 @@ const (
 	// Teardown phase
 	ErrEnvironmentTeardownFailed ErrorType = "environment_teardown_failed"
 
+	// Resource exhaustion, detected during agent or verifier phases
+	ErrEnvironmentOOM ErrorType = "environment_oom"
+	// ErrEnvironmentInterrupted marks a failure caused by a preemptible/spot
+	// instance being reclaimed mid-trial (e.g. outbid on Vast.ai), detected
+	// during agent or verifier phases.
+	ErrEnvironmentInterrupted ErrorType = "environment_interrupted"
+
 	// Pre-execution
 	ErrTaskInvalid  ErrorType = "task_invalid"
 	ErrTaskNotFound ErrorType = "task_not_found"
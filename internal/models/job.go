@@ -1,6 +1,11 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
 
 // PreservePolicy controls environment cleanup behavior.
 type PreservePolicy string
@@ -11,6 +16,45 @@ const (
 	PreserveOnFailure PreservePolicy = "on_failure"
 )
 
+// ProviderTypes is JobEnvironmentConfig.Type's value: either a single
+// provider name or a prioritized list of provider names to fail over
+// through. UnmarshalYAML accepts both forms so existing single-provider
+// job.yaml files keep working unchanged.
+type ProviderTypes []string
+
+// UnmarshalYAML accepts a scalar provider name or a sequence of provider
+// names, normalizing both into a slice.
+func (pt *ProviderTypes) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*pt = ProviderTypes{value.Value}
+		return nil
+	}
+	var list []string
+	if err := value.Decode(&list); err != nil {
+		return fmt.Errorf("environment.type must be a provider name or a list of provider names: %w", err)
+	}
+	*pt = ProviderTypes(list)
+	return nil
+}
+
+// Primary returns the first provider name, the one used when Type carries
+// no failover list. Empty if Type itself is empty.
+func (pt ProviderTypes) Primary() string {
+	if len(pt) == 0 {
+		return ""
+	}
+	return pt[0]
+}
+
+// String implements fmt.Stringer so ProviderTypes prints naturally in log
+// lines and error messages (e.g. "[modal docker]" or "docker").
+func (pt ProviderTypes) String() string {
+	if len(pt) == 1 {
+		return pt[0]
+	}
+	return fmt.Sprintf("%v", []string(pt))
+}
+
 // JobConfig represents the parsed job.yaml configuration.
 type JobConfig struct {
 	Name              *string              `yaml:"name,omitempty" json:"name,omitempty"`
@@ -26,6 +70,134 @@ type JobConfig struct {
 	Metrics           []MetricConfig       `yaml:"metrics,omitempty" json:"metrics,omitempty"`
 	Agents            []Agent              `yaml:"agents" json:"agents"`
 	Datasets          []DatasetRef         `yaml:"datasets" json:"datasets"`
+	Retention         RetentionConfig      `yaml:"retention,omitempty" json:"retention,omitempty"`
+	DiskGuard         DiskGuardConfig      `yaml:"disk_guard,omitempty" json:"disk_guard,omitempty"`
+	Diff              DiffConfig           `yaml:"diff,omitempty" json:"diff,omitempty"`
+	// Seed, if set, is used for any randomized behavior in this job (e.g.
+	// dataset shuffling, agent sampling) and is recorded in provenance.json
+	// so the run can be reproduced. Left unset, the orchestrator generates
+	// one and records it the same way.
+	Seed    *int64        `yaml:"seed,omitempty" json:"seed,omitempty"`
+	Budgets BudgetsConfig `yaml:"budgets,omitempty" json:"budgets,omitempty"`
+	Scoring ScoringConfig `yaml:"scoring,omitempty" json:"scoring,omitempty"`
+	// Labels are arbitrary caller-defined key/value tags (e.g. experiment,
+	// git_sha, owner) copied verbatim onto JobResult and every TrialResult,
+	// so downstream tooling reading the jobs directory can filter or group
+	// runs by them without re-deriving the context from job.yaml itself.
+	Labels      map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	SanityCheck SanityCheckConfig `yaml:"sanity_check,omitempty" json:"sanity_check,omitempty"`
+	// Mode selects what a job run does. Empty (or ModeRun) runs every
+	// agent's trials as usual; ModeValidate instead runs only each task's
+	// environment build, oracle solution, and verifier once, skipping
+	// Agents entirely, to spot-check a dataset's own health.
+	Mode JobMode `yaml:"mode,omitempty" json:"mode,omitempty"`
+	// Redaction scrubs secrets out of captured agent/verifier output and
+	// saved configs (config.json, provenance.json) before they're written
+	// under jobs_dir.
+	Redaction RedactionConfig `yaml:"redaction,omitempty" json:"redaction,omitempty"`
+}
+
+// RedactionConfig configures scrubbing of secrets from captured stdout/
+// stderr and saved job configs, so an agent that echoes an API key (or a
+// job.yaml that inlines one in agent.env) doesn't leave it sitting in
+// plaintext under jobs_dir.
+type RedactionConfig struct {
+	// Enabled turns on redaction. Off by default since it adds a pass over
+	// every captured log and saved config.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Secrets are literal values (e.g. an agent's API key, read from the
+	// job's own environment at config-load time) replaced verbatim
+	// wherever they appear.
+	Secrets []string `yaml:"secrets,omitempty" json:"secrets,omitempty"`
+	// Patterns are regexes (Go RE2 syntax, e.g. `sk-[A-Za-z0-9]+`) matched
+	// against captured text in addition to Secrets, for secret shapes
+	// whose exact value isn't known ahead of time.
+	Patterns []string `yaml:"patterns,omitempty" json:"patterns,omitempty"`
+}
+
+// JobMode selects what a job run does. See JobConfig.Mode.
+type JobMode string
+
+const (
+	// ModeRun runs every configured agent's trials. The default when Mode
+	// is empty.
+	ModeRun JobMode = "run"
+	// ModeValidate runs only each task's environment build, oracle
+	// solution, and verifier once, producing a per-task health report
+	// instead of scoring any agent.
+	ModeValidate JobMode = "validate"
+)
+
+// SanityCheckConfig controls whether the oracle agent runs once per task
+// before any real agent's trials, so a task whose own solution/verifier is
+// broken is caught and excluded instead of silently dragging down every
+// agent's score on a task nobody could have solved anyway.
+type SanityCheckConfig struct {
+	// Enabled turns on the oracle pre-flight pass.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+}
+
+// ScoringConfig controls how failed (errored) trials are counted when
+// aggregating pass rate and mean reward.
+type ScoringConfig struct {
+	// CountFailuresAsZero includes failed trials in the pass-rate and
+	// mean-reward denominators, scoring them as reward 0, instead of the
+	// default of excluding them entirely. Excluding failures inflates an
+	// agent's score when its environment flakes rather than its policy
+	// failing the task.
+	CountFailuresAsZero bool `yaml:"count_failures_as_zero,omitempty" json:"count_failures_as_zero,omitempty"`
+}
+
+// BudgetsConfig caps cumulative time or cost spent across attempts, on top
+// of the per-trial timeouts already enforced by TimeoutMultiplier and the
+// verifier's own timeout. Once a cap is hit, remaining attempts for the
+// task or agent it applies to are skipped rather than started.
+type BudgetsConfig struct {
+	// PerTaskSec caps the cumulative wall-clock duration (across all agents
+	// and attempts) spent on a single task. Zero disables the cap.
+	PerTaskSec float64 `yaml:"per_task_sec,omitempty" json:"per_task_sec,omitempty"`
+	// PerAgentCost caps the cumulative Cost (across all tasks and attempts)
+	// run up by a single agent. Zero disables the cap.
+	PerAgentCost float64 `yaml:"per_agent_cost,omitempty" json:"per_agent_cost,omitempty"`
+}
+
+// DiffConfig controls whether a workspace diff is captured after agent
+// execution, for forensic review and `rollout replay`.
+type DiffConfig struct {
+	// Enabled turns on diff capture.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Command is run inside the environment to produce the diff; its
+	// stdout is saved as the trial's workspace patch. Defaults to
+	// "git diff" when Enabled is set and Command is empty.
+	Command string `yaml:"command,omitempty" json:"command,omitempty"`
+}
+
+// RetentionConfig controls how old job result directories under JobsDir are
+// pruned or archived, so local disk usage doesn't grow without bound across
+// repeated runs of the same named job.
+type RetentionConfig struct {
+	// KeepRecent is how many of the most recent job run directories to keep
+	// per job name. Zero (the default) disables retention entirely.
+	KeepRecent int `yaml:"keep_recent,omitempty" json:"keep_recent,omitempty"`
+	// ArchiveDir, if set, receives a .tar.gz of each job directory before it
+	// is removed. Left empty, pruned directories are deleted outright.
+	ArchiveDir string `yaml:"archive_dir,omitempty" json:"archive_dir,omitempty"`
+}
+
+// DiskGuardConfig controls the free-space check run against JobsDir's
+// filesystem before a job starts scheduling trials, estimating what the run
+// needs as logs, built images, and dataset clones accumulate.
+type DiskGuardConfig struct {
+	// PerTrialEstimateMB estimates the disk a single trial consumes (logs,
+	// diffs, and its share of image layers). Defaults to 500 when zero.
+	PerTrialEstimateMB int64 `yaml:"per_trial_estimate_mb,omitempty" json:"per_trial_estimate_mb,omitempty"`
+	// ReserveMB is additional headroom to hold back for everything else on
+	// the filesystem, on top of the per-trial estimate.
+	ReserveMB int64 `yaml:"reserve_mb,omitempty" json:"reserve_mb,omitempty"`
+	// OnShortfall selects what happens when the estimate exceeds free space:
+	// "warn" (the default) logs and proceeds anyway; "block" fails the job
+	// before any trial starts.
+	OnShortfall string `yaml:"on_shortfall,omitempty" json:"on_shortfall,omitempty"`
 }
 
 type RetryConfig struct {
@@ -36,19 +208,181 @@ type RetryConfig struct {
 }
 
 type JobEnvironmentConfig struct {
-	Type              string         `yaml:"type" json:"type"`
+	// Type selects the environment provider (e.g. "docker", "modal"). May
+	// also be set to a prioritized list (e.g. [modal, docker]) in job.yaml;
+	// if creating an environment on an earlier entry fails with a capacity
+	// or auth error, the orchestrator retries the trial on the next one
+	// instead of failing it outright.
+	Type              ProviderTypes  `yaml:"type" json:"type"`
 	ForceBuild        bool           `yaml:"force_build" json:"force_build"`
 	PreserveEnv       PreservePolicy `yaml:"preserve_env" json:"preserve_env"`
 	ProviderConfig    map[string]any `yaml:"provider_config,omitempty" json:"provider_config,omitempty"`
 	OverrideCPUs      *int           `yaml:"override_cpus,omitempty" json:"override_cpus,omitempty"`
 	OverrideMemoryMB  *int           `yaml:"override_memory_mb,omitempty" json:"override_memory_mb,omitempty"`
 	OverrideStorageMB *int           `yaml:"override_storage_mb,omitempty" json:"override_storage_mb,omitempty"`
+	OverrideGPUCount  *int           `yaml:"override_gpu_count,omitempty" json:"override_gpu_count,omitempty"`
+	OverrideGPUType   *string        `yaml:"override_gpu_type,omitempty" json:"override_gpu_type,omitempty"`
+	// OverrideNetwork forces every task's environment.network to this value
+	// ("full", "none", or "restricted"), e.g. to lock down an entire job's
+	// worth of benchmark tasks regardless of what each task.toml requests.
+	OverrideNetwork *string `yaml:"override_network,omitempty" json:"override_network,omitempty"`
+	// OverrideFakeTime forces every task's environment.fake_time to this
+	// libfaketime string, e.g. to replay a whole job's worth of tasks at a
+	// pinned date regardless of what each task.toml requests.
+	OverrideFakeTime *string `yaml:"override_fake_time,omitempty" json:"override_fake_time,omitempty"`
+	// OverrideNetworkShaping forces every task's environment.network_shaping
+	// to this value, e.g. to simulate a degraded network across an entire
+	// job's worth of tasks regardless of what each task.toml requests.
+	OverrideNetworkShaping *NetworkShapingConfig `yaml:"override_network_shaping,omitempty" json:"override_network_shaping,omitempty"`
+	// OverrideSecurity forces every task's environment.security to this
+	// value, e.g. to harden an entire job's worth of tasks running
+	// untrusted agent code regardless of what each task.toml requests.
+	OverrideSecurity *SecurityConfig `yaml:"override_security,omitempty" json:"override_security,omitempty"`
+	// OverridePidsLimit forces every task's environment.pids_limit to this
+	// value, e.g. to cap fork-bomb blast radius across an entire job's
+	// worth of tasks regardless of what each task.toml requests.
+	OverridePidsLimit *int             `yaml:"override_pids_limit,omitempty" json:"override_pids_limit,omitempty"`
+	ImageCache        ImageCacheConfig `yaml:"image_cache,omitempty" json:"image_cache,omitempty"`
+	// ImageMirror optionally starts a local pull-through registry cache
+	// before the job runs, so hundreds of trials pulling the same base
+	// images (python, ubuntu, etc.) hit it instead of Docker Hub's rate
+	// limits. Only the docker provider's PullImage uses it; a task
+	// building its own Dockerfile must reference the mirror directly in
+	// its FROM line to benefit during a build.
+	ImageMirror ImageMirrorConfig `yaml:"image_mirror,omitempty" json:"image_mirror,omitempty"`
+	// MaxConcurrentEnvironments caps how many BuildImage/CreateEnvironment
+	// calls run at once, independent of n_concurrent_trials. Some providers
+	// (e.g. modal) can run far more trials in parallel than they can build
+	// or start environments for at once; others (e.g. docker, bottlenecked
+	// by local CPU/disk during concurrent builds) need the opposite. Zero
+	// (the default) leaves environment creation unthrottled.
+	MaxConcurrentEnvironments int                   `yaml:"max_concurrent_environments,omitempty" json:"max_concurrent_environments,omitempty"`
+	ResourceMonitor           ResourceMonitorConfig `yaml:"resource_monitor,omitempty" json:"resource_monitor,omitempty"`
+	Scan                      ScanConfig            `yaml:"scan,omitempty" json:"scan,omitempty"`
+	// Caches declares named volumes shared by every trial in this job (and
+	// reused across later jobs, since the volume isn't removed on
+	// teardown), so repeated dependency downloads in agent install phases
+	// (pip, npm, cargo caches) hit a warm cache instead of re-fetching from
+	// the network every trial. Not every provider supports this; providers
+	// without a persistent-volume mechanism ignore it and log a warning.
+	Caches []CacheMountConfig `yaml:"caches,omitempty" json:"caches,omitempty"`
+	// Chaos randomly injects environment-creation failures, exec timeouts,
+	// and copy errors on top of whatever provider.Type configures, so a
+	// large job's retry, resume, and aggregation behavior can be validated
+	// against realistic infrastructure flakiness before it's run for real.
+	Chaos ChaosConfig `yaml:"chaos,omitempty" json:"chaos,omitempty"`
+}
+
+// ChaosConfig controls failure injection for a job's environment provider,
+// applied on top of any provider.Type via executor.newChaosProvider. Each
+// rate is a fraction (0-1) of the relevant calls that fail; zero (the
+// default) disables that dimension. Injected failures look like ordinary
+// provider errors to the rest of the pipeline, so retry/resume/aggregation
+// code paths exercise the same logic they would against a real flaky
+// provider.
+type ChaosConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// CreateFailureRate is the fraction of CreateEnvironment calls that fail
+	// with a simulated provisioning error.
+	CreateFailureRate float64 `yaml:"create_failure_rate,omitempty" json:"create_failure_rate,omitempty"`
+	// ExecTimeoutRate is the fraction of Exec calls that fail as if they'd
+	// timed out, regardless of opts.Timeout.
+	ExecTimeoutRate float64 `yaml:"exec_timeout_rate,omitempty" json:"exec_timeout_rate,omitempty"`
+	// CopyErrorRate is the fraction of CopyTo/CopyFrom calls that fail with
+	// a simulated transport error.
+	CopyErrorRate float64 `yaml:"copy_error_rate,omitempty" json:"copy_error_rate,omitempty"`
+	// Seed seeds the deterministic PRNG deciding which calls fail, so a
+	// chaos run is reproducible across retries of the same job.yaml.
+	Seed int64 `yaml:"seed,omitempty" json:"seed,omitempty"`
+}
+
+// CacheMountConfig is one [[environment.caches]] entry: a named volume
+// mounted into every trial's environment at Path, shared across trials and
+// jobs rather than created fresh per trial like the rest of the
+// environment's filesystem.
+type CacheMountConfig struct {
+	// Name identifies the volume and is reused verbatim across jobs, so two
+	// jobs naming the same cache share its contents.
+	Name string `yaml:"name" json:"name"`
+	// Path is where the volume is mounted inside the environment (e.g.
+	// "/root/.cache/pip").
+	Path string `yaml:"path" json:"path"`
+}
+
+// ScanConfig controls whether each task's built or pulled image is scanned
+// for size and known vulnerabilities, recording the results in the job
+// report for benchmark maintainers curating task images.
+type ScanConfig struct {
+	// Enabled turns on scanning. Requires docker (for image size) and the
+	// configured Scanner CLI (for CVE counts) to be installed; scan
+	// failures are logged and otherwise non-fatal to the trial.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Scanner selects the CLI used to count CVEs: "trivy" (the default when
+	// empty) or "grype".
+	Scanner string `yaml:"scanner,omitempty" json:"scanner,omitempty"`
+}
+
+// ResourceMonitorConfig controls whether container CPU/memory usage is
+// sampled during the agent install/execute phases and recorded on
+// TrialResult.ResourceUsage.
+type ResourceMonitorConfig struct {
+	// Enabled turns on periodic resource sampling. Requires a provider
+	// whose Environment implements environment.ResourceSampler; ignored
+	// otherwise.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// IntervalSec is how often to sample. Defaults to 5 seconds when
+	// Enabled is set and IntervalSec is zero.
+	IntervalSec float64 `yaml:"interval_sec,omitempty" json:"interval_sec,omitempty"`
+}
+
+// ImageCacheConfig controls whether built task images are kept and reused
+// across jobs instead of being rebuilt with a fresh timestamped tag each time.
+type ImageCacheConfig struct {
+	// Keep tags built images deterministically (hash of the environment
+	// context) and reuses them on later jobs instead of rebuilding.
+	Keep bool `yaml:"keep" json:"keep"`
+	// Prefix is prepended to the deterministic image tag. Defaults to
+	// "rollout-cache" when Keep is true and Prefix is empty.
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+	// Registry, if set, pushes each image built locally to this registry
+	// (e.g. "myregistry.example.com/rollout") right after the build, then
+	// points the trial's provider at the pushed reference via PullImage
+	// instead of the local tag. This lets providers that can't build images
+	// themselves (k8s, Fargate) or that otherwise re-parse a task's
+	// Dockerfile per trial (modal) reuse the exact image rollout built, so
+	// a build-once/push/pull workflow replaces per-trial building on those
+	// providers. Only the docker provider's BuildImage actually produces a
+	// local image to push - providers that defer building to
+	// CreateEnvironment (modal) have nothing to push and should instead set
+	// docker_image directly. Pairs with Keep for a stable, content-addressed
+	// tag that later jobs reuse instead of re-pushing unchanged images.
+	Registry string `yaml:"registry,omitempty" json:"registry,omitempty"`
+}
+
+// ImageMirrorConfig controls whether rollout starts a local pull-through
+// registry cache (see internal/imagemirror) before the job runs.
+type ImageMirrorConfig struct {
+	// Enabled starts the mirror.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Port is the host port the mirror listens on. Defaults to 5000 when
+	// Enabled is set and Port is zero.
+	Port int `yaml:"port,omitempty" json:"port,omitempty"`
+	// RemoteURL is the upstream registry to proxy and cache pulls from.
+	// Defaults to Docker Hub when empty.
+	RemoteURL string `yaml:"remote_url,omitempty" json:"remote_url,omitempty"`
+	// CacheDir, if set, bind-mounts this host directory as the mirror's
+	// blob storage, so the cache survives across jobs instead of starting
+	// empty every run.
+	CacheDir string `yaml:"cache_dir,omitempty" json:"cache_dir,omitempty"`
 }
 
 type JobVerifierConfig struct {
 	OverrideTimeoutSec *float64 `yaml:"override_timeout_sec,omitempty" json:"override_timeout_sec,omitempty"`
 	MaxTimeoutSec      *float64 `yaml:"max_timeout_sec,omitempty" json:"max_timeout_sec,omitempty"`
 	Disable            bool     `yaml:"disable" json:"disable"`
+	// OverridePassThreshold, if set, takes precedence over each task's own
+	// pass_threshold for this job's run.
+	OverridePassThreshold *float64 `yaml:"override_pass_threshold,omitempty" json:"override_pass_threshold,omitempty"`
 }
 
 type MetricConfig struct {
@@ -61,6 +395,11 @@ type DatasetRef struct {
 	Registry *RegistryRef `yaml:"registry,omitempty" json:"registry,omitempty"`
 	Name     string       `yaml:"name,omitempty" json:"name,omitempty"`
 	Version  string       `yaml:"version,omitempty" json:"version,omitempty"`
+	// Environment, if set, replaces the job's top-level environment block
+	// for every trial on this dataset (e.g. pinning a dataset of unusually
+	// large images to a remote provider while the rest of the job runs on
+	// local docker). Nil uses the job's own environment block as usual.
+	Environment *JobEnvironmentConfig `yaml:"environment,omitempty" json:"environment,omitempty"`
 }
 
 type RegistryRef struct {
@@ -77,20 +416,69 @@ type Dataset struct {
 
 // JobResult contains aggregate metrics across all trials.
 type JobResult struct {
-	JobName          string                  `json:"job_name"`
-	Cancelled        bool                    `json:"cancelled"`
-	TotalTrials      int                     `json:"total_trials"`
-	CompletedTrials  int                     `json:"completed_trials"`
-	FailedTrials     int                     `json:"failed_trials"`
-	SkippedTrials    int                     `json:"skipped_trials"`
-	PassRate         float64                 `json:"pass_rate"`
-	MeanReward       float64                 `json:"mean_reward"`
-	TotalCost        float64                 `json:"total_cost"`
+	JobName         string  `json:"job_name"`
+	Cancelled       bool    `json:"cancelled"`
+	TotalTrials     int     `json:"total_trials"`
+	CompletedTrials int     `json:"completed_trials"`
+	FailedTrials    int     `json:"failed_trials"`
+	SkippedTrials   int     `json:"skipped_trials"`
+	PassRate        float64 `json:"pass_rate"`
+	MeanReward      float64 `json:"mean_reward"`
+	TotalCost       float64 `json:"total_cost"`
+	// RetriedTrials counts trials whose Status is StatusRetried, i.e. ones
+	// that needed at least one discarded attempt for
+	// models.ErrEnvironmentInterrupted before completing.
+	RetriedTrials int `json:"retried_trials,omitempty"`
+	// TotalWastedCost sums TrialResult.WastedCost across every trial,
+	// quantifying infrastructure flakiness' cost separately from TotalCost
+	// (which does not include it, since TotalCost sums only each trial's own
+	// Cost, taken from its final attempt).
+	TotalWastedCost  float64                 `json:"total_wasted_cost,omitempty"`
 	TotalDurationSec float64                 `json:"total_duration_sec"`
 	StartedAt        time.Time               `json:"started_at"`
 	EndedAt          time.Time               `json:"ended_at"`
 	Agents           map[string]AgentSummary `json:"agents"`
 	Results          []TrialSummary          `json:"results"`
+	// Flakiness lists, per agent and task, attempts that disagreed on pass
+	// vs. fail. Only tasks run with more than one attempt are considered.
+	Flakiness []FlakinessEntry `json:"flakiness,omitempty"`
+	// ImageReports lists size and vulnerability counts for each distinct
+	// image used across the job's trials, deduplicated by image ref.
+	// Empty unless JobEnvironmentConfig.Scan.Enabled was set.
+	ImageReports []ImageReport `json:"image_reports,omitempty"`
+	// Labels is copied verbatim from JobConfig.Labels.
+	Labels map[string]string `json:"labels,omitempty"`
+	// QuarantinedTasks lists tasks excluded from every agent's trials
+	// because SanityCheckConfig.Enabled caught the oracle agent itself
+	// failing them. Empty unless sanity_check.enabled was set.
+	QuarantinedTasks []QuarantinedTask `json:"quarantined_tasks,omitempty"`
+	// Validation is this run's per-task dataset health report. Only set
+	// when JobConfig.Mode is ModeValidate; every other field above that
+	// assumes per-agent trials (Agents, Results, Flakiness) is left zero.
+	Validation []TaskValidation `json:"validation,omitempty"`
+}
+
+// TaskValidation is one task's result from a ModeValidate run: its
+// environment build, oracle solution, and verifier, with no agent
+// involved.
+type TaskValidation struct {
+	DatasetName string   `json:"dataset_name"`
+	TaskName    string   `json:"task_name"`
+	Passed      bool     `json:"passed"`
+	Reward      *float64 `json:"reward"`
+	Cost        float64  `json:"cost"`
+	DurationSec float64  `json:"duration_sec"`
+	// Error is set instead of Passed when the environment, oracle install,
+	// or verifier itself failed, as opposed to running but scoring below
+	// the task's pass threshold.
+	Error string `json:"error,omitempty"`
+}
+
+// QuarantinedTask records a task the oracle sanity pass failed, and why.
+type QuarantinedTask struct {
+	DatasetName string `json:"dataset_name"`
+	TaskName    string `json:"task_name"`
+	Reason      string `json:"reason"`
 }
 
 type AgentSummary struct {
@@ -102,10 +490,25 @@ type AgentSummary struct {
 	TotalCost       float64 `json:"total_cost"`
 }
 
+// FlakinessEntry flags a task whose attempts under a single agent
+// disagreed on pass vs. fail, suggesting agent nondeterminism (or a flaky
+// environment) rather than a task the agent consistently can or can't solve.
+type FlakinessEntry struct {
+	AgentName   string `json:"agent_name"`
+	DatasetName string `json:"dataset_name"`
+	TaskName    string `json:"task_name"`
+	Attempts    int    `json:"attempts"`
+	Passes      int    `json:"passes"`
+}
+
 type TrialSummary struct {
-	TaskName    string   `json:"task_name"`
-	DatasetName string   `json:"dataset_name"`
-	AgentName   string   `json:"agent_name"`
-	Attempt     int      `json:"attempt"`
-	Reward      *float64 `json:"reward"`
+	TaskName    string `json:"task_name"`
+	DatasetName string `json:"dataset_name"`
+	AgentName   string `json:"agent_name"`
+	// Model records Agent.Model, i.e. which entry of a Models-expanded
+	// agent's list this trial ran against. Empty for an agent that doesn't
+	// set Models.
+	Model   string   `json:"model,omitempty"`
+	Attempt int      `json:"attempt"`
+	Reward  *float64 `json:"reward"`
 }
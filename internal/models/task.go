@@ -16,6 +16,23 @@ type TaskConfig struct {
 
 type VerifierConfig struct {
 	TimeoutSec float64 `toml:"timeout_sec"` // default: 600.0
+	// PassThreshold is the minimum reward counted as a pass for PassRate.
+	// Defaults to 1.0, matching the old reward == 1.0 behavior; set lower
+	// for continuous-reward benchmarks (e.g. 0.8).
+	PassThreshold float64 `toml:"pass_threshold"` // default: 1.0
+	// User runs /tests/test.sh as this user/UID instead of the image's
+	// default (e.g. a verifier that needs root to read files the agent's
+	// unprivileged user wrote). Empty uses the image's own default. Only
+	// honored by providers whose Exec supports ExecOptions.User (currently
+	// docker, apple, and modal).
+	User string `toml:"user,omitempty"`
+	// Env sets environment variables for the verifier phase (bash
+	// /tests/test.sh). Independent of the agent's Env/InstallEnv/ExecuteEnv:
+	// the verifier never automatically inherits agent secrets, closing a
+	// leakage channel where a verifier script could read an agent's API key
+	// out of its environment. Empty runs the verifier with no extra vars
+	// beyond the image's own.
+	Env map[string]string `toml:"env,omitempty"`
 }
 
 type AgentTaskConfig struct {
@@ -26,20 +43,202 @@ type AgentTaskConfig struct {
 type EnvironmentConfig struct {
 	BuildTimeoutSec float64 `toml:"build_timeout_sec"` // default: 600.0
 	DockerImage     *string `toml:"docker_image,omitempty"`
-	CPUs            int     `toml:"cpus"`    // default: 1
-	Memory          string  `toml:"memory,omitempty"`  // Deprecated: use MemoryMB
-	Storage         string  `toml:"storage,omitempty"` // Deprecated: use StorageMB
-	MemoryMB        int     `toml:"memory_mb,omitempty"`
-	StorageMB       int     `toml:"storage_mb,omitempty"`
+	// DockerImageDigest pins DockerImage to an exact content digest
+	// (e.g. "sha256:abcd..."). If set, the pulled image's digest is verified
+	// to match after PullImage; a mismatch fails the trial instead of
+	// silently running against drifted bits.
+	DockerImageDigest *string `toml:"docker_image_digest,omitempty"`
+	// BuildArgs are forwarded to BuildImage as --build-arg NAME=VALUE,
+	// letting a task parameterize its Dockerfile (e.g. a base image
+	// version) without maintaining near-duplicate Dockerfiles.
+	BuildArgs map[string]string `toml:"build_args,omitempty"`
+	// Target selects a build stage in a multi-stage Dockerfile, forwarded
+	// to BuildImage as --target. Empty builds the final stage as usual.
+	Target    string `toml:"target,omitempty"`
+	CPUs      int    `toml:"cpus"`              // default: 1
+	Memory    string `toml:"memory,omitempty"`  // Deprecated: use MemoryMB
+	Storage   string `toml:"storage,omitempty"` // Deprecated: use StorageMB
+	MemoryMB  int    `toml:"memory_mb,omitempty"`
+	StorageMB int    `toml:"storage_mb,omitempty"`
+	// GPUCount requests this many GPUs for the task's environment. Zero
+	// requests none. Providers without GPU support ignore it.
+	GPUCount int `toml:"gpu_count,omitempty"`
+	// GPUType selects a GPU model (e.g. "A100", "T4"), for providers that
+	// can choose among multiple types. Empty lets the provider pick its
+	// default when GPUCount > 0.
+	GPUType string `toml:"gpu_type,omitempty"`
+	// ProviderHints carries provider-specific overrides for this task
+	// alone, forwarded to CreateEnvironment as opts.Config. Keys mirror
+	// the environment's provider_config (e.g. "region", "cloud",
+	// "cpu_limit"), letting a task pin placement or resource limits (e.g.
+	// a GPU-only region) without changing the job's defaults for every
+	// other task. Providers that don't recognize a key ignore it.
+	ProviderHints map[string]any `toml:"provider_hints,omitempty"`
+	// Network is one of "full" (default), "none", or "restricted", letting
+	// a benchmark task guarantee its agent can't fetch a solution (or
+	// exfiltrate one) over the internet. "restricted" allows only a
+	// provider-defined egress allowlist; providers without an allowlist
+	// mechanism ignore it and log a warning. Empty means "full".
+	Network string `toml:"network,omitempty"`
+	// FakeTime pins the environment's clock via libfaketime, in
+	// libfaketime's own format (e.g. "2024-01-01 00:00:00" or "+10d"), for
+	// tasks that test date-dependent behavior deterministically. The task's
+	// image must have libfaketime installed; this only sets the
+	// FAKETIME/LD_PRELOAD environment variables that activate it, assuming
+	// libfaketime's standard Debian/Ubuntu glibc-amd64 install path - images
+	// installing it elsewhere should set LD_PRELOAD themselves instead.
+	FakeTime string `toml:"fake_time,omitempty"`
+	// NetworkShaping applies artificial latency/bandwidth constraints to the
+	// environment's network interface (tc-based on docker), for benchmarks
+	// about resilience and retry logic under degraded networks. Providers
+	// other than docker ignore it.
+	NetworkShaping NetworkShapingConfig `toml:"network_shaping,omitempty"`
+	// Services declares sidecar containers (a database, cache, or other
+	// dependency) that providers start alongside the main container on a
+	// shared network before the agent phase begins, reachable from the
+	// agent by Name. Not every provider supports this; providers without a
+	// shared-network mechanism ignore it and log a warning.
+	Services []ServiceConfig `toml:"services,omitempty"`
+	// MaxLogMB caps the size of /logs, the directory agents and the
+	// verifier write their output under before it's collected onto the
+	// host, so a runaway agent writing an enormous log file fills that
+	// quota instead of the sandbox's root disk and breaking the verifier.
+	// Zero disables the cap. Not every provider supports this; providers
+	// without a size-limited mount mechanism ignore it and log a warning.
+	MaxLogMB int `toml:"max_log_mb,omitempty"`
+	// Constraints restricts the region/architecture/price a provider may
+	// place this task's environment under. Unlike most of this struct's
+	// fields, a provider that can't satisfy a constraint set here fails
+	// the trial instead of silently ignoring it.
+	Constraints ConstraintsConfig `toml:"constraints,omitempty"`
+	// Mounts declares read-only host paths (large datasets, model weights)
+	// to bind-mount into the environment, instead of baking them into the
+	// image or copying them into every trial. Not every provider supports
+	// this; providers without a host bind-mount mechanism ignore it and
+	// log a warning.
+	Mounts []MountConfig `toml:"mounts,omitempty"`
+	// Security hardens the environment beyond its provider's defaults, for
+	// tasks that run untrusted agent code. Not every provider supports
+	// this; providers without a security-options mechanism ignore it and
+	// log a warning.
+	Security SecurityConfig `toml:"security,omitempty"`
+	// PidsLimit caps the number of processes/threads the environment's
+	// container can have live at once, so a fork bomb in agent code fails
+	// with "resource temporarily unavailable" instead of exhausting host
+	// PIDs and taking out other trials sharing the host. Zero uses the
+	// provider's default (unlimited, for docker). Not every provider
+	// supports this; providers without a pids-limit mechanism ignore it and
+	// log a warning.
+	PidsLimit int `toml:"pids_limit,omitempty"`
+	// Ulimits sets POSIX resource limits (soft/hard) inside the
+	// environment's container, e.g. nofile (open file descriptors) or nproc.
+	// Not every provider supports this; providers without a ulimit
+	// mechanism ignore it and log a warning.
+	Ulimits []UlimitConfig `toml:"ulimits,omitempty"`
+}
+
+// UlimitConfig is one [[environment.ulimits]] entry: a single POSIX
+// resource limit, named the same as docker's --ulimit (e.g. "nofile",
+// "nproc", "memlock").
+type UlimitConfig struct {
+	Name string `toml:"name"`
+	Soft int64  `toml:"soft"`
+	// Hard defaults to Soft when zero, matching docker's own --ulimit
+	// name=soft (no hard value) shorthand.
+	Hard int64 `toml:"hard,omitempty"`
+}
+
+// SecurityConfig is EnvironmentConfig.Security's shape. See its doc
+// comment.
+type SecurityConfig struct {
+	// SeccompProfile is a path to a seccomp JSON profile file, or one of
+	// the special values "unconfined" (disable syscall filtering) or
+	// "default" (the provider's built-in profile). Relative paths are
+	// resolved against the task directory. Empty uses the provider's
+	// default.
+	SeccompProfile string `toml:"seccomp_profile,omitempty"`
+	// AppArmorProfile selects an AppArmor profile by name (e.g.
+	// "docker-default", "unconfined"). Empty uses the provider's default.
+	AppArmorProfile string `toml:"apparmor_profile,omitempty"`
+	// CapDrop lists Linux capabilities to drop from the container (e.g.
+	// "NET_RAW", "SYS_ADMIN"). "ALL" drops every capability.
+	CapDrop []string `toml:"cap_drop,omitempty"`
+	// CapAdd lists Linux capabilities to add back on top of CapDrop.
+	CapAdd []string `toml:"cap_add,omitempty"`
+	// NoNewPrivileges disables privilege escalation inside the container
+	// (setuid/setgid binaries gaining privileges they didn't start with).
+	NoNewPrivileges bool `toml:"no_new_privileges,omitempty"`
+}
+
+// MountConfig is one [[environment.mounts]] entry: a host path bind-mounted
+// into the environment.
+type MountConfig struct {
+	// HostPath is the path on the machine running the provider (not inside
+	// the environment) to mount. Relative paths are resolved against the
+	// task directory.
+	HostPath string `toml:"host_path"`
+	// Path is where HostPath is mounted inside the environment.
+	Path string `toml:"path"`
+	// ReadOnly mounts HostPath read-only, so an agent can't corrupt a
+	// dataset or model weights shared (read-only, concurrently) across
+	// every trial mounting it. False (writable) by default, like the
+	// image's own filesystem.
+	ReadOnly bool `toml:"read_only,omitempty"`
+}
+
+// ConstraintsConfig is EnvironmentConfig.Constraints's shape. See its doc
+// comment.
+type ConstraintsConfig struct {
+	// Region restricts placement to a specific provider region (e.g.
+	// "us-east"). Empty lets the provider choose.
+	Region string `toml:"region,omitempty"`
+	// Arch restricts placement to a CPU architecture (e.g. "amd64",
+	// "arm64"), using Go's GOARCH naming. Empty lets the provider choose.
+	Arch string `toml:"arch,omitempty"`
+	// MaxPrice caps the estimated USD/hour cost of the environment.
+	// Zero disables the cap.
+	MaxPrice float64 `toml:"max_price,omitempty"`
+}
+
+// ServiceConfig is one [[environment.services]] entry: a sidecar container
+// started alongside the task's main container.
+type ServiceConfig struct {
+	// Name identifies the service and is also the hostname the main
+	// container can reach it at on the shared network.
+	Name  string            `toml:"name"`
+	Image string            `toml:"image"`
+	Env   map[string]string `toml:"env,omitempty"`
+	// Ports are published to the host, same as the main container's
+	// published ports, for a verifier or developer that needs to reach the
+	// service directly rather than only from inside the main container.
+	Ports []int `toml:"ports,omitempty"`
+	// Healthcheck is a shell command run inside the service container
+	// (via `docker exec`-equivalent); the provider waits for it to exit 0
+	// before starting the agent phase, gating readiness instead of racing
+	// a service that's still initializing. Empty skips readiness gating
+	// and only waits for the container to start.
+	Healthcheck string `toml:"healthcheck,omitempty"`
+	// HealthcheckTimeoutSec bounds how long the provider waits for
+	// Healthcheck to succeed. Defaults to 60 when Healthcheck is set and
+	// this is zero.
+	HealthcheckTimeoutSec float64 `toml:"healthcheck_timeout_sec,omitempty"`
+}
+
+// NetworkShapingConfig is EnvironmentConfig.NetworkShaping's shape. See its
+// doc comment.
+type NetworkShapingConfig struct {
+	LatencyMs     int `toml:"latency_ms,omitempty"`
+	BandwidthKbps int `toml:"bandwidth_kbps,omitempty"`
 }
 
 // Task represents a fully loaded task ready for execution.
 type Task struct {
 	Name        string
-	Path        string      // filesystem path to task directory
-	FS          fs.FS       // filesystem rooted at task directory
+	Path        string // filesystem path to task directory
+	FS          fs.FS  // filesystem rooted at task directory
 	Config      TaskConfig
-	GitCommitID *string     // resolved git SHA, nil if not in git repo
+	GitCommitID *string // resolved git SHA, nil if not in git repo
+	GitURL      string  // resolved git remote, empty if not loaded from a registry
 }
 
 // Instruction opens the instruction.md file.
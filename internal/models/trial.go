@@ -2,6 +2,26 @@ package models
 
 import "time"
 
+// Status values for TrialResult.Status.
+const (
+	// StatusCompleted is a trial that ran to completion on its first
+	// attempt.
+	StatusCompleted = "completed"
+	// StatusRetried is a trial that failed with a retryable error (e.g.
+	// models.ErrEnvironmentInterrupted) on an earlier attempt but
+	// eventually completed within its retry budget.
+	StatusRetried = "retried"
+	// StatusFailed is a trial that ended with a TrialError, whether or not
+	// it exhausted its retry budget first.
+	StatusFailed = "failed"
+	// StatusSkipped is a trial a budget cap (models.BudgetsConfig) kept
+	// from ever starting.
+	StatusSkipped = "skipped"
+	// StatusCancelled is a trial the job's context cancellation kept from
+	// ever starting.
+	StatusCancelled = "cancelled"
+)
+
 // Trial represents a single agent attempt at a task.
 type Trial struct {
 	ID        string // unique identifier
@@ -22,18 +42,125 @@ type TrialSpec struct {
 
 // TrialResult contains the outcome of a trial execution.
 type TrialResult struct {
-	TaskName        string      `json:"task_name"`
-	DatasetName     string      `json:"dataset_name"`
-	AgentName       string      `json:"agent_name"`
-	Attempt         int         `json:"attempt"`
-	TaskGitCommitID *string     `json:"task_git_commit_id"`
-	Reward          *float64    `json:"reward"`
-	Cost            float64     `json:"cost"`
-	Error           *TrialError `json:"error"`
-	Durations       Durations   `json:"durations"`
-	Timestamps      Timestamps  `json:"timestamps"`
-	VerifierStdout  string      `json:"verifier_stdout,omitempty"`
-	VerifierStderr  string      `json:"verifier_stderr,omitempty"`
+	TaskName    string `json:"task_name"`
+	DatasetName string `json:"dataset_name"`
+	AgentName   string `json:"agent_name"`
+	// Model records Agent.Model, i.e. which entry of a Models-expanded
+	// agent's list this trial ran against. Empty for an agent that doesn't
+	// set Models.
+	Model           string  `json:"model,omitempty"`
+	Attempt         int     `json:"attempt"`
+	TaskGitCommitID *string `json:"task_git_commit_id"`
+	// ImageRef records the exact image the trial ran against (a pre-built
+	// docker_image, or the tag a Dockerfile was built to), so a trial can
+	// later be replayed against the same bits with `rollout replay`.
+	ImageRef string `json:"image_ref,omitempty"`
+	// EnvironmentProvider records which provider name actually created this
+	// trial's environment. Always set to the single configured
+	// environment.type, except when that's a failover list, in which case
+	// it names whichever entry succeeded (possibly not the first, if an
+	// earlier one failed with a capacity or auth error).
+	EnvironmentProvider string `json:"environment_provider,omitempty"`
+	// Status is one of the Status* constants below. Cancelled and skipped
+	// results are written for trials never started by a cancelled job or a
+	// budget cap, so resume/report tooling can tell "never attempted" apart
+	// from "lost".
+	Status string   `json:"status"`
+	Reward *float64 `json:"reward"`
+	// PassThreshold is the reward threshold Reward was compared against to
+	// decide pass/fail for PassRate, resolved from the task's and job's
+	// pass_threshold configuration. A pointer so a task that legitimately
+	// configures pass_threshold = 0.0 is distinguishable from a result that
+	// never reached one (nil, same as Reward).
+	PassThreshold *float64 `json:"pass_threshold,omitempty"`
+	Cost          float64  `json:"cost"`
+	// AttemptCount is how many times this trial was attempted, including
+	// ones discarded for models.ErrEnvironmentInterrupted retries. 1 unless
+	// the trial was retried (Status is StatusRetried, or StatusFailed after
+	// retries).
+	AttemptCount int `json:"attempt_count,omitempty"`
+	// WastedCost sums Cost across this trial's discarded retry attempts,
+	// quantifying infrastructure flakiness' cost separately from Cost
+	// (which reflects only the attempt the result was actually taken from).
+	// Zero unless the trial was retried.
+	WastedCost float64 `json:"wasted_cost,omitempty"`
+	// RetryReasons lists the TrialError.Type of each discarded attempt, in
+	// order. Empty unless the trial was retried.
+	RetryReasons   []ErrorType `json:"retry_reasons,omitempty"`
+	Error          *TrialError `json:"error"`
+	Durations      Durations   `json:"durations"`
+	Timestamps     Timestamps  `json:"timestamps"`
+	VerifierStdout string      `json:"verifier_stdout,omitempty"`
+	VerifierStderr string      `json:"verifier_stderr,omitempty"`
+	// Phases records the per-phase outcome of the trial in execution order,
+	// so consumers can see exactly which phase failed instead of inferring
+	// it from Error.Type and which Durations field is nil.
+	Phases []PhaseResult `json:"phases,omitempty"`
+	// ResourceUsage summarizes container CPU/memory samples taken during the
+	// agent install/execute phases. Nil if resource monitoring was disabled
+	// or the provider doesn't support sampling.
+	ResourceUsage *ResourceUsageSummary `json:"resource_usage,omitempty"`
+	// ImageReport records this trial's image size and vulnerability counts.
+	// Nil unless JobEnvironmentConfig.Scan.Enabled was set; a failed scan
+	// still sets it, with ImageReport.Error recording why.
+	ImageReport *ImageReport `json:"image_report,omitempty"`
+	// LogCollectionErrors records each /logs entry that couldn't be copied
+	// out of the environment, after retries, during the teardown log
+	// collection phase. Empty means every entry was collected; a non-empty
+	// list doesn't fail the trial itself, since the agent/verifier already
+	// ran to completion - it just means some diagnostic artifacts are
+	// missing from the output directory.
+	LogCollectionErrors []string `json:"log_collection_errors,omitempty"`
+	// Labels is copied verbatim from JobConfig.Labels, so a trial result can
+	// be filtered or grouped (e.g. by experiment or git_sha) without joining
+	// back against the job.yaml that produced it.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Seed is the per-trial seed exposed to the agent's execute step as
+	// ROLLOUT_SEED, derived from JobConfig.Seed and this trial's ID so every
+	// trial gets a distinct value that's still reproducible: pinning
+	// JobConfig.Seed reproduces the exact same Seed here on a re-run.
+	Seed int64 `json:"seed,omitempty"`
+}
+
+// ImageReport summarizes a container image's size and known vulnerabilities,
+// useful for benchmark maintainers curating task images.
+type ImageReport struct {
+	ImageRef     string `json:"image_ref"`
+	TaskName     string `json:"task_name"`
+	SizeBytes    int64  `json:"size_bytes,omitempty"`
+	CriticalCVEs int    `json:"critical_cves,omitempty"`
+	HighCVEs     int    `json:"high_cves,omitempty"`
+	MediumCVEs   int    `json:"medium_cves,omitempty"`
+	LowCVEs      int    `json:"low_cves,omitempty"`
+	// Error is set instead of the counts above if scanning failed (e.g. the
+	// scanner binary isn't installed); non-fatal to the trial itself.
+	Error string `json:"error,omitempty"`
+}
+
+// ResourceUsageSummary aggregates periodic resource usage samples taken
+// during a trial's agent phases, helping task authors right-size MemoryMB
+// and catch OOM-adjacent failures.
+type ResourceUsageSummary struct {
+	SampleCount    int     `json:"sample_count"`
+	PeakCPUPercent float64 `json:"peak_cpu_percent"`
+	MeanCPUPercent float64 `json:"mean_cpu_percent"`
+	PeakMemoryMB   float64 `json:"peak_memory_mb"`
+	MeanMemoryMB   float64 `json:"mean_memory_mb"`
+}
+
+// PhaseResult captures the outcome of a single trial phase (environment
+// setup, agent install, agent execution, verification, teardown).
+type PhaseResult struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"` // "completed", "failed", or "skipped"
+	ExitCode   *int   `json:"exit_code,omitempty"`
+	StdoutPath string `json:"stdout_path,omitempty"`
+	StderrPath string `json:"stderr_path,omitempty"`
+	// Notes carries provider-supplied context about this phase worth
+	// surfacing to whoever reads the result (e.g. a container runtime's
+	// resource overhead), beyond exit code and logs. Empty when the
+	// provider has nothing to add.
+	Notes string `json:"notes,omitempty"`
 }
 
 type TrialError struct {
@@ -0,0 +1,96 @@
+// Package provenance captures the information needed to reproduce a job's
+// results months later: the fully-resolved configuration it ran with, the
+// seed used for any randomized behavior, the host it ran on, and the
+// versions of the external tools (docker, kubectl, ...) its
+// environment provider shelled out to.
+package provenance
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spachava753/rollout/internal/models"
+)
+
+// Provenance is written as jobDir/provenance.json alongside config.json and
+// result.json.
+type Provenance struct {
+	CapturedAt time.Time `json:"captured_at"`
+	// EffectiveConfig is the job config after defaults and overrides have
+	// been resolved, i.e. exactly what the executor ran with.
+	EffectiveConfig models.JobConfig `json:"effective_config"`
+	// Seed is the value used to seed any randomized behavior for this job;
+	// each trial's ROLLOUT_SEED environment variable is derived from it and
+	// the trial's ID.
+	Seed int64 `json:"seed"`
+	Host Host  `json:"host"`
+	// ProviderVersion is the output of the environment provider's CLI
+	// version command (e.g. `docker version`, `kubectl version`), or empty
+	// if the provider has no such tool or the probe failed.
+	ProviderVersion string `json:"provider_version,omitempty"`
+}
+
+// Host records the machine the job ran on.
+type Host struct {
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	GoVersion string `json:"go_version"`
+}
+
+// providerVersionCommands maps an environment type to the CLI invocation
+// that reports its version, mirroring how each provider package itself
+// shells out (docker, kubectl, aws, gcloud).
+var providerVersionCommands = map[string][]string{
+	"docker":       {"docker", "version", "--format", "{{.Server.Version}}"},
+	"k8s":          {"kubectl", "version", "--client", "-o", "yaml"},
+	"fargate":      {"aws", "--version"},
+	"gcp_cloudrun": {"gcloud", "--version"},
+	"daytona":      {"daytona", "version"},
+	"ssh":          {"ssh", "-V"},
+	"containerd":   {"ctr", "version"},
+	"incus":        {"incus", "version"},
+	"qemu":         {"virsh", "--version"},
+	"cloudflare":   {"wrangler", "--version"},
+	"morph":        {"morphcloud", "--version"},
+	"vastai":       {"vastai", "--version"},
+	"hetzner":      {"hcloud", "version"},
+	"wsl":          {"wsl", "--version"},
+	"coder":        {"coder", "version"},
+	"apple":        {"container", "--version"},
+}
+
+// Capture builds a Provenance record for cfg, probing the configured
+// provider's CLI for its version. Probing failures (missing binary, no
+// daemon running, etc.) are non-fatal; ProviderVersion is left empty.
+func Capture(cfg models.JobConfig, seed int64) Provenance {
+	return Provenance{
+		CapturedAt:      time.Now(),
+		EffectiveConfig: cfg,
+		Seed:            seed,
+		Host: Host{
+			OS:        runtime.GOOS,
+			Arch:      runtime.GOARCH,
+			GoVersion: runtime.Version(),
+		},
+		ProviderVersion: probeProviderVersion(cfg.Environment.Type.Primary()),
+	}
+}
+
+func probeProviderVersion(envType string) string {
+	args, ok := providerVersionCommands[envType]
+	if !ok {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,29 @@
+package provenance
+
+import (
+	"testing"
+
+	"github.com/spachava753/rollout/internal/models"
+)
+
+func TestCaptureRecordsHostAndSeed(t *testing.T) {
+	cfg := models.JobConfig{JobsDir: "/tmp/jobs", Environment: models.JobEnvironmentConfig{Type: models.ProviderTypes{"some-unknown-provider"}}}
+
+	got := Capture(cfg, 42)
+
+	if got.Seed != 42 {
+		t.Errorf("Seed = %d, want 42", got.Seed)
+	}
+	if got.Host.OS == "" || got.Host.Arch == "" || got.Host.GoVersion == "" {
+		t.Errorf("Host fields should be populated, got %+v", got.Host)
+	}
+	if got.EffectiveConfig.JobsDir != cfg.JobsDir {
+		t.Errorf("EffectiveConfig.JobsDir = %q, want %q", got.EffectiveConfig.JobsDir, cfg.JobsDir)
+	}
+}
+
+func TestProbeProviderVersionUnknownType(t *testing.T) {
+	if v := probeProviderVersion("some-unknown-provider"); v != "" {
+		t.Errorf("probeProviderVersion(unknown) = %q, want empty", v)
+	}
+}
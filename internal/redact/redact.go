@@ -0,0 +1,68 @@
+// Package redact scrubs secrets out of captured agent output and saved job
+// configs before they land on disk, so an agent that echoes an API key (or
+// a job.yaml that inlines one in agent.env) doesn't leave it sitting in
+// plaintext under jobs_dir.
+package redact
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+
+	"github.com/spachava753/rollout/internal/models"
+)
+
+// placeholder replaces every redacted match.
+const placeholder = "[REDACTED]"
+
+// Redactor scrubs text against a fixed set of literal secrets and regex
+// patterns. A nil *Redactor is valid and a no-op, so callers can hold one
+// unconditionally without checking for redaction being disabled.
+type Redactor struct {
+	secrets  []string
+	patterns []*regexp.Regexp
+}
+
+// New compiles cfg into a Redactor, or returns nil if cfg.Enabled is false.
+func New(cfg models.RedactionConfig) (*Redactor, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	r := &Redactor{}
+	for _, secret := range cfg.Secrets {
+		if secret != "" {
+			r.secrets = append(r.secrets, secret)
+		}
+	}
+	for _, pattern := range cfg.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("compiling redaction pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Bytes returns data with every configured secret/pattern match replaced.
+func (r *Redactor) Bytes(data []byte) []byte {
+	if r == nil {
+		return data
+	}
+	for _, secret := range r.secrets {
+		data = bytes.ReplaceAll(data, []byte(secret), []byte(placeholder))
+	}
+	for _, re := range r.patterns {
+		data = re.ReplaceAll(data, []byte(placeholder))
+	}
+	return data
+}
+
+// String is Bytes for a string.
+func (r *Redactor) String(s string) string {
+	if r == nil {
+		return s
+	}
+	return string(r.Bytes([]byte(s)))
+}
@@ -0,0 +1,48 @@
+package redact
+
+import (
+	"testing"
+
+	"github.com/spachava753/rollout/internal/models"
+)
+
+func TestNewDisabledReturnsNilRedactor(t *testing.T) {
+	r, err := New(models.RedactionConfig{Secrets: []string{"sk-abc"}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if r != nil {
+		t.Fatalf("expected nil Redactor when Enabled is false")
+	}
+	if got := r.String("contains sk-abc here"); got != "contains sk-abc here" {
+		t.Errorf("nil Redactor should be a no-op, got %q", got)
+	}
+}
+
+func TestRedactsLiteralSecrets(t *testing.T) {
+	r, err := New(models.RedactionConfig{Enabled: true, Secrets: []string{"sk-abc123"}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	got := r.String("Authorization: Bearer sk-abc123")
+	if got != "Authorization: Bearer [REDACTED]" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestRedactsPatterns(t *testing.T) {
+	r, err := New(models.RedactionConfig{Enabled: true, Patterns: []string{`sk-[A-Za-z0-9]+`}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	got := r.String("key is sk-XyZ789 end")
+	if got != "key is [REDACTED] end" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestNewInvalidPatternErrors(t *testing.T) {
+	if _, err := New(models.RedactionConfig{Enabled: true, Patterns: []string{"("}}); err == nil {
+		t.Fatal("expected error for invalid regex")
+	}
+}
@@ -47,10 +47,22 @@ func (r *Resolver) BaseDir() string {
 // Resolve resolves all tasks in a registry dataset by cloning the necessary
 // repositories and loading each task. Repositories are deduplicated by
 // (git_url, git_commit_id) to avoid redundant clones.
-func (r *Resolver) Resolve(ctx context.Context, dataset *RegistryDataset) ([]models.Task, error) {
+//
+// pins, if non-nil, maps task name to a git commit that overrides the
+// registry's own git_commit_id for that task (used by --locked runs to pin
+// exactly the commits recorded in rollout.lock).
+func (r *Resolver) Resolve(ctx context.Context, dataset *RegistryDataset, pins map[string]string) ([]models.Task, error) {
+	regTasks := make([]RegistryTask, len(dataset.Tasks))
+	copy(regTasks, dataset.Tasks)
+	for i, t := range regTasks {
+		if pin, ok := pins[t.Name]; ok && pin != "" {
+			regTasks[i].GitCommitID = pin
+		}
+	}
+
 	// Group tasks by clone key for deduplication
 	groups := make(map[cloneKey][]RegistryTask)
-	for _, t := range dataset.Tasks {
+	for _, t := range regTasks {
 		key := cloneKey{GitURL: t.GitURL, GitCommitID: t.GitCommitID}
 		groups[key] = append(groups[key], t)
 	}
@@ -58,21 +70,23 @@ func (r *Resolver) Resolve(ctx context.Context, dataset *RegistryDataset) ([]mod
 	slog.Debug("resolving registry dataset",
 		"dataset", dataset.Name,
 		"unique_repos", len(groups),
-		"total_tasks", len(dataset.Tasks))
+		"total_tasks", len(regTasks))
 
 	// Clone each unique repository (parallel)
 	clones := make(map[cloneKey]string)
+	resolvedCommits := make(map[cloneKey]string)
 	var clonesMu sync.Mutex
 
 	g, ctx := errgroup.WithContext(ctx)
 	for key := range groups {
 		g.Go(func() error {
-			clonePath, err := r.cloneRepo(ctx, key)
+			clonePath, resolvedCommit, err := r.cloneRepo(ctx, key)
 			if err != nil {
 				return fmt.Errorf("cloning %s: %w", key.GitURL, err)
 			}
 			clonesMu.Lock()
 			clones[key] = clonePath
+			resolvedCommits[key] = resolvedCommit
 			clonesMu.Unlock()
 			return nil
 		})
@@ -84,7 +98,7 @@ func (r *Resolver) Resolve(ctx context.Context, dataset *RegistryDataset) ([]mod
 
 	// Load tasks from cloned repositories
 	var tasks []models.Task
-	for _, regTask := range dataset.Tasks {
+	for _, regTask := range regTasks {
 		key := cloneKey{GitURL: regTask.GitURL, GitCommitID: regTask.GitCommitID}
 		clonePath := clones[key]
 
@@ -104,10 +118,13 @@ func (r *Resolver) Resolve(ctx context.Context, dataset *RegistryDataset) ([]mod
 			return nil, fmt.Errorf("validating task %s: %w", regTask.Name, err)
 		}
 
-		// Override task name with registry name and set git commit ID
+		// Override task name with registry name and record the exact
+		// resolved git commit, even when regTask.GitCommitID was empty
+		// (meaning "HEAD" at resolve time), so callers can pin it later.
 		t.Name = regTask.Name
-		if regTask.GitCommitID != "" {
-			t.GitCommitID = &regTask.GitCommitID
+		t.GitURL = regTask.GitURL
+		if commit := resolvedCommits[key]; commit != "" {
+			t.GitCommitID = &commit
 		}
 
 		tasks = append(tasks, *t)
@@ -117,9 +134,11 @@ func (r *Resolver) Resolve(ctx context.Context, dataset *RegistryDataset) ([]mod
 	return tasks, nil
 }
 
-// cloneRepo clones a repository to baseDir. For specific commits, it does a full
-// clone then checks out the commit. For HEAD, it does a shallow clone.
-func (r *Resolver) cloneRepo(ctx context.Context, key cloneKey) (string, error) {
+// cloneRepo clones a repository to baseDir and returns its local path along
+// with the exact commit checked out. For specific commits, it does a full
+// clone then checks out the commit. For HEAD, it does a shallow clone and
+// resolves the commit that landed on disk with `git rev-parse HEAD`.
+func (r *Resolver) cloneRepo(ctx context.Context, key cloneKey) (string, string, error) {
 	// Create a unique directory name based on URL and commit
 	dirName := r.cloneDirName(key)
 	clonePath := filepath.Join(r.baseDir, dirName)
@@ -127,7 +146,11 @@ func (r *Resolver) cloneRepo(ctx context.Context, key cloneKey) (string, error)
 	// Check if already cloned (idempotent)
 	if _, err := os.Stat(clonePath); err == nil {
 		slog.Debug("repository already cloned", "url", key.GitURL, "path", clonePath)
-		return clonePath, nil
+		commit, err := r.resolveHead(ctx, clonePath)
+		if err != nil {
+			return "", "", err
+		}
+		return clonePath, commit, nil
 	}
 
 	if key.GitCommitID == "" {
@@ -137,7 +160,7 @@ func (r *Resolver) cloneRepo(ctx context.Context, key cloneKey) (string, error)
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 		if err := cmd.Run(); err != nil {
-			return "", fmt.Errorf("git clone: %w", err)
+			return "", "", fmt.Errorf("git clone: %w", err)
 		}
 	} else {
 		// Full clone then checkout specific commit
@@ -146,7 +169,7 @@ func (r *Resolver) cloneRepo(ctx context.Context, key cloneKey) (string, error)
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 		if err := cmd.Run(); err != nil {
-			return "", fmt.Errorf("git clone: %w", err)
+			return "", "", fmt.Errorf("git clone: %w", err)
 		}
 
 		slog.Debug("checking out commit", "commit", key.GitCommitID)
@@ -155,12 +178,28 @@ func (r *Resolver) cloneRepo(ctx context.Context, key cloneKey) (string, error)
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 		if err := cmd.Run(); err != nil {
-			return "", fmt.Errorf("git checkout %s: %w", key.GitCommitID, err)
+			return "", "", fmt.Errorf("git checkout %s: %w", key.GitCommitID, err)
 		}
 	}
 
-	slog.Debug("repository cloned successfully", "url", key.GitURL, "path", clonePath)
-	return clonePath, nil
+	commit, err := r.resolveHead(ctx, clonePath)
+	if err != nil {
+		return "", "", err
+	}
+
+	slog.Debug("repository cloned successfully", "url", key.GitURL, "path", clonePath, "commit", commit)
+	return clonePath, commit, nil
+}
+
+// resolveHead returns the full commit SHA checked out at clonePath.
+func (r *Resolver) resolveHead(ctx context.Context, clonePath string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "HEAD")
+	cmd.Dir = clonePath
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse HEAD: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
 }
 
 // cloneDirName generates a unique directory name for a clone key.
@@ -36,7 +36,7 @@ func TestResolveIntegration(t *testing.T) {
 
 	t.Logf("Clone directory: %s", resolver.BaseDir())
 
-	tasks, err := resolver.Resolve(ctx, dataset)
+	tasks, err := resolver.Resolve(ctx, dataset, nil)
 	if err != nil {
 		t.Fatalf("Resolve: %v", err)
 	}
@@ -86,7 +86,7 @@ func TestResolveWithDeduplication(t *testing.T) {
 				Path:   "examples/tasks/hello-world",
 			},
 			{
-				Name:   "task-2", 
+				Name:   "task-2",
 				GitURL: "https://github.com/laude-institute/harbor.git",
 				Path:   "examples/tasks/hello-world", // Same path, different name
 			},
@@ -100,7 +100,7 @@ func TestResolveWithDeduplication(t *testing.T) {
 
 	t.Logf("Clone directory: %s", resolver.BaseDir())
 
-	tasks, err := resolver.Resolve(ctx, dataset)
+	tasks, err := resolver.Resolve(ctx, dataset, nil)
 	if err != nil {
 		t.Fatalf("Resolve: %v", err)
 	}
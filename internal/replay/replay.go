@@ -0,0 +1,113 @@
+// Package replay implements forensic re-execution of a previously recorded
+// trial: it rebuilds the environment from the image the trial actually ran
+// against, re-applies any captured workspace patch, and re-runs the
+// verifier, so a surprising reward can be reproduced without re-running the
+// whole job.
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spachava753/rollout/internal/environment"
+	"github.com/spachava753/rollout/internal/models"
+)
+
+// WorkspacePatchFile is the name of the captured workspace diff within a
+// trial's output directory, if one was recorded.
+const WorkspacePatchFile = "workspace.patch"
+
+// Result is the outcome of replaying a trial's verifier.
+type Result struct {
+	ExitCode     int
+	Reward       *float64
+	VerifierOut  string
+	VerifierErr  string
+	PatchApplied bool
+}
+
+// Replay rebuilds the environment the trial at trialDir ran against and
+// re-runs its verifier, returning the reproduced result.
+func Replay(ctx context.Context, trialDir string, provider environment.Provider) (*Result, error) {
+	original, err := loadResult(trialDir)
+	if err != nil {
+		return nil, err
+	}
+	if original.ImageRef == "" {
+		return nil, fmt.Errorf("trial has no recorded image_ref; it predates replay support or used a provider that doesn't report one")
+	}
+
+	if err := provider.PullImage(ctx, original.ImageRef); err != nil {
+		return nil, fmt.Errorf("pulling recorded image %s: %w", original.ImageRef, err)
+	}
+
+	env, err := provider.CreateEnvironment(ctx, environment.CreateEnvironmentOptions{
+		Name:     "replay-" + sanitizeName(original.TaskName),
+		ImageRef: original.ImageRef,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating replay environment: %w", err)
+	}
+	defer env.Destroy(context.Background())
+
+	result := &Result{}
+
+	patchPath := filepath.Join(trialDir, WorkspacePatchFile)
+	if _, err := os.Stat(patchPath); err == nil {
+		const remotePatch = "/tmp/replay.patch"
+		if err := env.CopyTo(ctx, patchPath, remotePatch); err != nil {
+			return nil, fmt.Errorf("copying workspace patch: %w", err)
+		}
+		var applyOut bytes.Buffer
+		exitCode, err := env.Exec(ctx, "git apply "+remotePatch, &applyOut, &applyOut, environment.ExecOptions{})
+		if err != nil || exitCode != 0 {
+			return nil, fmt.Errorf("applying workspace patch: exit %d: %w: %s", exitCode, err, applyOut.String())
+		}
+		result.PatchApplied = true
+	}
+
+	var stdout, stderr bytes.Buffer
+	exitCode, err := env.Exec(ctx, "bash /tests/test.sh", &stdout, &stderr, environment.ExecOptions{})
+	result.ExitCode = exitCode
+	result.VerifierOut = stdout.String()
+	result.VerifierErr = stderr.String()
+	if err != nil {
+		return result, fmt.Errorf("running verifier: %w", err)
+	}
+
+	var rewardBuf bytes.Buffer
+	if _, err := env.Exec(ctx, "cat /logs/verifier/reward.txt", &rewardBuf, nil, environment.ExecOptions{}); err == nil {
+		if reward, err := strconv.ParseFloat(strings.TrimSpace(rewardBuf.String()), 64); err == nil {
+			result.Reward = &reward
+		}
+	}
+
+	return result, nil
+}
+
+func loadResult(trialDir string) (*models.TrialResult, error) {
+	data, err := os.ReadFile(filepath.Join(trialDir, "result.json"))
+	if err != nil {
+		return nil, fmt.Errorf("reading result.json: %w", err)
+	}
+	var result models.TrialResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("parsing result.json: %w", err)
+	}
+	return &result, nil
+}
+
+func sanitizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' {
+			return r
+		}
+		return '-'
+	}, name)
+}
@@ -0,0 +1,47 @@
+package replay
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadResult(t *testing.T) {
+	dir := t.TempDir()
+	data := `{"task_name":"hello-world","image_ref":"rollout-cache-abc123"}`
+	if err := os.WriteFile(filepath.Join(dir, "result.json"), []byte(data), 0644); err != nil {
+		t.Fatalf("writing result.json: %v", err)
+	}
+
+	result, err := loadResult(dir)
+	if err != nil {
+		t.Fatalf("loadResult failed: %v", err)
+	}
+	if result.TaskName != "hello-world" {
+		t.Errorf("expected task name hello-world, got %q", result.TaskName)
+	}
+	if result.ImageRef != "rollout-cache-abc123" {
+		t.Errorf("expected image ref rollout-cache-abc123, got %q", result.ImageRef)
+	}
+}
+
+func TestLoadResultMissingFile(t *testing.T) {
+	if _, err := loadResult(t.TempDir()); err == nil {
+		t.Error("expected error for missing result.json")
+	}
+}
+
+func TestSanitizeName(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"hello-world", "hello-world"},
+		{"hello_world.v2", "hello-world-v2"},
+	}
+	for _, tt := range tests {
+		if got := sanitizeName(tt.input); got != tt.expected {
+			t.Errorf("sanitizeName(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Package report builds pivot-table summaries of a job's results, so a
+// multi-agent multi-dataset job can be read at a glance instead of scanning
+// JobResult.Results trial by trial.
+package report
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/spachava753/rollout/internal/models"
+)
+
+// Axis names what a pivot table's rows or columns are grouped by.
+type Axis string
+
+const (
+	AxisTask    Axis = "task"
+	AxisAgent   Axis = "agent"
+	AxisDataset Axis = "dataset"
+)
+
+// Cell is one pivot table cell: every trial grouped under its row and
+// column label, summarized as a mean reward and trial count. Trials with no
+// reward (e.g. a failed environment setup) are excluded from both.
+type Cell struct {
+	MeanReward float64 `json:"mean_reward"`
+	Trials     int     `json:"trials"`
+}
+
+// Pivot is a 2D table of a job's results grouped by two axes (e.g.
+// rows=tasks, cols=agents, or rows=datasets, cols=agents), for spotting
+// which combination is struggling without reading every trial individually.
+type Pivot struct {
+	RowAxis Axis     `json:"row_axis"`
+	ColAxis Axis     `json:"col_axis"`
+	Rows    []string `json:"rows"`
+	Cols    []string `json:"cols"`
+	// Cells is keyed Cells[row][col]; a missing entry means no trial in
+	// results with a reward had that (row, col) pair.
+	Cells map[string]map[string]Cell `json:"cells"`
+}
+
+// labelOf returns result's label along axis, or an error if axis is not one
+// of the Axis constants.
+func labelOf(axis Axis, result models.TrialSummary) (string, error) {
+	switch axis {
+	case AxisTask:
+		return result.TaskName, nil
+	case AxisAgent:
+		return result.AgentName, nil
+	case AxisDataset:
+		return result.DatasetName, nil
+	default:
+		return "", fmt.Errorf("unknown pivot axis %q", axis)
+	}
+}
+
+// Build groups results by rowAxis and colAxis, averaging Reward into a Cell
+// per (row, col) pair. Rows and Cols are sorted alphabetically so two
+// pivots of the same shape diff cleanly.
+func Build(results []models.TrialSummary, rowAxis, colAxis Axis) (*Pivot, error) {
+	sums := map[string]map[string]float64{}
+	counts := map[string]map[string]int{}
+	rowSet := map[string]bool{}
+	colSet := map[string]bool{}
+
+	for _, r := range results {
+		if r.Reward == nil {
+			continue
+		}
+		row, err := labelOf(rowAxis, r)
+		if err != nil {
+			return nil, err
+		}
+		col, err := labelOf(colAxis, r)
+		if err != nil {
+			return nil, err
+		}
+		rowSet[row] = true
+		colSet[col] = true
+		if sums[row] == nil {
+			sums[row] = map[string]float64{}
+			counts[row] = map[string]int{}
+		}
+		sums[row][col] += *r.Reward
+		counts[row][col]++
+	}
+
+	p := &Pivot{RowAxis: rowAxis, ColAxis: colAxis, Cells: map[string]map[string]Cell{}}
+	for row := range rowSet {
+		p.Rows = append(p.Rows, row)
+	}
+	for col := range colSet {
+		p.Cols = append(p.Cols, col)
+	}
+	sort.Strings(p.Rows)
+	sort.Strings(p.Cols)
+
+	for row, cols := range sums {
+		p.Cells[row] = map[string]Cell{}
+		for col, sum := range cols {
+			n := counts[row][col]
+			p.Cells[row][col] = Cell{MeanReward: sum / float64(n), Trials: n}
+		}
+	}
+	return p, nil
+}
+
+// RenderHTML renders p as an HTML table, each cell's background colored
+// from red (reward 0) to green (reward 1), so multi-agent multi-dataset
+// jobs are interpretable at a glance.
+func (p *Pivot) RenderHTML() string {
+	var b strings.Builder
+	b.WriteString(`<table border="1" cellpadding="4" style="border-collapse:collapse;font-family:monospace">` + "\n")
+	b.WriteString("<tr><th></th>")
+	for _, col := range p.Cols {
+		fmt.Fprintf(&b, "<th>%s</th>", html.EscapeString(col))
+	}
+	b.WriteString("</tr>\n")
+	for _, row := range p.Rows {
+		fmt.Fprintf(&b, "<tr><th>%s</th>", html.EscapeString(row))
+		for _, col := range p.Cols {
+			cell, ok := p.Cells[row][col]
+			if !ok {
+				b.WriteString("<td></td>")
+				continue
+			}
+			fmt.Fprintf(&b, `<td style="background-color:%s">%.2f (%d)</td>`, heatColor(cell.MeanReward), cell.MeanReward, cell.Trials)
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("</table>\n")
+	return b.String()
+}
+
+// heatColor maps a reward to a red-to-green CSS color, clamping to [0, 1]
+// first since most but not all tasks' rewards fall in that range.
+func heatColor(reward float64) string {
+	if reward < 0 {
+		reward = 0
+	}
+	if reward > 1 {
+		reward = 1
+	}
+	red := int(255 * (1 - reward))
+	green := int(255 * reward)
+	return fmt.Sprintf("rgb(%d,%d,0)", red, green)
+}
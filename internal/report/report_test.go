@@ -0,0 +1,58 @@
+package report
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spachava753/rollout/internal/models"
+)
+
+func reward(v float64) *float64 { return &v }
+
+func TestBuildPivot(t *testing.T) {
+	results := []models.TrialSummary{
+		{TaskName: "t1", AgentName: "a1", DatasetName: "d1", Reward: reward(1)},
+		{TaskName: "t1", AgentName: "a1", DatasetName: "d1", Reward: reward(0)},
+		{TaskName: "t1", AgentName: "a2", DatasetName: "d1", Reward: reward(1)},
+		{TaskName: "t2", AgentName: "a1", DatasetName: "d1", Reward: nil},
+	}
+
+	p, err := Build(results, AxisTask, AxisAgent)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	cell := p.Cells["t1"]["a1"]
+	if cell.Trials != 2 {
+		t.Errorf("expected 2 trials for t1/a1, got %d", cell.Trials)
+	}
+	if cell.MeanReward != 0.5 {
+		t.Errorf("expected mean reward 0.5 for t1/a1, got %v", cell.MeanReward)
+	}
+
+	if _, ok := p.Cells["t2"]["a1"]; ok {
+		t.Error("expected t2/a1 to be excluded: its only trial has no reward")
+	}
+}
+
+func TestBuildPivotUnknownAxis(t *testing.T) {
+	results := []models.TrialSummary{{TaskName: "t1", AgentName: "a1", Reward: reward(1)}}
+	if _, err := Build(results, Axis("bogus"), AxisAgent); err == nil {
+		t.Error("expected error for unknown axis")
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	p, err := Build([]models.TrialSummary{
+		{TaskName: "t1", AgentName: "a1", Reward: reward(1)},
+	}, AxisTask, AxisAgent)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	out := p.RenderHTML()
+	for _, want := range []string{"<table", "t1", "a1", "1.00 (1)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("rendered HTML missing %q: %s", want, out)
+		}
+	}
+}
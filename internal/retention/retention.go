@@ -0,0 +1,152 @@
+// Package retention prunes or archives old job result directories under a
+// job's jobs_dir, implementing the keep-N-most-recent-per-name policy
+// configured by models.RetentionConfig.
+package retention
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spachava753/rollout/internal/models"
+)
+
+// Result summarizes what Apply did.
+type Result struct {
+	Archived int
+	Removed  int
+}
+
+// Apply prunes job directories under jobsDir according to policy, grouping
+// them by the job name recorded in each directory's config.json (falling
+// back to the directory name itself for jobs run without an explicit
+// name). Within each group, the KeepRecent most recently modified
+// directories are left alone; the rest are archived to policy.ArchiveDir (if
+// set) and removed.
+func Apply(jobsDir string, policy models.RetentionConfig) (Result, error) {
+	var result Result
+	if policy.KeepRecent <= 0 {
+		return result, nil
+	}
+
+	entries, err := os.ReadDir(jobsDir)
+	if err != nil {
+		return result, fmt.Errorf("reading jobs dir: %w", err)
+	}
+
+	groups := make(map[string][]jobDir)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(jobsDir, entry.Name())
+		info, err := entry.Info()
+		if err != nil {
+			slog.Warn("skipping job dir with unreadable info", "path", path, "error", err)
+			continue
+		}
+		name := jobNameOf(path, entry.Name())
+		groups[name] = append(groups[name], jobDir{path: path, modTime: info.ModTime()})
+	}
+
+	for name, dirs := range groups {
+		sort.Slice(dirs, func(i, j int) bool { return dirs[i].modTime.After(dirs[j].modTime) })
+		if len(dirs) <= policy.KeepRecent {
+			continue
+		}
+
+		for _, d := range dirs[policy.KeepRecent:] {
+			if policy.ArchiveDir != "" {
+				if err := archive(d.path, policy.ArchiveDir); err != nil {
+					return result, fmt.Errorf("archiving %s: %w", d.path, err)
+				}
+				result.Archived++
+			}
+			if err := os.RemoveAll(d.path); err != nil {
+				return result, fmt.Errorf("removing %s: %w", d.path, err)
+			}
+			result.Removed++
+			slog.Debug("pruned job directory", "name", name, "path", d.path)
+		}
+	}
+
+	return result, nil
+}
+
+type jobDir struct {
+	path    string
+	modTime time.Time
+}
+
+// jobNameOf returns the job name recorded in path's config.json, falling
+// back to fallback (the directory's own name) if config.json is missing,
+// unreadable, or has no name set.
+func jobNameOf(path, fallback string) string {
+	data, err := os.ReadFile(filepath.Join(path, "config.json"))
+	if err != nil {
+		return fallback
+	}
+	var cfg models.JobConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fallback
+	}
+	if cfg.Name != nil && *cfg.Name != "" {
+		return *cfg.Name
+	}
+	return fallback
+}
+
+// archive writes dir as a gzip-compressed tarball named after dir's base
+// name into archiveDir.
+func archive(dir, archiveDir string) error {
+	if err := os.MkdirAll(archiveDir, 0755); err != nil {
+		return fmt.Errorf("creating archive dir: %w", err)
+	}
+
+	tarballPath := filepath.Join(archiveDir, filepath.Base(dir)+".tar.gz")
+	f, err := os.Create(tarballPath)
+	if err != nil {
+		return fmt.Errorf("creating archive file: %w", err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	defer gz.Close()
+	tw := tar.NewWriter(gz)
+	defer tw.Close()
+
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(filepath.Dir(dir), path)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = relPath
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tw, file)
+		return err
+	})
+}
@@ -0,0 +1,111 @@
+package retention
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spachava753/rollout/internal/models"
+)
+
+func makeJobDir(t *testing.T, jobsDir, dirName string, name *string, modTime time.Time) string {
+	t.Helper()
+	path := filepath.Join(jobsDir, dirName)
+	if err := os.MkdirAll(path, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	cfg := models.JobConfig{Name: name}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("marshal config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(path, "config.json"), data, 0644); err != nil {
+		t.Fatalf("write config.json: %v", err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	return path
+}
+
+func TestApplyKeepsMostRecentPerName(t *testing.T) {
+	jobsDir := t.TempDir()
+	name := "nightly"
+	now := time.Now()
+
+	oldest := makeJobDir(t, jobsDir, "run-1", &name, now.Add(-3*time.Hour))
+	middle := makeJobDir(t, jobsDir, "run-2", &name, now.Add(-2*time.Hour))
+	newest := makeJobDir(t, jobsDir, "run-3", &name, now.Add(-1*time.Hour))
+
+	result, err := Apply(jobsDir, models.RetentionConfig{KeepRecent: 2})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if result.Removed != 1 {
+		t.Errorf("expected 1 removed, got %d", result.Removed)
+	}
+	if _, err := os.Stat(oldest); !os.IsNotExist(err) {
+		t.Errorf("expected oldest run to be removed")
+	}
+	if _, err := os.Stat(middle); err != nil {
+		t.Errorf("expected middle run to remain: %v", err)
+	}
+	if _, err := os.Stat(newest); err != nil {
+		t.Errorf("expected newest run to remain: %v", err)
+	}
+}
+
+func TestApplyArchivesBeforeRemoving(t *testing.T) {
+	jobsDir := t.TempDir()
+	archiveDir := t.TempDir()
+	name := "nightly"
+	now := time.Now()
+
+	makeJobDir(t, jobsDir, "run-1", &name, now.Add(-2*time.Hour))
+	makeJobDir(t, jobsDir, "run-2", &name, now.Add(-1*time.Hour))
+
+	result, err := Apply(jobsDir, models.RetentionConfig{KeepRecent: 1, ArchiveDir: archiveDir})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if result.Archived != 1 {
+		t.Errorf("expected 1 archived, got %d", result.Archived)
+	}
+	if _, err := os.Stat(filepath.Join(archiveDir, "run-1.tar.gz")); err != nil {
+		t.Errorf("expected archive tarball for run-1: %v", err)
+	}
+}
+
+func TestApplyDisabledWhenKeepRecentIsZero(t *testing.T) {
+	jobsDir := t.TempDir()
+	name := "nightly"
+	makeJobDir(t, jobsDir, "run-1", &name, time.Now())
+
+	result, err := Apply(jobsDir, models.RetentionConfig{})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if result.Removed != 0 || result.Archived != 0 {
+		t.Errorf("expected no-op when KeepRecent is zero, got %+v", result)
+	}
+}
+
+func TestApplyGroupsSeparatelyByName(t *testing.T) {
+	jobsDir := t.TempDir()
+	nameA := "job-a"
+	nameB := "job-b"
+	now := time.Now()
+
+	makeJobDir(t, jobsDir, "a-run-1", &nameA, now.Add(-1*time.Hour))
+	makeJobDir(t, jobsDir, "b-run-1", &nameB, now.Add(-1*time.Hour))
+
+	result, err := Apply(jobsDir, models.RetentionConfig{KeepRecent: 1})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if result.Removed != 0 {
+		t.Errorf("expected both distinct-named jobs to be kept, got %d removed", result.Removed)
+	}
+}
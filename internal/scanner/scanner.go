@@ -0,0 +1,139 @@
+// Package scanner shells out to docker (for image size) and a vulnerability
+// scanner CLI, trivy or grype (for CVE counts), to summarize a built or
+// pulled task image. Neither tool is vendored as a Go dependency; both must
+// already be installed on the host running the job, the same assumption the
+// docker provider makes about the docker CLI itself.
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Report summarizes an image's size and known vulnerabilities by severity.
+type Report struct {
+	SizeBytes int64
+	Critical  int
+	High      int
+	Medium    int
+	Low       int
+}
+
+// Scan inspects imageRef's size in the local docker image store and counts
+// its CVEs with scannerName ("trivy" or "grype"; defaults to "trivy" when
+// empty). imageRef must already be present in the local docker image store,
+// which holds for the docker provider against a local daemon but not for
+// ssh (which pulls on the remote host), containerd (which pulls into its own
+// store), or remote providers like fargate or cloudrun.
+func Scan(ctx context.Context, scannerName, imageRef string) (Report, error) {
+	var report Report
+
+	size, err := imageSizeBytes(ctx, imageRef)
+	if err != nil {
+		return report, fmt.Errorf("inspecting image size: %w", err)
+	}
+	report.SizeBytes = size
+
+	counts, err := vulnerabilityCounts(ctx, scannerName, imageRef)
+	if err != nil {
+		return report, fmt.Errorf("scanning for vulnerabilities: %w", err)
+	}
+	report.Critical = counts.Critical
+	report.High = counts.High
+	report.Medium = counts.Medium
+	report.Low = counts.Low
+	return report, nil
+}
+
+func imageSizeBytes(ctx context.Context, imageRef string) (int64, error) {
+	out, err := exec.CommandContext(ctx, "docker", "image", "inspect", "--format", "{{.Size}}", imageRef).Output()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+}
+
+type severityCounts struct {
+	Critical, High, Medium, Low int
+}
+
+// trivyReport covers the subset of `trivy image --format json` this package
+// reads.
+type trivyReport struct {
+	Results []struct {
+		Vulnerabilities []struct {
+			Severity string `json:"Severity"`
+		} `json:"Vulnerabilities"`
+	} `json:"Results"`
+}
+
+// grypeReport covers the subset of `grype -o json` this package reads.
+type grypeReport struct {
+	Matches []struct {
+		Vulnerability struct {
+			Severity string `json:"severity"`
+		} `json:"vulnerability"`
+	} `json:"matches"`
+}
+
+func vulnerabilityCounts(ctx context.Context, scannerName, imageRef string) (severityCounts, error) {
+	if scannerName == "" {
+		scannerName = "trivy"
+	}
+
+	var counts severityCounts
+	switch scannerName {
+	case "trivy":
+		out, err := exec.CommandContext(ctx, "trivy", "image", "--format", "json", "--quiet", imageRef).Output()
+		if err != nil {
+			return counts, err
+		}
+		var report trivyReport
+		if err := json.Unmarshal(out, &report); err != nil {
+			return counts, fmt.Errorf("parsing trivy output: %w", err)
+		}
+		for _, result := range report.Results {
+			for _, v := range result.Vulnerabilities {
+				switch v.Severity {
+				case "CRITICAL":
+					counts.Critical++
+				case "HIGH":
+					counts.High++
+				case "MEDIUM":
+					counts.Medium++
+				case "LOW":
+					counts.Low++
+				}
+			}
+		}
+		return counts, nil
+	case "grype":
+		out, err := exec.CommandContext(ctx, "grype", imageRef, "-o", "json").Output()
+		if err != nil {
+			return counts, err
+		}
+		var report grypeReport
+		if err := json.Unmarshal(out, &report); err != nil {
+			return counts, fmt.Errorf("parsing grype output: %w", err)
+		}
+		for _, m := range report.Matches {
+			switch m.Vulnerability.Severity {
+			case "Critical":
+				counts.Critical++
+			case "High":
+				counts.High++
+			case "Medium":
+				counts.Medium++
+			case "Low":
+				counts.Low++
+			}
+		}
+		return counts, nil
+	default:
+		return counts, fmt.Errorf("unsupported scanner %q (supported: trivy, grype)", scannerName)
+	}
+}